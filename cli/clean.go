@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+)
+
+// cleanOptions holds the scopes selected for the 'clean' command.
+type cleanOptions struct {
+	state   bool // Remove the state file and start the analysis from scratch.
+	mutants bool // Remove the mutants directory and gambit's output next to it.
+	llm     bool // Clear the LLM progress and outcomes but keep the slaying results.
+	config  bool // Remove the gambit config json file.
+	restore bool // Restore original sources from the '.sol.bak' backups.
+	yes     bool // Skip the confirmation prompt.
+}
+
+func (o cleanOptions) anySelected() bool {
+	return o.state || o.mutants || o.llm || o.config || o.restore
+}
+
+// cleanAction is a single step of the clean command. The description is
+// shown to the user before asking for confirmation.
+type cleanAction struct {
+	description string
+	run         func() (string, error) // Returns a report line on success.
+}
+
+func parseCleanFlags(args []string) (cleanOptions, error) {
+	var opts cleanOptions
+
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	fs.BoolVar(&opts.state, "state", false, fmt.Sprintf("Reset the analysis state by removing '%s' (or '%s' with --state-store log).", stateFileName, stateLogFileName))
+	fs.BoolVar(&opts.mutants, "mutants", false, "Remove the mutants directory with all generated mutants, and gambit's results next to it.")
+	fs.BoolVar(&opts.llm, "llm", false, "Clear the LLM analysis progress and outcomes but keep the slaying results.")
+	fs.BoolVar(&opts.config, "config", false, "Remove the gambit config json file.")
+	fs.BoolVar(&opts.restore, "restore", false, "Put back original sources that were left mutated by an interrupted run.")
+	fs.BoolVar(&opts.yes, "yes", false, "Don't ask for confirmation before deleting.")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("Unexpected arguments for clean: %v", fs.Args())
+	}
+
+	return opts, nil
+}
+
+// runClean implements 'checkmate clean'. It plans all actions for the selected
+// scopes, asks for confirmation, executes them and reports what was removed.
+func runClean(p *Program) error {
+	opts, err := parseCleanFlags(p.commandArgs)
+	if err != nil {
+		return err
+	}
+	if !opts.anySelected() {
+		return fmt.Errorf("Nothing to clean. Select at least one scope: --state, --mutants, --llm, --config or --restore.")
+	}
+	// The backups are next to the sources, in the contracts folder of the framework.
+	if opts.restore {
+		if err := resolveFramework(p); err != nil {
+			return err
+		}
+	}
+
+	actions, err := planCleanActions(p, opts)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		fmt.Println("[Info] Nothing to clean, the selected scopes are already clean.")
+		return nil
+	}
+
+	fmt.Println("[Info] Checkmate will perform the following actions:")
+	for _, action := range actions {
+		fmt.Printf("  - %s\n", action.description)
+	}
+
+	if !opts.yes && !confirm(os.Stdin, "Proceed?") {
+		fmt.Println("[Info] Clean aborted. Nothing was changed.")
+		return nil
+	}
+
+	var report []string
+	for _, action := range actions {
+		line, err := action.run()
+		if err != nil {
+			printCleanReport(report)
+			return err
+		}
+		report = append(report, line)
+	}
+
+	printCleanReport(report)
+	return nil
+}
+
+func planCleanActions(p *Program, opts cleanOptions) ([]cleanAction, error) {
+	var actions []cleanAction
+
+	// Restore goes first so that the sources are put back before the mutants
+	// (and with them any trace of what was mutated) are removed.
+	if opts.restore {
+		backups := findSourceBackups(p)
+		if len(backups) > 0 {
+			actions = append(actions, cleanAction{
+				description: fmt.Sprintf("Restore %d source file(s) from '.bak' backups: %s", len(backups), strings.Join(backups, ", ")),
+				run: func() (string, error) {
+					restored := checkForAndRestoreInterruptedState(p)
+					if len(restored) != len(backups) {
+						return "", fmt.Errorf("Restored %d out of %d source files, manual check required.", len(restored), len(backups))
+					}
+					return fmt.Sprintf("Restored: %s", strings.Join(restored, ", ")), nil
+				},
+			})
+		}
+	}
+
+	if opts.mutants {
+		action, err := planRemoval(*p.mutantsDIR, "mutants directory")
+		if err != nil {
+			return nil, err
+		}
+		if action != nil {
+			actions = append(actions, *action)
+		}
+
+		// Only Gambit's own files are removed next to the mutants directory,
+		// its parent may be a source directory e.g. with --mutants-dir src/mutants.
+		outdir := gambitOutDir(p)
+		for _, output := range []struct{ name, what string }{
+			{"gambit_results.json", "gambit results"},
+			{"mutants.log", "gambit mutants log"},
+			{excludedDIR, "excluded mutants directory"},
+			{regenerateDIR, "leftover regeneration directory"},
+		} {
+			path := filepath.Join(outdir, output.name)
+			if _, err := os.Lstat(path); err != nil {
+				continue // Not every run writes all of them.
+			}
+			action, err := planRemoval(path, output.what)
+			if err != nil {
+				return nil, err
+			}
+			if action != nil {
+				actions = append(actions, *action)
+			}
+		}
+	}
+
+	if opts.config {
+		action, err := planRemoval(*p.gambitConfigPath, "gambit config")
+		if err != nil {
+			return nil, err
+		}
+		if action != nil {
+			actions = append(actions, *action)
+		}
+	}
+
+	if opts.state {
+		action, err := planRemoval(p.store.Path(), "state file")
+		if err != nil {
+			return nil, err
+		}
+		if action != nil {
+			actions = append(actions, cleanAction{
+				description: action.description,
+				run: func() (string, error) {
+					line, err := action.run()
+					if err != nil {
+						return "", err
+					}
+					p.dbState, err = p.store.Load()
+					return line, err
+				},
+			})
+		}
+	} else if opts.llm {
+		// Clearing the LLM results is pointless if the whole state is removed.
+		if count := countLLMOutcomes(&p.dbState); count > 0 || len(p.dbState.LanguageModelProgress.MutantsProcessed) > 0 {
+			actions = append(actions, cleanAction{
+				description: fmt.Sprintf("Clear %d LLM analysis outcome(s) and recommendations from '%s' (slaying results are kept)", count, p.store.Path()),
+				run: func() (string, error) {
+					cleared := clearLLMResults(&p.dbState)
+					if err := p.store.Save(&p.dbState); err != nil {
+						return "", fmt.Errorf("Failed to save the state after clearing LLM results: %w", err)
+					}
+					return fmt.Sprintf("Cleared %d LLM analysis outcome(s) from '%s'", cleared, p.store.Path()), nil
+				},
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// planRemoval returns an action removing the given path or nil if there is
+// nothing to remove. It refuses paths that point outside of the project.
+func planRemoval(path, what string) (*cleanAction, error) {
+	if err := ensureWithinProject(path); err != nil {
+		return nil, fmt.Errorf("Refusing to remove the %s: %w", what, err)
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("[Info] The %s at '%s' does not exist. Skipping.\n", what, path)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("There was a problem accessing the %s at '%s': %w", what, path, err)
+	}
+
+	description := fmt.Sprintf("Remove the %s '%s'", what, path)
+	if info.IsDir() {
+		description = fmt.Sprintf("Remove the %s '%s' with all of its content", what, path)
+	}
+
+	return &cleanAction{
+		description: description,
+		run: func() (string, error) {
+			if err := os.RemoveAll(path); err != nil {
+				return "", fmt.Errorf("Failed to remove the %s '%s': %w", what, path, err)
+			}
+			return fmt.Sprintf("Removed: %s", path), nil
+		},
+	}, nil
+}
+
+// ensureWithinProject returns an error if the path resolves to the project
+// root itself or to anything outside of it. The project root is the current
+// working directory, the same one all other checkmate paths are relative to.
+func ensureWithinProject(path string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("couldn't determine the project root: %w", err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("couldn't resolve the project root: %w", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("couldn't resolve '%s': %w", path, err)
+	}
+	// Resolve symlinks for existing paths so that a link can't point us outside.
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return fmt.Errorf("'%s' is not inside the project root '%s'", path, root)
+	}
+	if rel == "." {
+		return fmt.Errorf("'%s' is the project root", path)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("'%s' is outside of the project root '%s'", path, root)
+	}
+
+	return nil
+}
+
+// findSourceBackups lists the source files that have a leftover '.bak' backup.
+func findSourceBackups(p *Program) []string {
+	var backups []string
+	if p.contractsDIR == nil || *p.contractsDIR == "" {
+		return backups
+	}
+	if _, err := os.Stat(*p.contractsDIR); err != nil {
+		return backups
+	}
+
+	return listSourceBackups(*p.contractsDIR)
+}
+
+func countLLMOutcomes(state *db.MutationAnalysis) int {
+	count := 0
+	for _, fileData := range state.AnalyzedFiles {
+		count += len(fileData.LLMAnalysisOutcomes)
+	}
+	return count
+}
+
+// clearLLMResults removes the LLM progress, outcomes and recommendations while
+// leaving the slaying progress and statistics untouched. It returns the number
+// of outcomes that were removed.
+func clearLLMResults(state *db.MutationAnalysis) int {
+	cleared := 0
+	for path, fileData := range state.AnalyzedFiles {
+		cleared += len(fileData.LLMAnalysisOutcomes)
+		fileData.LLMAnalysisOutcomes = make(map[string]db.MutantLLMAnalysisOutcome)
+		fileData.FileSpecificRecommendations = make([]string, 0)
+		state.AnalyzedFiles[path] = fileData
+	}
+	state.LanguageModelProgress.MutantsProcessed = make(map[string]bool)
+
+	return cleared
+}
+
+// confirm asks a yes/no question and returns true only on an explicit yes.
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("\033[33m%s [y/N]: \033[0m", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printCleanReport(report []string) {
+	if len(report) == 0 {
+		fmt.Println("[Info] Nothing was removed.")
+		return
+	}
+
+	fmt.Println("\033[32m[Info] Clean summary:\033[0m")
+	for _, line := range report {
+		fmt.Printf("  - %s\n", line)
+	}
+}
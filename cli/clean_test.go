@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanCleanMutants(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	// The mutants live in the sources, so gambit's output directory is 'src'.
+	for _, path := range []string{
+		"src/Vault.sol",
+		"src/mutants/1/src/Vault.sol",
+		"src/gambit_results.json",
+		"src/mutants.log",
+		"src/excluded/2/src/Vault.sol",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mutantsDIR := "src/mutants"
+	p := &Program{mutantsDIR: &mutantsDIR}
+	actions, err := planCleanActions(p, cleanOptions{mutants: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 4 {
+		t.Fatalf("got %d actions, want 4", len(actions))
+	}
+	for _, action := range actions {
+		if _, err := action.run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{"src/mutants", "src/gambit_results.json", "src/mutants.log", "src/excluded"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected '%s' to be removed", path)
+		}
+	}
+	if _, err := os.Stat("src/Vault.sol"); err != nil {
+		t.Errorf("the sources next to the mutants were removed: %v", err)
+	}
+}
+
+func TestEnsureWithinProject(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	if err := os.Symlink(outside, "link"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"gambit_out", true},
+		{"gambit_out/mutants", true},
+		{"./src/../gambit_out", true},
+		{".", false},
+		{"src/..", false},
+		{"..", false},
+		{"../other", false},
+		{outside, false},
+		{"link", false}, // Resolves outside of the project.
+	}
+	for _, tt := range tests {
+		err := ensureWithinProject(tt.path)
+		if (err == nil) != tt.ok {
+			t.Errorf("ensureWithinProject(%q) = %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
+
+func TestRestoreBackupOfMissingSource(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	// Only the backup is left, e.g. the run was killed mid-swap.
+	if err := os.MkdirAll("src/vault", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("src/vault/Vault.sol.bak", []byte("contract Vault {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contractsDIR := "./src"
+	p := &Program{contractsDIR: &contractsDIR}
+	want := filepath.Join("src", "vault", "Vault.sol")
+	if backups := findSourceBackups(p); len(backups) != 1 || backups[0] != want {
+		t.Fatalf("expected the backup of %s, got %v", want, backups)
+	}
+	if restored := checkForAndRestoreInterruptedState(p); len(restored) != 1 || restored[0] != want {
+		t.Fatalf("expected %s to be restored, got %v", want, restored)
+	}
+
+	content, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "contract Vault {}\n" {
+		t.Fatalf("unexpected restored content %q", content)
+	}
+	if _, err := os.Stat(want + ".bak"); !os.IsNotExist(err) {
+		t.Fatal("expected the backup to be gone")
+	}
+}
@@ -1,12 +1,11 @@
 package cli
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
@@ -14,25 +13,61 @@ import (
 	"runtime/debug"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ChmielewskiKamil/checkmate/assert"
 	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/framework"
 	"github.com/ChmielewskiKamil/checkmate/llm"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
 )
 
 const (
-	stateFileName = "checkmate_analysis_state.json"
-	saveInterval  = 10 // Save state every 10 mutants tested
+	stateFileName     = "checkmate_analysis_state.json"
+	stateLogFileName  = "checkmate_analysis_state.jsonl" // State file of --state-store log.
+	stateLockFileName = "checkmate_analysis_state.lock"  // Held by the process working on the state.
+	saveInterval      = 10                               // Save state every 10 mutants tested
+	defaultMutantsDIR = "./gambit_out/mutants"
 )
 
 type Program struct {
-	testCMD          *string // The command to run the test suite e.g. 'forge test'.
+	testCMD          *string // The command to run the test suite against a mutant e.g. 'forge test --fail-fast'.
+	baselineTestCMD  string  // The command to run the whole test suite on the unmutated code e.g. 'forge test'.
 	mutantsDIR       *string // Path to the directory where generated mutants are stored.
 	gambitConfigPath *string // Path to gambit's config json file
 	skipGambit       *bool   // If you don't have or don't want to run gambit, skip it.
 	contractsDIR     *string // Path to the folder where Solidity contracts are store. Default is "src/".
 	analyzeMutations *bool   // Whether to analyze mutations with LLM or not
 	printReport      *bool   // Pretty print the mutation analysis report after all is done.
+	frameworkName    *string // Name of the framework to use instead of the detected one e.g. 'hardhat'.
+	configPath       *string // Path to checkmate's own config file e.g. './checkmate.json'.
+	mutatorName      *string // Mutant generator to use: 'auto', 'gambit' or 'native'.
+	tce              *bool   // Exclude equivalent and duplicate mutants with trivial compiler equivalence before slaying.
+	killMatrix       *bool   // Run the whole test suite against every mutant and record the tests that kill it.
+	preview          *bool   // Stop after the mutant distribution preview, before slaying.
+	storeDiffs       *bool   // Keep only the diff of each mutant and apply it in memory when testing it.
+	stateStore       *string // Backend keeping the state: 'json' or 'log'.
+
+	// config holds the settings from checkmate's config file, such as the
+	// Gambit options applied globally or per file.
+	config Config
+
+	// framework is the detected (or selected) Solidity framework. It provides
+	// the default test commands, contracts folder and import remappings.
+	framework framework.Framework
+	// buildSettings holds the source layout and compiler settings read from
+	// the framework's config files e.g. 'foundry.toml'.
+	buildSettings framework.BuildSettings
+
+	command     string   // Optional subcommand e.g. 'clean'. Empty string runs the default analysis.
+	commandArgs []string // Arguments that follow the subcommand, parsed by the subcommand itself.
+
+	// store loads and saves dbState, as selected with --state-store. It's
+	// read-only for the commands that don't change the state.
+	store db.Store
+	// lock keeps other checkmate processes from working on the state at the
+	// same time. Nil for the read-only commands.
+	lock *db.Lock
 
 	// dbState holds all persistent information, loaded from and saved to mutationAnalysisStateFile.
 	// All statistics and progress will be read from and written to this struct.
@@ -45,8 +80,20 @@ type SolidityFile struct {
 }
 
 type GambitEntry struct {
-	FilePath       string   `json:"filename"`        // File to the Solidity file from the project's root e.g. src/Counter.sol
-	SolcRemappings []string `json:"solc_remappings"` // A list of Solc compiler remappings
+	FilePath       string   `json:"filename"`                   // File to the Solidity file from the project's root e.g. src/Counter.sol
+	SolcRemappings []string `json:"solc_remappings"`            // A list of Solc compiler remappings
+	Mutations      []string `json:"mutations,omitempty"`        // Mutation operators to apply. All of them if empty.
+	Functions      []string `json:"functions,omitempty"`        // Only mutate these functions.
+	Contract       string   `json:"contract,omitempty"`         // Only mutate this contract.
+	NumMutants     int      `json:"num_mutants,omitempty"`      // Randomly sample this many mutants. All of them if 0.
+	RandomSeed     bool     `json:"random_seed,omitempty"`      // Use a random seed for sampling instead of Gambit's fixed one.
+	Solc           string   `json:"solc,omitempty"`             // Path to the solc binary to compile the file with.
+	SolcOptimize   bool     `json:"solc_optimize,omitempty"`    // Whether to run the optimizer when validating mutants.
+	SolcViaIR      bool     `json:"solc_via_ir,omitempty"`      // Whether to compile via the IR pipeline.
+	SolcBasePath   string   `json:"solc_base_path,omitempty"`   // Base path solc resolves imports from.
+	SolcAllowPaths []string `json:"solc_allow_paths,omitempty"` // Additional folders solc may read imports from e.g. libs.
+	Outdir         string   `json:"outdir,omitempty"`           // Gambit's output directory. 'gambit_out' if empty.
+	SkipValidate   bool     `json:"skip_validate,omitempty"`    // Don't compile the mutants to check that they are valid.
 }
 
 func New() *Program {
@@ -54,26 +101,148 @@ func New() *Program {
 
 	parseCmdFlags(&p)
 
+	store, err := newStore(*p.stateStore)
+	if err != nil {
+		log.Fatalf("[Critical] %v", err)
+	}
+	p.store = store
+
+	if err := lockState(&p); err != nil {
+		log.Fatalf("[Critical] %v", err)
+	}
+	// 'checkmate state' reads the state file itself, it may not load.
+	if p.command == "state" {
+		return &p
+	}
+
 	// Load existing state or initialize a new one
-	loadedState, err := db.LoadStateFromFile(stateFileName)
+	loadedState, err := p.store.Load()
 	if err != nil {
+		releaseStateLock(&p)
 		// This error means something went wrong beyond "file not found"
 		// (e.g., corrupt JSON, permissions).
-		log.Fatalf("[Critical] Error loading state from %s: %v. If the file is corrupt, please remove it to start fresh.", stateFileName, err)
+		log.Fatalf("[Critical] Error loading state from %s: %v. If the file is corrupt, please remove it to start fresh.", p.store.Path(), err)
 	}
 	p.dbState = loadedState // Assign loaded data (or fresh initialized struct if file didn't exist)
 
 	return &p
 }
 
+// resolveFramework picks the framework of the project and fills in the
+// defaults that the user didn't override with flags.
+func resolveFramework(p *Program) error {
+	if *p.frameworkName != "" {
+		fw, err := framework.ByName(*p.frameworkName)
+		if err != nil {
+			return err
+		}
+		p.framework = fw
+	} else if fw, ok := framework.Detect("."); ok {
+		p.framework = fw
+	} else {
+		p.framework = framework.Foundry{}
+		fmt.Println("[Info] Couldn't detect the framework from 'foundry.toml' or 'hardhat.config.*'. Assuming Foundry.")
+	}
+
+	// A custom test command is used for both the baseline and the mutants.
+	if *p.testCMD == "" {
+		*p.testCMD = p.framework.MutantTestCommand()
+		p.baselineTestCMD = p.framework.BaselineTestCommand()
+	} else {
+		p.baselineTestCMD = *p.testCMD
+	}
+
+	settings, err := p.framework.BuildSettings(".")
+	if err != nil {
+		return err
+	}
+	for _, warning := range settings.Warnings {
+		fmt.Printf("\033[33m[Warning] %s\033[0m\n", warning)
+	}
+	p.buildSettings = settings
+
+	if *p.contractsDIR == "" {
+		*p.contractsDIR = "./" + filepath.ToSlash(settings.SourceDir)
+	}
+
+	return nil
+}
+
+// needsFramework reports whether the command runs the tests or works on the
+// sources. The others only read or remove what checkmate wrote, they work
+// without the framework, e.g. with a broken 'foundry.toml'.
+func needsFramework(p *Program) bool {
+	switch p.command {
+	case "", "watch", "serve", "worker", "import":
+		return !*p.printReport
+	}
+	return false
+}
+
+// resolveConfig loads checkmate's config file. A custom Gambit output
+// directory moves the default mutants directory with it.
+func resolveConfig(p *Program) error {
+	config, err := loadConfig(*p.configPath)
+	if err != nil {
+		return err
+	}
+	p.config = config
+
+	if outdir := config.Gambit.Outdir; outdir != "" && *p.mutantsDIR == defaultMutantsDIR {
+		*p.mutantsDIR = "./" + filepath.ToSlash(filepath.Join(outdir, "mutants"))
+	}
+
+	return nil
+}
+
 func Run(p *Program) (err error) {
+	// Deferred first, so that it runs after the final save.
+	defer releaseStateLock(p)
+
+	if needsFramework(p) {
+		if err := resolveFramework(p); err != nil {
+			return err
+		}
+	}
+	if err := resolveConfig(p); err != nil {
+		return err
+	}
+
+	// --- Subcommands ---
+	// Subcommands manage the state on their own, so they are dispatched
+	// before the deferred state save below.
+	switch p.command {
+	case "":
+		// No subcommand, continue with the default analysis.
+	case "clean":
+		return runClean(p)
+	case "watch":
+		return runWatch(p)
+	case "serve":
+		return runServe(p)
+	case "worker":
+		return runWorker(p)
+	case "import":
+		return runImport(p)
+	case "higher-order":
+		return runHigherOrder(p)
+	case "state":
+		return runState(p)
+	case "status":
+		return runStatus(p)
+	case "mutants":
+		return runMutants(p)
+	default:
+		return fmt.Errorf("Unknown command: '%s'. Available commands: clean, watch, serve, worker, import, higher-order, state, status, mutants.", p.command)
+	}
+
 	var exitedForSpecialReason bool = false
 	// Attempt to save state on exit, especially if an error occurs.
 	// This is a best-effort save. A more robust solution might involve signal handling.
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Fprintf(os.Stderr, "\033[31m[CRITICAL] Panic occurred: %v. Attempting to save state...\033[0m\n", r)
-			saveErr := db.SaveStateToFile(stateFileName, &p.dbState)
+			saveErr := p.store.Save(&p.dbState)
 			if saveErr != nil {
 				fmt.Fprintf(os.Stderr, "\033[31m[Error] Failed to save state during panic: %v\033[0m\n", saveErr)
 			} else {
@@ -97,9 +266,9 @@ func Run(p *Program) (err error) {
 
 		fmt.Printf("[Info] Attempting to save %s...\n", actionMessage)
 
-		saveErr := db.SaveStateToFile(stateFileName, &p.dbState)
+		saveErr := p.store.Save(&p.dbState)
 		if saveErr != nil {
-			fmt.Fprintf(os.Stderr, "\033[31m[Error] Failed to save state to %s: %v\033[0m\n", stateFileName, saveErr)
+			fmt.Fprintf(os.Stderr, "\033[31m[Error] Failed to save state to %s: %v\033[0m\n", p.store.Path(), saveErr)
 			if err == nil {
 				err = fmt.Errorf("failed to save final state: %w", saveErr)
 			}
@@ -116,11 +285,13 @@ func Run(p *Program) (err error) {
 	if *p.printReport {
 		if p.dbState.OverallStats.MutantsTotalGenerated == 0 && len(p.dbState.AnalyzedFiles) == 0 {
 			fmt.Println("\033[33m[Warning] No analysis data found in state file. Nothing to print.\033[0m")
-			fmt.Printf("[Info] State file used: %s\n", stateFileName)
+			fmt.Printf("[Info] State file used: %s\n", p.store.Path())
+			exitedForSpecialReason = true
 			return nil
 		}
 		fmt.Println("--- Checkmate Analysis Report ---")
 		printMutationStatsReport(p)
+		printSubsumptionReport(p)
 		printLLMRecommendationsReport(p)
 		printLLMAnalysisErrorsReport(p)
 		fmt.Println("--- End of Report ---")
@@ -133,10 +304,10 @@ func Run(p *Program) (err error) {
 		fmt.Println("[Info] LLM Analysis mode selected.")
 
 		llmErr := llm.AnalyzeMutations(
-			*p.mutantsDIR,      // Path to the mutants directory (e.g., ./gambit_out/mutants)
-			&p.dbState,         // Pointer to the persistent state object
-			db.SaveStateToFile, // The actual save function
-			stateFileName,      // The name of the state file
+			*p.mutantsDIR, // Path to the mutants directory (e.g., ./gambit_out/mutants)
+			&p.dbState,    // Pointer to the persistent state object
+			func(_ string, data *db.MutationAnalysis) error { return p.store.Save(data) }, // The actual save function
+			p.store.Path(), // The name of the state file
 		)
 		if llmErr != nil {
 			return fmt.Errorf("LLM analysis failed: %w", llmErr) // Propagate error
@@ -148,6 +319,13 @@ func Run(p *Program) (err error) {
 
 	// Actions
 	// ---- Slaying Mode ----
+	// TODO: If the run was stopped and now resumed, there is most likely the
+	// backup file in the contracts folder and the original file might contain
+	// the mutation.
+	// Implement a more robust backup of the OG file or simply restore the
+	// backup here.
+	checkForAndRestoreInterruptedState(p)
+
 	gambitWasRunThisSession := false
 	if !mutantsExist(p) && !*p.skipGambit {
 		if !gambitConfigExists(p) {
@@ -155,16 +333,34 @@ func Run(p *Program) (err error) {
 			if err != nil {
 				return err
 			}
-			fmt.Println("\033[33m[Info] Generated gambit config successfuly.\n       Please review it and remove any files that you don't intend to test e.g. interfaces.\n       This will speed up the time it takes for gambit to generate the mutants and later\n       to run the analysis. After that re-run checkmate.\033[0m")
+			fmt.Println("\033[33m[Info] Generated gambit config successfuly.\n       Please review it and remove any other files that you don't intend to test.\n       This will speed up the time it takes for gambit to generate the mutants and later\n       to run the analysis. After that re-run checkmate.\033[0m")
 			exitedForSpecialReason = true
 			return nil
 		}
 
-		// TODO: Before running Gambit ensure that the Solidity compiler version is
-		// set to correct version.
+		if err := ensureGambitCompilers(p); err != nil {
+			return err
+		}
 
-		runGambit(p) // This generates mutants
+		mutator, err := selectMutator(*p.mutatorName)
+		if err != nil {
+			return err
+		}
+		if err := mutator.Generate(p); err != nil { // This generates mutants
+			return err
+		}
+		if err := generateExtraMutants(p); err != nil {
+			return err
+		}
 		gambitWasRunThisSession = true
+		if err := recordFingerprint(p); err != nil {
+			return err
+		}
+	} else if err := refreshStaleMutants(p); err != nil {
+		return err
+	}
+	if err := storeMutantsAsDiffs(p); err != nil {
+		return err
 	}
 
 	var generatedCountBeforeInitialization int32
@@ -173,6 +369,9 @@ func Run(p *Program) (err error) {
 	}
 
 	initializeGeneratedMutantStats(p)
+	if err := syncMutantRecords(p); err != nil {
+		return err
+	}
 
 	var baselineEstablishedThisSession bool
 	if gambitWasRunThisSession {
@@ -187,7 +386,7 @@ func Run(p *Program) (err error) {
 
 	if baselineEstablishedThisSession {
 		fmt.Println("[Info] Saving baseline mutant statistics...")
-		saveErr := db.SaveStateToFile(stateFileName, &p.dbState)
+		saveErr := p.store.Save(&p.dbState)
 		if saveErr != nil {
 			log.Printf("[Warning] Failed to save baseline state after populating/refreshing stats: %v\n", saveErr)
 		} else {
@@ -195,22 +394,27 @@ func Run(p *Program) (err error) {
 		}
 	}
 
-	fmt.Printf("[Info] Loaded analysis state from %s. Overall Mutants Generated: %d\n",
-		stateFileName, p.dbState.OverallStats.MutantsTotalGenerated)
+	if *p.tce {
+		if err := runTCE(p); err != nil {
+			return err
+		}
+	}
 
-	// TODO: If the run was stopped and now resumed, there is most likely the
-	// backup file in the contracts folder and the original file might contain
-	// the mutation.
-	// Implement a more robust backup of the OG file or simply restore the
-	// backup here.
-	checkForAndRestoreInterruptedState(p)
+	fmt.Printf("[Info] Loaded analysis state from %s. Overall Mutants Generated: %d\n",
+		p.store.Path(), p.dbState.OverallStats.MutantsTotalGenerated)
 
 	fmt.Println("[Info] Attempting an initial test run to check if your test suite is ready for the mutation analysis.")
+	baselineStart := time.Now()
 	if !testSuitePasses(p, true) {
 		return fmt.Errorf(`Your test suite fails the initial run.
         The test suite must be passing when the code is not mutated yet!
         Ensure that you have no failing tests before you attempt mutation testing your code.`)
 	}
+	printMutantDistribution(p, time.Since(baselineStart))
+	if *p.preview {
+		fmt.Println("[Info] Preview only, re-run checkmate without --preview to slay the mutants.")
+		return nil
+	}
 
 	printMutationStats(p)
 
@@ -230,7 +434,7 @@ func Run(p *Program) (err error) {
 func initializeGeneratedMutantStats(p *Program) {
 	fmt.Println("[Info] Initializing mutant stats in persistent state...")
 
-	mutants := listSolidityFiles(*p.mutantsDIR)
+	mutants := listMutants(*p.mutantsDIR)
 
 	if len(mutants) == 0 && p.dbState.OverallStats.MutantsTotalGenerated == 0 {
 		fmt.Println("\033[33m[Warning] No mutants found in directory and no prior state. Nothing to initialize.\033[0m")
@@ -325,12 +529,12 @@ func parseCmdFlags(p *Program) {
 
 	testCMD := flag.String(
 		"test-command",
-		"forge test --fail-fast",
-		"Specify the command to run your test suite. For hardhat repos, you can use 'npx hardhat test --bail'.")
+		"",
+		"Specify the command to run your test suite. Defaults to the detected framework's command e.g. 'forge test --fail-fast' or 'npx hardhat test --bail'.")
 
 	mutantsDIR := flag.String(
 		"mutants-dir",
-		"./gambit_out/mutants",
+		defaultMutantsDIR,
 		"Specify the path to the mutants directory.")
 
 	skipGambit := flag.Bool(
@@ -347,8 +551,26 @@ func parseCmdFlags(p *Program) {
 
 	contractFilesPath := flag.String(
 		"contracts-path",
-		"./src",
-		"Specify the path to the folder with your smart contracts. For hardhat repositories this is usually './contracts'.",
+		"",
+		"Specify the path to the folder with your smart contracts. Defaults to the detected framework's folder e.g. './src' or './contracts'.",
+	)
+
+	frameworkName := flag.String(
+		"framework",
+		"",
+		"Specify the framework of your project ('foundry' or 'hardhat'). By default it's detected from 'foundry.toml' or 'hardhat.config.*'.",
+	)
+
+	configPath := flag.String(
+		"checkmate-config",
+		"./checkmate.json",
+		"Specify the path to checkmate's own config file with the Gambit settings applied globally or per file.",
+	)
+
+	mutatorName := flag.String(
+		"mutator",
+		"auto",
+		"Specify the mutant generator: 'gambit', 'native' (built into checkmate, no Rust toolchain needed) or 'auto' to use Gambit if it's installed.",
 	)
 
 	analyzeMutations := flag.Bool(
@@ -357,6 +579,35 @@ func parseCmdFlags(p *Program) {
 		"Analyze the mutations present in the gambit_out/mutants/ directory with the help of an LLM.",
 	)
 
+	tce := flag.Bool(
+		"tce",
+		false,
+		"Compile the mutants with solc before slaying and exclude the ones whose optimized bytecode equals the original's (equivalent) or another mutant's (duplicate).",
+	)
+
+	killMatrix := flag.Bool(
+		"kill-matrix",
+		false,
+		"Run the whole test suite against every mutant instead of stopping at the first failure, and record which tests kill it. Slower, but the report then shows the subsumption analysis. A custom --test-command replaces the whole suite command of the framework, so it must not stop at the first failure either.",
+	)
+	preview := flag.Bool(
+		"preview",
+		false,
+		"Print the distribution of the mutants by file, operator and function with the estimated slaying time, then exit before slaying them.",
+	)
+
+	storeDiffs := flag.Bool(
+		"store-diffs",
+		false,
+		"Keep only the diff of each mutant against its original file instead of a full copy, and apply it in memory when the mutant is tested. Cuts the disk use of big protocols.",
+	)
+
+	stateStore := flag.String(
+		"state-store",
+		db.JSONStoreName,
+		"Specify how the analysis state is kept: 'json' rewrites a single JSON file on every save, 'log' appends the changes to a log, which is faster for thousands of mutants.",
+	)
+
 	printReport := flag.Bool("print", false, "Print a summary report from the last analysis state and exit.")
 
 	flag.Parse()
@@ -383,18 +634,40 @@ func parseCmdFlags(p *Program) {
 	p.contractsDIR = contractFilesPath
 	p.analyzeMutations = analyzeMutations
 	p.printReport = printReport
+	p.frameworkName = frameworkName
+	p.configPath = configPath
+	p.mutatorName = mutatorName
+	p.tce = tce
+	p.killMatrix = killMatrix
+	p.preview = preview
+	p.storeDiffs = storeDiffs
+	p.stateStore = stateStore
+
+	// Everything after the first non-flag argument belongs to a subcommand
+	// e.g. 'checkmate clean --mutants'.
+	if flag.NArg() > 0 {
+		p.command = flag.Arg(0)
+		p.commandArgs = flag.Args()[1:]
+	}
 
 	// Post-conditions
 	// TODO: Gambit config should be a valid json file
 }
 
+// gambitOutDir returns Gambit's output directory. Gambit writes the mutants to
+// '<outdir>/mutants' and gambit_results.json, mutants.log and the rest of its
+// output to '<outdir>', so it's the parent of the mutants directory.
+func gambitOutDir(p *Program) string {
+	return filepath.Dir(filepath.Clean(*p.mutantsDIR))
+}
+
 func mutantsExist(p *Program) bool {
 	if _, err := os.Stat(*p.mutantsDIR); err != nil {
 		fmt.Printf("[Info] Mutants directory at: '%s' does not exist.\n", *p.mutantsDIR)
 		return false
 	}
 
-	if len(listSolidityFiles(*p.mutantsDIR)) == 0 {
+	if len(listMutants(*p.mutantsDIR)) == 0 {
 		fmt.Printf("[Info] The mutants directory at: '%s' exists but it DOES NOT contain any Solidity files.\n", *p.mutantsDIR)
 		return false
 	}
@@ -426,11 +699,21 @@ func gambitConfigExists(p *Program) bool {
 func generateGambitConfig(p *Program) error {
 	// Pre-conditions
 	assert.PathNotExists(*p.gambitConfigPath)
-	assert.PathExists(*p.contractsDIR)
+	if _, err := os.Stat(*p.contractsDIR); err != nil {
+		return fmt.Errorf("The contracts folder '%s' is not accessible: %w. Set it with --contracts-path or 'src' in foundry.toml.", *p.contractsDIR, err)
+	}
 
 	// Actions
-	solidityFiles := listSolidityFiles(*p.contractsDIR)
-	gambitEntries, err := generateGambitEntries(solidityFiles)
+	solidityFiles, excluded, err := selectSolidityFiles(p, listSolidityFiles(*p.contractsDIR))
+	if err != nil {
+		return err
+	}
+	printExcludedFiles(excluded)
+	if len(solidityFiles) == 0 {
+		return fmt.Errorf("All Solidity files in '%s' were excluded, there is nothing to mutate. Review 'include', 'exclude' and 'auto_exclude' in %s.", *p.contractsDIR, *p.configPath)
+	}
+
+	gambitEntries, err := generateGambitEntries(p, solidityFiles)
 	if err != nil {
 		return fmt.Errorf("[Error] Couldn't generate gambit entires: %s", err)
 	}
@@ -457,124 +740,51 @@ func generateGambitConfig(p *Program) error {
 	return nil
 }
 
-func runGambit(p *Program) {
-	assert.PathExists(*p.gambitConfigPath)
-	assert.NotEmpty(*p.gambitConfigPath)
-
-	cmd := exec.Command("gambit", "mutate", "--json", *p.gambitConfigPath)
-
-	stderrPipe, _ := cmd.StderrPipe()
-
-	// Buffer to capture stderr to detect errors later
-	stderrScanner := bufio.NewScanner(stderrPipe)
-
-	errDetected := make(chan struct{})
-	var snippet []string
-	const snippetLines = 5 // length of solc compiler version mismatch error
-	linesAfterMatch := 0
-	var errorPrinted bool
-
-	// Start a goroutine to scan stderr and capture the error
-	go func() {
-		for stderrScanner.Scan() {
-			line := stderrScanner.Text()
-
-			// If we detect an error in stderr
-			if strings.Contains(line, "compiler returned exit code") ||
-				strings.Contains(line, "Source file requires different compiler version") {
-
-				// Collect the error snippet
-				if !errorPrinted {
-					// If it's the first match, print the error and collect further lines
-					snippet = append(snippet, line)
-					linesAfterMatch = 0
-					errorPrinted = true
-				} else {
-					// Continue collecting the error lines
-					snippet = append(snippet, line)
-				}
-			} else if len(snippet) > 0 && linesAfterMatch < snippetLines {
-				// Collect the following lines for the snippet
-				snippet = append(snippet, line)
-				linesAfterMatch++
-				if linesAfterMatch >= snippetLines {
-					// Stop reading and signal fatal error
-					errDetected <- struct{}{}
-					return
-				}
-			}
-		}
-	}()
-
-	// Start the process
-	if err := cmd.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "\033[91m[Error] Failed to start gambit: %v\033[0m\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("\033[92m[Info] Mutating the code with gambit, please wait...\n       This might take a while for bigger projects (e.g. over 15 minutes).\033[0m\n")
-
-	// Use select block to either handle the error or continue execution
-	select {
-	case <-errDetected:
-		// Handle error when detected
-		fmt.Fprintln(os.Stderr, "\n\033[91m[Error] Solidity compilation failed during mutation.\033[0m")
-		fmt.Fprintln(os.Stderr, "\033[93m[Hint] Your local Solidity compiler (solc) version may not match the pragma version declared in your contracts.\033[0m")
-		fmt.Fprintln(os.Stderr, "\033[93m[Hint] You can install and switch compiler versions using solc-select:\033[0m")
-		fmt.Fprintln(os.Stderr, "\033[93m    pip install solc-select\033[0m")
-		fmt.Fprintln(os.Stderr, "\033[93m    solc-select install 0.8.23   # change to correct version\033[0m")
-		fmt.Fprintln(os.Stderr, "\033[93m    solc-select use 0.8.23       # change to correct version\033[0m")
-		fmt.Fprintln(os.Stderr, "\n\033[91m[Error] The compiler error snippet is shown below: \033[0m")
-		for _, l := range snippet {
-			fmt.Fprintln(os.Stderr, l)
-		}
-
-		_ = cmd.Process.Kill()
-		os.Exit(1)
-
-	case err := <-waitForCmd(cmd):
-		if err != nil {
-			// Handle Gambit process exit error
-			fmt.Fprintf(os.Stderr, "\033[91m[Error] Gambit exited with error: %v\033[0m\n", err)
-			os.Exit(1)
-		}
-	}
-
-	// If no error detected, print the success message
-	fmt.Println("\n[Info] Mutants generated ✅")
-	assert.NotEmpty(*p.mutantsDIR)
-	assert.True(len(listSolidityFiles(*p.mutantsDIR)) > 0, "There are no Solidity files in the mutants directory after running 'gambit mutate'.")
-}
-
-// waitForCmd wraps cmd.Wait() so we can use it in a select block
-func waitForCmd(cmd *exec.Cmd) <-chan error {
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
-	return done
+// testSuitePasses runs the test suite and reports whether it passed. The
+// baseline run on the unmutated code uses the full test command and prints
+// detailed logs on failure. Mutant runs use the faster, fail-fast command.
+func testSuitePasses(p *Program, baseline bool) bool {
+	passed, _ := runTestSuite(p, baseline)
+	return passed
 }
 
-func testSuitePasses(p *Program, detailedLogs bool) bool {
+// runTestSuite is testSuitePasses, also returning the exit code of the test
+// command, -1 if it couldn't run.
+func runTestSuite(p *Program, baseline bool) (bool, int) {
 	// Pre-conditions
 
+	testCMD := *p.testCMD
+	if baseline {
+		testCMD = p.baselineTestCMD
+	}
+
 	// sh -c enables the CMD to be passed as a single string without slicing
-	cmd := exec.Command("sh", "-c", *p.testCMD)
-	fmt.Printf("[Info] Running the test suite with: %s.\n", *p.testCMD)
+	cmd := exec.Command("sh", "-c", testCMD)
+	fmt.Printf("[Info] Running the test suite with: %s.\n", testCMD)
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
+		exitCode := -1
 		// Check if the command error is due to the command not being found
 		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
 			// If exit code is non-zero, check if it's due to command not found or other reasons
 			if exitErr.ExitCode() == 127 {
-				fmt.Fprintf(os.Stderr, "[Error] Command not found: %s\n", *p.testCMD)
+				fmt.Fprintf(os.Stderr, "[Error] Command not found: %s\n", testCMD)
 			} else {
-				if detailedLogs {
-					fmt.Fprintf(os.Stderr, "[Error] %s\n        Foundry's forge output:\n", err)
-					fmt.Fprintln(os.Stderr, "\033[31m------- Foundry Error Zone - Start -------\033[0m")
+				if baseline {
+					name := p.framework.Name()
+					fmt.Fprintf(os.Stderr, "[Error] %s\n        %s output:\n", err, name)
+					fmt.Fprintf(os.Stderr, "\033[31m------- %s Error Zone - Start -------\033[0m\n", name)
 					fmt.Fprintln(os.Stderr, string(output))
-					fmt.Fprintln(os.Stderr, "\033[31m------- Foundry Error Zone - End -------\033[0m")
+					fmt.Fprintf(os.Stderr, "\033[31m------- %s Error Zone - End -------\033[0m\n", name)
+
+					if results := p.framework.ParseResults(string(output)); len(results.FailingTests) > 0 {
+						fmt.Fprintf(os.Stderr, "[Error] %d test(s) failed:\n", len(results.FailingTests))
+						for _, test := range results.FailingTests {
+							fmt.Fprintf(os.Stderr, "  - %s\n", test)
+						}
+					}
 				}
 			}
 		} else {
@@ -582,12 +792,12 @@ func testSuitePasses(p *Program, detailedLogs bool) bool {
 		}
 
 		fmt.Println("[Info] Test suite failed.")
-		return false
+		return false, exitCode
 	}
 
 	// If no errors, the test suite passed
 	fmt.Println("[Info] Test suite passed successfully.")
-	return true
+	return true, 0
 
 	// Post-conditions
 }
@@ -599,24 +809,35 @@ func printMutationStats(p *Program) {
 	fmt.Printf("\n--------- Mutation Stats - Start ---------\n\n")
 
 	fmt.Printf("Total mutants generated: %d\n", stats.MutantsTotalGenerated)
+	if excluded := stats.MutantsTotalEquivalent + stats.MutantsTotalDuplicate; excluded > 0 {
+		fmt.Printf("Total mutants excluded by TCE: %d (%d equivalent, %d duplicate)\n", excluded, stats.MutantsTotalEquivalent, stats.MutantsTotalDuplicate)
+	}
 	fmt.Printf("Total mutants unslain: %d\n", stats.MutantsTotalUnslain)
 	fmt.Printf("Total mutants slain: %d\n", stats.MutantsTotalSlain)
-	fmt.Printf("Overall Mutation Score: %.2f%%\n\n", stats.MutationScore)
+	fmt.Printf("Overall Mutation Score: %.2f%%\n", stats.MutationScore)
+	if analysis, missing, ok := analyzeSubsumption(p); ok {
+		fmt.Printf("Subsumption-adjusted Score: %.2f%% (%d dominator mutants", analysis.Score, len(analysis.Dominators))
+		if missing > 0 {
+			fmt.Printf(", %d slain without kill data left out", missing)
+		}
+		fmt.Println(")")
+	}
+	fmt.Println()
 
 	if len(analyzedFiles) > 0 {
 		fmt.Printf("Below is the per file breakdown: \n")
 		// Need to iterate in a sorted order for consistent output if possible, or just range
 		for filePath, fileData := range analyzedFiles {
 			fmt.Printf("File: %s\n", filePath)
-			fmt.Printf("  Generated: %d\n", fileData.FileSpecificStats.MutantsTotalGenerated)
-			unslainInFile := fileData.FileSpecificStats.MutantsTotalGenerated - fileData.FileSpecificStats.MutantsTotalSlain
-			fmt.Printf("  Unslain:   %d\n", unslainInFile) // Calculate derived value
-			fmt.Printf("  Slain:     %d\n", fileData.FileSpecificStats.MutantsTotalSlain)
-			scoreInFile := float32(0.0)
-			if fileData.FileSpecificStats.MutantsTotalGenerated > 0 {
-				scoreInFile = (float32(fileData.FileSpecificStats.MutantsTotalSlain) / float32(fileData.FileSpecificStats.MutantsTotalGenerated)) * 100
+			fileStats := fileData.FileSpecificStats
+			recalculateFileStats(&fileStats) // Calculate derived values
+			fmt.Printf("  Generated: %d\n", fileStats.MutantsTotalGenerated)
+			if excluded := fileStats.MutantsTotalEquivalent + fileStats.MutantsTotalDuplicate; excluded > 0 {
+				fmt.Printf("  Excluded:  %d\n", excluded)
 			}
-			fmt.Printf("  Score:     %.2f%%\n", scoreInFile) // Calculate derived value
+			fmt.Printf("  Unslain:   %d\n", fileStats.MutantsTotalUnslain)
+			fmt.Printf("  Slain:     %d\n", fileStats.MutantsTotalSlain)
+			fmt.Printf("  Score:     %.2f%%\n", fileStats.MutationScore)
 		}
 	} else {
 		fmt.Println("No per-file data available yet.")
@@ -646,6 +867,22 @@ func listSolidityFiles(pathToContracts string) []SolidityFile {
 	return solidityFiles
 }
 
+// listSourceBackups lists the source files that have a '.sol.bak' backup in
+// the contracts directory, whether or not the source itself still exists.
+func listSourceBackups(pathToContracts string) []string {
+	var sources []string
+	filepath.WalkDir(pathToContracts, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what can't be read, the backups elsewhere still count.
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sol.bak") {
+			sources = append(sources, strings.TrimSuffix(path, ".bak"))
+		}
+		return nil
+	})
+	return sources
+}
+
 func visitSolFile(absolutePath string, info os.FileInfo) *SolidityFile {
 	if !info.IsDir() && strings.HasSuffix(info.Name(), ".sol") {
 		// Compute the relative path based on absolute and project root.
@@ -666,29 +903,65 @@ func visitSolFile(absolutePath string, info os.FileInfo) *SolidityFile {
 	return nil
 }
 
-func generateGambitEntries(solidityFiles []SolidityFile) ([]GambitEntry, error) {
+func generateGambitEntries(p *Program, solidityFiles []SolidityFile) ([]GambitEntry, error) {
 	// Pre-condition
 	assert.True(len(solidityFiles) > 0, "No Solidity files were provided. Can't generate gambit entries.")
 
-	forgeRemappings, err := getForgeRemappings()
+	remappings, err := p.framework.Remappings(*p.contractsDIR)
 	if err != nil {
 		return nil, err
 	}
 
-	gambitRemappings := transformForgeRemappings(forgeRemappings)
-
-	assert.True(len(gambitRemappings) > 0, "Got 0 gambit remappings.")
+	gambitRemappings := []string{}
+	if strings.TrimSpace(remappings) != "" {
+		gambitRemappings = transformForgeRemappings(remappings)
+	} else {
+		fmt.Printf("[Info] %s reported no import remappings.\n", p.framework.Name())
+	}
 
 	var gambitEntries []GambitEntry
 
+	settings := p.buildSettings
+	solc := ""
+	if strings.ContainsRune(settings.SolcVersion, filepath.Separator) {
+		solc = settings.SolcVersion // foundry.toml can point at a solc binary directly.
+	}
+
+	// Libs outside of the project need to be explicitly allowed.
+	var allowPaths []string
+	for _, lib := range settings.Libs {
+		if err := ensureWithinProject(lib); err != nil {
+			allowPaths = append(allowPaths, lib)
+		}
+	}
+
+	compilers := newCompilerSelector(p)
+	for _, file := range p.config.unmatchedFiles(solidityFiles) {
+		fmt.Printf("\033[33m[Warning] '%s' is configured in %s but it's not among the contracts selected in '%s'.\033[0m\n", file, *p.configPath, *p.contractsDIR)
+	}
+
 	for _, file := range solidityFiles {
 		entry := GambitEntry{
 			FilePath:       file.PathFromProjectRoot,
 			SolcRemappings: gambitRemappings,
+			Solc:           solc,
+			SolcOptimize:   settings.Optimizer,
+			SolcViaIR:      settings.ViaIR,
+			SolcAllowPaths: allowPaths,
+		}
+		applyGambitSettings(&entry, p.config.forFile(file.PathFromProjectRoot))
+
+		// Without an explicit binary pick one that matches the file's pragma.
+		if entry.Solc == "" {
+			entry.Solc, _ = compilers.selectFor(file.PathFromProjectRoot)
 		}
 
 		gambitEntries = append(gambitEntries, entry)
 	}
+	compilers.printSummary()
+	if err := compilers.err(); err != nil {
+		return nil, err
+	}
 
 	// Post-condition
 	assert.True(len(gambitEntries) > 0, "Generated 0 gambit entries.")
@@ -696,71 +969,85 @@ func generateGambitEntries(solidityFiles []SolidityFile) ([]GambitEntry, error)
 	return gambitEntries, nil
 }
 
-func getForgeRemappings() (string, error) {
-	var out bytes.Buffer
-
-	cmd := exec.Command("forge", "remappings")
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-
-	err := cmd.Start()
-	if err != nil {
-		return "", fmt.Errorf("Failed to run forge remappings: %v", err)
+// applyGambitSettings overrides the entry's defaults with the settings from
+// checkmate's config.
+func applyGambitSettings(entry *GambitEntry, settings GambitSettings) {
+	entry.Mutations = settings.Mutations
+	entry.Functions = settings.Functions
+	entry.Contract = settings.Contract
+	entry.SolcBasePath = settings.SolcBasePath
+	entry.Outdir = settings.Outdir
+
+	if settings.NumMutants != nil {
+		entry.NumMutants = *settings.NumMutants
 	}
-
-	err = cmd.Wait()
-	if err != nil {
-		return "", fmt.Errorf("Forge remappings finished with an error: %v", err)
+	if settings.RandomSeed != nil {
+		entry.RandomSeed = *settings.RandomSeed
+	}
+	if settings.Solc != "" {
+		entry.Solc = settings.Solc
+	}
+	if settings.SolcOptimize != nil {
+		entry.SolcOptimize = *settings.SolcOptimize
+	}
+	if settings.SkipValidate != nil {
+		entry.SkipValidate = *settings.SkipValidate
+	}
+	// Allow paths from the config extend the detected ones.
+	entry.SolcAllowPaths = append(append([]string{}, entry.SolcAllowPaths...), settings.SolcAllowPaths...)
+	if len(entry.SolcAllowPaths) == 0 {
+		entry.SolcAllowPaths = nil
 	}
-
-	// Post-conditions
-	// There is always at least: forge-std/=lib/forge-std/src/
-	assert.True(out.Len() > 0, "Forge remappings are empty.")
-
-	return out.String(), nil
 }
 
+// transformForgeRemappings translates remappings in the 'forge remappings'
+// format to the ones accepted by Gambit and solc. Context-scoped remappings
+// keep their context. Remappings that can't be translated are reported with a
+// warning and skipped.
 func transformForgeRemappings(forgeRemappings string) []string {
-	// Pre-conditions
-	assert.True(len(forgeRemappings) > 0, "Cannot create gambit remappings if forge remappings are empty.")
-
-	var gambitRemappings []string
+	gambitRemappings := []string{}
+	seen := make(map[string]bool)
 
 	lines := strings.Split(forgeRemappings, "\n")
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" {
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		// Split each remapping into "from" and "to" parts
-		parts := strings.Split(line, "/=")
-		if len(parts) == 2 {
-			from := strings.TrimSpace(parts[0])
-			to := strings.TrimSpace(parts[1])
+		remapping, err := framework.ParseRemapping(line)
+		if err != nil {
+			fmt.Printf("\033[33m[Warning] Skipping remapping %s.\033[0m\n", err)
+			continue
+		}
 
-			if checkRemappingExists(to) {
-				gambitRemappings = append(gambitRemappings, fmt.Sprintf("%s=%s", from, to))
-			}
+		// The target can be a directory or a single file.
+		if !checkRemappingExists(remapping.Target) {
+			fmt.Printf("\033[33m[Warning] Skipping remapping '%s', its target '%s' does not exist.\033[0m\n", line, remapping.Target)
+			continue
+		}
+
+		// The first remapping of a prefix wins, like in forge.
+		if seen[remapping.Key()] {
+			fmt.Printf("\033[33m[Warning] Skipping remapping '%s', the prefix '%s' is already remapped.\033[0m\n", line, remapping.Prefix)
+			continue
 		}
+		seen[remapping.Key()] = true
+
+		gambitRemappings = append(gambitRemappings, remapping.String())
 	}
 
-	// Post-conditions
-	assert.True(len(gambitRemappings) > 0, "Transformed 0 gambit remappings.")
+	if len(gambitRemappings) == 0 {
+		fmt.Println("\033[33m[Warning] None of the remappings could be translated. Imports of dependencies might fail to resolve.\033[0m")
+	}
 
 	return gambitRemappings
 }
 
 func checkRemappingExists(path string) bool {
-	info, err := os.Stat(path)
-	if err != nil {
-		// Path doesn't exist
-		return false
-	}
-
-	// Remapping points to a dir
-	return info.IsDir()
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func testMutations(p *Program) error {
@@ -774,7 +1061,7 @@ func testMutations(p *Program) error {
 
 	fmt.Printf("\n\033[32m[Info] Starting the mutation analysis.\033[0m\n\n")
 
-	mutantFiles := listSolidityFiles(*p.mutantsDIR)
+	mutantFiles := listMutants(*p.mutantsDIR)
 	mutantsProcessedCount := len(p.dbState.SlayingProgress.MutantsProcessed)
 	consecutiveSkippedCount := 0 // Counter for consecutively skipped mutants
 
@@ -804,134 +1091,264 @@ func testMutations(p *Program) error {
 			continue
 		}
 
-		// Ensure AnalyzedFile entry exists
-		fileAnalysisEntry, ok := p.dbState.AnalyzedFiles[originalFilePath]
-		if !ok {
-			log.Printf("[Warning] No analysis entry for original file %s. Initializing.", originalFilePath)
-			fileAnalysisEntry = db.AnalyzedFile{
-				FileSpecificStats: db.FileSpecificStats{
-					// MutantsTotalGenerated should have been set by initializeGeneratedMutantStats
-					MutantsTotalGenerated: p.dbState.AnalyzedFiles[originalFilePath].FileSpecificStats.MutantsTotalGenerated,
-				},
-				FileSpecificRecommendations: []string{},
-			}
-		}
-
-		destinationPath := originalFilePath // Path in the project to overwrite with mutant
-		backupPath := destinationPath + ".bak"
-
-		err := copyFile(destinationPath, backupPath)
-		if err != nil {
-			return fmt.Errorf("failed to backup original file %s: %w", destinationPath, err)
-		}
-
-		err = copyFile(mutantFile.PathFromProjectRoot, destinationPath)
+		run, err := runTestsAgainstMutant(p, mutantFile, originalFilePath)
 		if err != nil {
-			_ = os.Remove(backupPath) // Attempt cleanup
-			return fmt.Errorf("failed to copy mutant %s to %s: %w", mutantFile.PathFromProjectRoot, destinationPath, err)
+			return err
 		}
 
-		if !testSuitePasses(p, false) { // Test suite fails -> mutant is slain
+		if run.slain { // Test suite fails -> mutant is slain
 			fmt.Printf("[Info] Mutant slain 🗡️ (%s)\n", mutantFile.PathFromProjectRoot)
+		} else {
+			fmt.Printf("[Info] Test suite didn't catch the bug ❌ Mutant unslain: (%s)\n", mutantFile.PathFromProjectRoot)
+			// Update total unslain as derivation of total generated and slain below.
+		}
 
-			var mutantDirToRemove string // Will hold the path like "gambit_out/mutants/492"
+		recordMutantResult(p, mutantFile, originalFilePath, run)
 
-			cleanMutantsBaseDir := filepath.Clean(*p.mutantsDIR)                  // gambit_out/mutants/
-			cleanMutantFilePath := filepath.Clean(mutantFile.PathFromProjectRoot) // gambit_out/mutants/492/src/Mutant.sol
+		mutantsProcessedCount++
 
-			// Get the path of the mutant file relative to the base mutants directory
-			// e.g., if base is "gambit_out/mutants" and file is "gambit_out/mutants/492/src/File.sol",
-			// relPath will be "492/src/File.sol" (or "492\src\File.sol" on Windows)
-			relPath, err := filepath.Rel(cleanMutantsBaseDir, cleanMutantFilePath)
-			if err != nil {
-				fmt.Printf("\033[31m[Warning] Could not determine relative path for mutant %s regarding base %s: %v. Skipping removal.\033[0m",
-					cleanMutantFilePath, cleanMutantsBaseDir, err)
+		if mutantsProcessedCount%saveInterval == 0 {
+			recalculateOverallStats(&p.dbState.OverallStats)
+			if errSave := p.store.Save(&p.dbState); errSave != nil {
+				log.Printf("[Warning] Failed to save state during testing mutations: %v", errSave)
 			} else {
-				// relPath should now be something like "492/src/File.sol" or "492/File.sol" or "492/src/libraries/File.sol"
-				// We want the first component of this relative path, which is the mutant ID folder.
-				parts := strings.Split(relPath, string(filepath.Separator))
-				if len(parts) > 0 && parts[0] != "" && parts[0] != "." && parts[0] != ".." {
-					mutantIdFolderName := parts[0] // This should be "492"
-					mutantDirToRemove = filepath.Join(cleanMutantsBaseDir, mutantIdFolderName)
-				} else {
-					log.Printf("\033[31m[Warning] Could not extract a valid mutant ID folder from relative path '%s' (derived from %s). Skipping removal.\033[0m",
-						relPath, cleanMutantFilePath)
-				}
+				fmt.Printf("\033[32m[Info] Progress saved. Processed %d mutants so far. %d mutants remaining.\033[0m\n",
+					mutantsProcessedCount, int(p.dbState.OverallStats.MutantsTotalGenerated-p.dbState.OverallStats.MutantsTotalEquivalent-p.dbState.OverallStats.MutantsTotalDuplicate)-mutantsProcessedCount)
 			}
+		}
+	}
 
-			if mutantDirToRemove != "" {
-				// 1. Ensure it's still prefixed by the base mutants directory (double check after join).
-				// 2. Ensure we are not trying to remove the base mutants directory itself or current dir.
-				finalCleanMutantDirToRemove := filepath.Clean(mutantDirToRemove)
+	// Final calculation for overall unslain and score
+	recalculateOverallStats(&p.dbState.OverallStats)
 
-				if strings.HasPrefix(finalCleanMutantDirToRemove, cleanMutantsBaseDir) &&
-					finalCleanMutantDirToRemove != cleanMutantsBaseDir &&
-					finalCleanMutantDirToRemove != "." {
+	return nil
+}
 
-					if errRem := os.RemoveAll(finalCleanMutantDirToRemove); errRem != nil {
-						log.Printf("[Warning] Failed to remove slain mutant directory %s: %v\n", finalCleanMutantDirToRemove, errRem)
-					}
-				} else {
-					log.Printf("[Warning] Sanity check failed: Path '%s' derived for removal is not a valid mutant sub-directory of '%s'. Removal skipped.",
-						finalCleanMutantDirToRemove, cleanMutantsBaseDir)
-				}
-			}
+// recalculateFileStats derives the unslain count and the mutation score of a
+// file from its generated and slain counts. Equivalent and duplicate mutants
+// don't count.
+func recalculateFileStats(stats *db.FileSpecificStats) {
+	scored := stats.MutantsTotalGenerated - stats.MutantsTotalEquivalent - stats.MutantsTotalDuplicate
+	stats.MutantsTotalUnslain = scored - stats.MutantsTotalSlain
+	if scored > 0 {
+		stats.MutationScore = (float32(stats.MutantsTotalSlain) / float32(scored)) * 100
+	}
+}
 
-			p.dbState.OverallStats.MutantsTotalSlain++
-			fileAnalysisEntry.FileSpecificStats.MutantsTotalSlain++
-		} else {
-			fmt.Printf("[Info] Test suite didn't catch the bug ❌ Mutant unslain: (%s)\n", mutantFile.PathFromProjectRoot)
-			// Update total unslain as derivation of total generated and slain below.
-		}
+// recalculateOverallStats derives the overall unslain count and the mutation
+// score from the generated and slain counts. Equivalent and duplicate mutants
+// don't count.
+func recalculateOverallStats(stats *db.OverallStats) {
+	scored := stats.MutantsTotalGenerated - stats.MutantsTotalEquivalent - stats.MutantsTotalDuplicate
+	stats.MutantsTotalUnslain = scored - stats.MutantsTotalSlain
+	if scored > 0 {
+		stats.MutationScore = (float32(stats.MutantsTotalSlain) / float32(scored)) * 100
+	}
+}
 
-		// Restore original file
-		err = copyFile(backupPath, destinationPath)
-		if err != nil {
-			return fmt.Errorf("failed to restore backup for %s: %w", destinationPath, err)
-		}
-		err = os.Remove(backupPath)
-		if err != nil {
-			return fmt.Errorf("failed to remove backup file %s: %w", backupPath, err)
+// recordMutantResult updates the slaying progress, the record of the mutant
+// and the statistics after it was tested. Slain mutants are removed from the
+// mutants directory.
+func recordMutantResult(p *Program, mutantFile SolidityFile, originalFilePath string, run mutantRun) {
+	// Ensure AnalyzedFile entry exists
+	fileAnalysisEntry, ok := p.dbState.AnalyzedFiles[originalFilePath]
+	if !ok {
+		log.Printf("[Warning] No analysis entry for original file %s. Initializing.", originalFilePath)
+		fileAnalysisEntry = db.AnalyzedFile{
+			FileSpecificStats: db.FileSpecificStats{
+				// MutantsTotalGenerated should have been set by initializeGeneratedMutantStats
+				MutantsTotalGenerated: p.dbState.AnalyzedFiles[originalFilePath].FileSpecificStats.MutantsTotalGenerated,
+			},
+			FileSpecificRecommendations: []string{},
 		}
+	}
 
-		// Update stats after test
-		p.dbState.SlayingProgress.MutantsProcessed[mutantIdentifier] = true
+	if run.slain {
+		removeSlainMutantDir(p, mutantFile)
 
-		// Recalculate unslain counts and scores
-		fileAnalysisEntry.FileSpecificStats.MutantsTotalUnslain = fileAnalysisEntry.FileSpecificStats.MutantsTotalGenerated - fileAnalysisEntry.FileSpecificStats.MutantsTotalSlain
+		p.dbState.OverallStats.MutantsTotalSlain++
+		fileAnalysisEntry.FileSpecificStats.MutantsTotalSlain++
 
-		if fileAnalysisEntry.FileSpecificStats.MutantsTotalGenerated > 0 {
-			fileAnalysisEntry.FileSpecificStats.MutationScore =
-				(float32(fileAnalysisEntry.FileSpecificStats.MutantsTotalSlain) / float32(fileAnalysisEntry.FileSpecificStats.MutantsTotalGenerated)) * 100
+		if len(run.killingTests) > 0 {
+			if p.dbState.SlayingProgress.KillingTests == nil {
+				p.dbState.SlayingProgress.KillingTests = make(map[string][]string)
+			}
+			p.dbState.SlayingProgress.KillingTests[mutantFile.PathFromProjectRoot] = run.killingTests
 		}
+	}
 
-		p.dbState.AnalyzedFiles[originalFilePath] = fileAnalysisEntry
+	// Update stats after test
+	p.dbState.SlayingProgress.MutantsProcessed[mutantFile.PathFromProjectRoot] = true
 
-		mutantsProcessedCount++
+	// Recalculate unslain counts and scores
+	recalculateFileStats(&fileAnalysisEntry.FileSpecificStats)
 
-		if mutantsProcessedCount%saveInterval == 0 {
-			p.dbState.OverallStats.MutantsTotalUnslain = p.dbState.OverallStats.MutantsTotalGenerated - p.dbState.OverallStats.MutantsTotalSlain
-			if p.dbState.OverallStats.MutantsTotalGenerated > 0 {
-				p.dbState.OverallStats.MutationScore =
-					(float32(p.dbState.OverallStats.MutantsTotalSlain) / float32(p.dbState.OverallStats.MutantsTotalGenerated)) * 100
-			}
-			if errSave := db.SaveStateToFile(stateFileName, &p.dbState); errSave != nil {
-				log.Printf("[Warning] Failed to save state during testing mutations: %v", errSave)
-			} else {
-				fmt.Printf("\033[32m[Info] Progress saved. Processed %d mutants so far. %d mutants remaining.\033[0m\n",
-					mutantsProcessedCount, int(p.dbState.OverallStats.MutantsTotalGenerated)-mutantsProcessedCount)
-			}
-		}
+	p.dbState.AnalyzedFiles[originalFilePath] = fileAnalysisEntry
+
+	recordMutantRun(p, mutantFile.PathFromProjectRoot, run)
+	deriveStats(p)
+}
+
+// mutantRun is the outcome of testing a mutant, kept in its record.
+type mutantRun struct {
+	slain        bool     // The test suite failed.
+	killingTests []string // Only known with --kill-matrix.
+	command      string
+	exitCode     int // -1 if the test command couldn't run.
+	started      time.Time
+	duration     time.Duration
+}
+
+// runTestsAgainstMutant writes the mutant over its original file, runs the
+// test suite and restores the original file afterwards. The run is slain if
+// the test suite failed. With --kill-matrix the whole test suite runs and the
+// failing tests are returned too.
+func runTestsAgainstMutant(p *Program, mutantFile SolidityFile, originalFilePath string) (mutantRun, error) {
+	mutant, err := mutator.ReadMutant(mutantFile.PathFromProjectRoot, originalFilePath)
+	if err != nil {
+		return mutantRun{}, fmt.Errorf("failed to read mutant %s: %w", mutantFile.PathFromProjectRoot, err)
 	}
+	return runTestsAgainstSource(p, mutant, originalFilePath)
+}
 
-	// Final calculation for overall unslain and score
-	p.dbState.OverallStats.MutantsTotalUnslain = p.dbState.OverallStats.MutantsTotalGenerated - p.dbState.OverallStats.MutantsTotalSlain
-	if p.dbState.OverallStats.MutantsTotalGenerated > 0 {
-		p.dbState.OverallStats.MutationScore =
-			(float32(p.dbState.OverallStats.MutantsTotalSlain) / float32(p.dbState.OverallStats.MutantsTotalGenerated)) * 100
+// runTestsAgainstSource tests the mutated source of the original file. The
+// original is copied to its '.bak' backup and the mutant renamed over it, both
+// through a temporary file, so that an interrupted run never leaves the
+// original or its backup missing or half written.
+func runTestsAgainstSource(p *Program, mutant []byte, originalFilePath string) (mutantRun, error) {
+	destinationPath := originalFilePath // Path in the project to overwrite with mutant
+	backupPath := destinationPath + ".bak"
+
+	info, err := os.Stat(destinationPath)
+	if err != nil {
+		return mutantRun{}, fmt.Errorf("failed to stat original file %s: %w", destinationPath, err)
+	}
+	original, err := os.ReadFile(destinationPath)
+	if err != nil {
+		return mutantRun{}, fmt.Errorf("failed to read original file %s: %w", destinationPath, err)
+	}
+	err = writeFileAtomic(backupPath, original, info.Mode().Perm())
+	if err != nil {
+		return mutantRun{}, fmt.Errorf("failed to backup original file %s: %w", destinationPath, err)
 	}
 
-	return nil
+	err = writeFileAtomic(destinationPath, mutant, info.Mode().Perm())
+	if err != nil {
+		_ = os.Remove(backupPath) // The original is untouched.
+		return mutantRun{}, fmt.Errorf("failed to write mutant to %s: %w", destinationPath, err)
+	}
+
+	run := mutantRun{started: time.Now()}
+	if *p.killMatrix {
+		run.command = p.baselineTestCMD
+		run.slain, run.killingTests, run.exitCode = failingTests(p)
+	} else {
+		var passed bool
+		run.command = *p.testCMD
+		passed, run.exitCode = runTestSuite(p, false)
+		run.slain = !passed
+	}
+	run.duration = time.Since(run.started)
+
+	// Restore original file
+	err = os.Rename(backupPath, destinationPath)
+	if err != nil {
+		return mutantRun{}, fmt.Errorf("failed to restore backup for %s: %w", destinationPath, err)
+	}
+
+	return run, nil
+}
+
+// writeFileAtomic writes the content to a temporary file next to path and
+// renames it over path, so that path is never left half written. The
+// temporary file doesn't end with '.sol', it's never taken for a source.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+// failingTests runs the whole test suite, without stopping at the first
+// failure, and returns whether it failed, the names of the failing tests and
+// the exit code of the test command.
+func failingTests(p *Program) (bool, []string, int) {
+	cmd := exec.Command("sh", "-c", p.baselineTestCMD)
+	fmt.Printf("[Info] Running the whole test suite with: %s.\n", p.baselineTestCMD)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		fmt.Println("[Info] Test suite passed successfully.")
+		return false, nil, 0
+	}
+
+	exitCode := -1
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	}
+	failing := p.framework.ParseResults(string(output)).FailingTests
+	fmt.Printf("[Info] Test suite failed, %d failing test(s).\n", len(failing))
+	return true, failing, exitCode
+}
+
+// removeSlainMutantDir removes the directory of a slain mutant e.g.
+// "gambit_out/mutants/492" so that only survivors are left in the mutants directory.
+func removeSlainMutantDir(p *Program, mutantFile SolidityFile) {
+	var mutantDirToRemove string // Will hold the path like "gambit_out/mutants/492"
+
+	cleanMutantsBaseDir := filepath.Clean(*p.mutantsDIR)                  // gambit_out/mutants/
+	cleanMutantFilePath := filepath.Clean(mutantFile.PathFromProjectRoot) // gambit_out/mutants/492/src/Mutant.sol
+
+	// Get the path of the mutant file relative to the base mutants directory
+	// e.g., if base is "gambit_out/mutants" and file is "gambit_out/mutants/492/src/File.sol",
+	// relPath will be "492/src/File.sol" (or "492\src\File.sol" on Windows)
+	relPath, err := filepath.Rel(cleanMutantsBaseDir, cleanMutantFilePath)
+	if err != nil {
+		fmt.Printf("\033[31m[Warning] Could not determine relative path for mutant %s regarding base %s: %v. Skipping removal.\033[0m",
+			cleanMutantFilePath, cleanMutantsBaseDir, err)
+	} else {
+		// relPath should now be something like "492/src/File.sol" or "492/File.sol" or "492/src/libraries/File.sol"
+		// We want the first component of this relative path, which is the mutant ID folder.
+		parts := strings.Split(relPath, string(filepath.Separator))
+		if len(parts) > 0 && parts[0] != "" && parts[0] != "." && parts[0] != ".." {
+			mutantIdFolderName := parts[0] // This should be "492"
+			mutantDirToRemove = filepath.Join(cleanMutantsBaseDir, mutantIdFolderName)
+		} else {
+			log.Printf("\033[31m[Warning] Could not extract a valid mutant ID folder from relative path '%s' (derived from %s). Skipping removal.\033[0m",
+				relPath, cleanMutantFilePath)
+		}
+	}
+
+	if mutantDirToRemove != "" {
+		// 1. Ensure it's still prefixed by the base mutants directory (double check after join).
+		// 2. Ensure we are not trying to remove the base mutants directory itself or current dir.
+		finalCleanMutantDirToRemove := filepath.Clean(mutantDirToRemove)
+
+		if strings.HasPrefix(finalCleanMutantDirToRemove, cleanMutantsBaseDir) &&
+			finalCleanMutantDirToRemove != cleanMutantsBaseDir &&
+			finalCleanMutantDirToRemove != "." {
+
+			if errRem := os.RemoveAll(finalCleanMutantDirToRemove); errRem != nil {
+				log.Printf("[Warning] Failed to remove slain mutant directory %s: %v\n", finalCleanMutantDirToRemove, errRem)
+			}
+		} else {
+			log.Printf("[Warning] Sanity check failed: Path '%s' derived for removal is not a valid mutant sub-directory of '%s'. Removal skipped.",
+				finalCleanMutantDirToRemove, cleanMutantsBaseDir)
+		}
+	}
 }
 
 func copyFile(src, dst string) error {
@@ -961,8 +1378,11 @@ func printMutationStatsReport(p *Program) {
 
 	fmt.Printf("### Overall Statistics\n")
 	fmt.Printf("- Total mutants generated: %d\n", stats.MutantsTotalGenerated)
+	if excluded := stats.MutantsTotalEquivalent + stats.MutantsTotalDuplicate; excluded > 0 {
+		fmt.Printf("- Total mutants excluded by TCE: %d (%d equivalent, %d duplicate)\n", excluded, stats.MutantsTotalEquivalent, stats.MutantsTotalDuplicate)
+	}
 	// Ensure Unslain is calculated if not already up-to-date from a full run
-	actualUnslain := stats.MutantsTotalGenerated - stats.MutantsTotalSlain
+	actualUnslain := stats.MutantsTotalGenerated - stats.MutantsTotalEquivalent - stats.MutantsTotalDuplicate - stats.MutantsTotalSlain
 	fmt.Printf("- Total mutants unslain: %d\n", actualUnslain)
 	fmt.Printf("- Total mutants slain: %d\n", stats.MutantsTotalSlain)
 	fmt.Printf("- Overall Mutation Score: %.2f%%\n\n", stats.MutationScore)
@@ -980,7 +1400,11 @@ func printMutationStatsReport(p *Program) {
 			fileData := analyzedFiles[filePath]
 			fmt.Printf("\n#### File: `%s`\n", filePath)
 			fmt.Printf("- Generated: %d\n", fileData.FileSpecificStats.MutantsTotalGenerated)
-			fileUnslain := fileData.FileSpecificStats.MutantsTotalGenerated - fileData.FileSpecificStats.MutantsTotalSlain
+			fileExcluded := fileData.FileSpecificStats.MutantsTotalEquivalent + fileData.FileSpecificStats.MutantsTotalDuplicate
+			if fileExcluded > 0 {
+				fmt.Printf("- Excluded:  %d\n", fileExcluded)
+			}
+			fileUnslain := fileData.FileSpecificStats.MutantsTotalGenerated - fileExcluded - fileData.FileSpecificStats.MutantsTotalSlain
 			fmt.Printf("- Unslain:   %d\n", fileUnslain)
 			fmt.Printf("- Slain:     %d\n", fileData.FileSpecificStats.MutantsTotalSlain)
 			fmt.Printf("- Score:     %.2f%%\n", fileData.FileSpecificStats.MutationScore)
@@ -1082,22 +1506,19 @@ func printLLMAnalysisErrorsReport(p *Program) {
 	fmt.Println()
 }
 
-func checkForAndRestoreInterruptedState(p *Program) {
+// checkForAndRestoreInterruptedState restores original contracts from the
+// '.sol.bak' files left behind by an interrupted slaying run. It returns the
+// paths of the files that were restored.
+func checkForAndRestoreInterruptedState(p *Program) []string {
+	var restored []string
+
 	if p.contractsDIR == nil || *p.contractsDIR == "" {
 		fmt.Println("[Info] Contracts directory not specified; skipping check for leftover '.sol.bak' files.")
-		return
+		return restored
 	}
 
-	originalContractFiles := listSolidityFiles(*p.contractsDIR)
-
 	restoredCount := 0
-	if len(originalContractFiles) == 0 {
-		fmt.Printf("[Info] No source files found in '%s' to check for backups.\n", *p.contractsDIR)
-		return
-	}
-
-	for _, solFile := range originalContractFiles {
-		originalFilePath := solFile.PathFromProjectRoot
+	for _, originalFilePath := range listSourceBackups(*p.contractsDIR) {
 		backupFilePath := originalFilePath + ".bak"
 
 		// Check if the backup file exists
@@ -1131,6 +1552,7 @@ func checkForAndRestoreInterruptedState(p *Program) {
 				} else {
 					fmt.Printf("[Info] Removed backup file '%s'.\n", backupFilePath)
 					restoredCount++
+					restored = append(restored, originalFilePath)
 				}
 			}
 		} else if errBak != nil && !os.IsNotExist(errBak) {
@@ -1142,4 +1564,6 @@ func checkForAndRestoreInterruptedState(p *Program) {
 	if restoredCount > 0 {
 		fmt.Printf("[Info] Finished checking for backups. %d file(s) were restored.\n", restoredCount)
 	}
+
+	return restored
 }
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// Config is checkmate's own configuration file ('checkmate.json' by default).
+// It is optional, a missing file results in the defaults.
+type Config struct {
+	// Gambit holds the Gambit settings applied to every file.
+	Gambit GambitSettings `json:"gambit"`
+
+	// Files overrides the global Gambit settings for individual files. The map
+	// key is the path to the file from the project's root e.g. "src/Vault.sol".
+	Files map[string]GambitSettings `json:"files"`
+
+	// SolcBinaries is a folder with solc binaries e.g. 'solc-0.8.23', searched
+	// in addition to the ones installed with solc-select and svm.
+	SolcBinaries string `json:"solc_binaries"`
+
+	// Include and Exclude select the files to mutate with glob patterns
+	// matched against the path from the project's root e.g. "src/**/*.sol".
+	// All files are included if Include is empty.
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+
+	// AutoExclude leaves out tests, scripts, mocks, interfaces and abstract
+	// contracts without implementations. Enabled unless set to false.
+	AutoExclude *bool `json:"auto_exclude"`
+
+	// Rules are user-defined mutations applied in addition to the mutator's
+	// operators e.g. replacing 'msg.sender' with 'tx.origin'.
+	Rules []MutationRule `json:"rules"`
+
+	// SecurityOperators enables operators of the security pack: true for all
+	// of them or a list e.g. ["modifier-removal-mutation"].
+	SecurityOperators OperatorSelection `json:"security_operators"`
+}
+
+// OperatorSelection is a list of operators that can also be given as a
+// boolean: true selects all operators of the pack.
+type OperatorSelection struct {
+	All       bool
+	Operators []string
+}
+
+func (s *OperatorSelection) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.All); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &s.Operators)
+}
+
+// MutationRule is a user-defined mutation rule, optionally limited to files.
+type MutationRule struct {
+	mutator.Rule
+
+	// Files are glob patterns of the files the rule applies to, matched
+	// against the path from the project's root. All files by default.
+	Files []string `json:"files"`
+}
+
+// GambitSettings are the Gambit config options that can be set from
+// checkmate's config. Unset fields don't override anything, so pointers are
+// used wherever the zero value is a valid setting.
+type GambitSettings struct {
+	Mutations      []string `json:"mutations,omitempty"`        // Mutation operators to apply e.g. ["require-mutation", "if-cond-mutation"]. All by default.
+	Functions      []string `json:"functions,omitempty"`        // Only mutate these functions.
+	Contract       string   `json:"contract,omitempty"`         // Only mutate this contract.
+	NumMutants     *int     `json:"num_mutants,omitempty"`      // Randomly sample this many mutants.
+	RandomSeed     *bool    `json:"random_seed,omitempty"`      // Use a random seed for sampling instead of Gambit's fixed one.
+	Solc           string   `json:"solc,omitempty"`             // Path to the solc binary.
+	SolcOptimize   *bool    `json:"solc_optimize,omitempty"`    // Whether to run the optimizer when validating mutants.
+	SolcBasePath   string   `json:"solc_base_path,omitempty"`   // Base path solc resolves imports from.
+	SolcAllowPaths []string `json:"solc_allow_paths,omitempty"` // Additional folders solc may read imports from.
+	Outdir         string   `json:"outdir,omitempty"`           // Gambit's output directory, 'gambit_out' by default.
+	SkipValidate   *bool    `json:"skip_validate,omitempty"`    // Don't compile the mutants to check that they are valid.
+}
+
+// gambitMutations lists the mutation operators supported by Gambit, in the
+// form used in its config file.
+var gambitMutations = []string{
+	"assignment-mutation",
+	"binary-op-mutation",
+	"delete-expression-mutation",
+	"elim-delegate-mutation",
+	"function-call-mutation",
+	"if-cond-mutation",
+	"require-mutation",
+	"swap-arguments-function-mutation",
+	"swap-arguments-operator-mutation",
+	"unary-operator-mutation",
+}
+
+// mutationAliases maps the names that Gambit prints in gambit_results.json to
+// the ones its config accepts, when they differ.
+var mutationAliases = map[string]string{
+	"if-statement-mutation": "if-cond-mutation",
+}
+
+// loadConfig reads checkmate's config from path. A missing file is not an error.
+func loadConfig(path string) (Config, error) {
+	var config Config
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, fmt.Errorf("Failed to read the checkmate config '%s': %w", path, err)
+	}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("Failed to parse the checkmate config '%s': %w", path, err)
+	}
+
+	if err := config.Gambit.normalize(); err != nil {
+		return config, fmt.Errorf("Invalid 'gambit' settings in '%s': %w", path, err)
+	}
+
+	files := make(map[string]GambitSettings, len(config.Files))
+	for file, settings := range config.Files {
+		if err := settings.normalize(); err != nil {
+			return config, fmt.Errorf("Invalid settings for '%s' in '%s': %w", file, path, err)
+		}
+		if settings.Outdir != "" && filepath.Clean(settings.Outdir) != filepath.Clean(config.Gambit.Outdir) {
+			return config, fmt.Errorf("Invalid settings for '%s' in '%s': checkmate reads all mutants from one directory, set 'outdir' in the global 'gambit' settings instead", file, path)
+		}
+		files[filepath.Clean(file)] = settings
+	}
+	config.Files = files
+
+	if err := config.SecurityOperators.normalize(); err != nil {
+		return config, fmt.Errorf("Invalid 'security_operators' in '%s': %w", path, err)
+	}
+
+	for i := range config.Rules {
+		if err := config.Rules[i].Compile(); err != nil {
+			return config, fmt.Errorf("Invalid rule #%d in '%s': %w", i+1, path, err)
+		}
+	}
+
+	fmt.Printf("[Info] Loaded checkmate config from %s.\n", path)
+	return config, nil
+}
+
+// normalize validates the settings and converts the mutation names to the
+// form accepted by Gambit's config.
+func (s *GambitSettings) normalize() error {
+	for i, name := range s.Mutations {
+		mutation, err := normalizeMutationName(name)
+		if err != nil {
+			return err
+		}
+		s.Mutations[i] = mutation
+	}
+	if s.NumMutants != nil && *s.NumMutants <= 0 {
+		return fmt.Errorf("'num_mutants' must be positive, got %d", *s.NumMutants)
+	}
+	return nil
+}
+
+// normalizeMutationName accepts both the config form of a mutation operator
+// e.g. "require-mutation" and the one printed in gambit_results.json e.g.
+// "RequireMutation".
+func normalizeMutationName(name string) (string, error) {
+	mutation := kebabCase(name)
+	if alias, ok := mutationAliases[mutation]; ok {
+		mutation = alias
+	}
+	for _, supported := range gambitMutations {
+		if mutation == supported {
+			return mutation, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown mutation '%s', supported mutations are: %s", name, strings.Join(gambitMutations, ", "))
+}
+
+// normalize converts the selected operators to their config names and
+// expands All.
+func (s *OperatorSelection) normalize() error {
+	var supported []string
+	for name := range mutator.SecurityOperators {
+		supported = append(supported, name)
+	}
+	sort.Strings(supported)
+
+	if s.All {
+		s.Operators = supported
+		return nil
+	}
+	for i, name := range s.Operators {
+		operator := kebabCase(name)
+		if _, ok := mutator.SecurityOperators[operator]; !ok {
+			return fmt.Errorf("unknown security operator '%s', supported operators are: %s", name, strings.Join(supported, ", "))
+		}
+		s.Operators[i] = operator
+	}
+	return nil
+}
+
+// kebabCase converts an operator name like "RequireMutation" to the form
+// used in the configs e.g. "require-mutation".
+func kebabCase(name string) string {
+	var kebab strings.Builder
+	for i, r := range strings.TrimSpace(name) {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				kebab.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		} else if r == '_' {
+			r = '-'
+		}
+		kebab.WriteRune(r)
+	}
+	return kebab.String()
+}
+
+// forFile returns the Gambit settings for the file at path, with the file's
+// own settings taking precedence over the global ones.
+func (c Config) forFile(path string) GambitSettings {
+	merged := c.Gambit
+	file, ok := c.Files[filepath.Clean(path)]
+	if !ok {
+		return merged
+	}
+
+	if file.Mutations != nil {
+		merged.Mutations = file.Mutations
+	}
+	if file.Functions != nil {
+		merged.Functions = file.Functions
+	}
+	if file.Contract != "" {
+		merged.Contract = file.Contract
+	}
+	if file.NumMutants != nil {
+		merged.NumMutants = file.NumMutants
+	}
+	if file.RandomSeed != nil {
+		merged.RandomSeed = file.RandomSeed
+	}
+	if file.Solc != "" {
+		merged.Solc = file.Solc
+	}
+	if file.SolcOptimize != nil {
+		merged.SolcOptimize = file.SolcOptimize
+	}
+	if file.SolcBasePath != "" {
+		merged.SolcBasePath = file.SolcBasePath
+	}
+	if file.SolcAllowPaths != nil {
+		merged.SolcAllowPaths = file.SolcAllowPaths
+	}
+	if file.SkipValidate != nil {
+		merged.SkipValidate = file.SkipValidate
+	}
+
+	return merged
+}
+
+// unmatchedFiles returns the files configured in the config that are not
+// among the Solidity files being mutated, most likely typos.
+func (c Config) unmatchedFiles(solidityFiles []SolidityFile) []string {
+	known := make(map[string]bool, len(solidityFiles))
+	for _, file := range solidityFiles {
+		known[filepath.Clean(file.PathFromProjectRoot)] = true
+	}
+
+	var unmatched []string
+	for file := range c.Files {
+		if !known[file] {
+			unmatched = append(unmatched, file)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testCheckmateConfig = `{
+    "gambit": {
+        "mutations": ["BinaryOpMutation", "require-mutation"],
+        "num_mutants": 50,
+        "solc_allow_paths": ["../shared"]
+    },
+    "files": {
+        "./src/Vault.sol": {
+            "mutations": ["RequireMutation", "IfStatementMutation"],
+            "functions": ["withdraw"],
+            "contract": "Vault",
+            "skip_validate": true
+        }
+    }
+}`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "checkmate.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPerFileSettings(t *testing.T) {
+	config, err := loadConfig(writeTestConfig(t, testCheckmateConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := GambitEntry{FilePath: "src/Vault.sol", SolcAllowPaths: []string{"/opt/libs"}}
+	applyGambitSettings(&entry, config.forFile("src/Vault.sol"))
+	want := GambitEntry{
+		FilePath:       "src/Vault.sol",
+		Mutations:      []string{"require-mutation", "if-cond-mutation"},
+		Functions:      []string{"withdraw"},
+		Contract:       "Vault",
+		NumMutants:     50,
+		SolcAllowPaths: []string{"/opt/libs", "../shared"},
+		SkipValidate:   true,
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Fatalf("got  %+v\nwant %+v", entry, want)
+	}
+
+	// Files without their own settings get the global ones.
+	entry = GambitEntry{FilePath: "src/Token.sol"}
+	applyGambitSettings(&entry, config.forFile("src/Token.sol"))
+	if !reflect.DeepEqual(entry.Mutations, []string{"binary-op-mutation", "require-mutation"}) || entry.Functions != nil || entry.SkipValidate {
+		t.Fatalf("unexpected global settings: %+v", entry)
+	}
+
+	unmatched := config.unmatchedFiles([]SolidityFile{{PathFromProjectRoot: "src/Token.sol"}})
+	if !reflect.DeepEqual(unmatched, []string{"src/Vault.sol"}) {
+		t.Fatalf("expected src/Vault.sol to be unmatched, got %v", unmatched)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown mutation":    `{"gambit": {"mutations": ["FlipEverythingMutation"]}}`,
+		"negative num":        `{"files": {"src/A.sol": {"num_mutants": -1}}}`,
+		"per file outdir":     `{"files": {"src/A.sol": {"outdir": "elsewhere"}}}`,
+		"invalid json":        `{"gambit": `,
+		"wrong setting types": `{"gambit": {"functions": "withdraw"}}`,
+		"rule without name":   `{"rules": [{"pattern": "msg.sender", "replacement": "tx.origin"}]}`,
+		"invalid rule regex":  `{"rules": [{"name": "X", "pattern": "(", "regex": true}]}`,
+		"unknown security op": `{"security_operators": ["RemoveEverythingMutation"]}`,
+	} {
+		if _, err := loadConfig(writeTestConfig(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	config, err := loadConfig(filepath.Join(t.TempDir(), "checkmate.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config, Config{}) {
+		t.Fatalf("expected an empty config, got %+v", config)
+	}
+}
+
+func TestLoadConfigRules(t *testing.T) {
+	config, err := loadConfig(writeTestConfig(t, `{
+    "rules": [
+        {"name": "TxOrigin", "pattern": "msg.sender", "replacement": "tx.origin", "files": ["src/**/*.sol"]},
+        {"name": "DropNonReentrantMutation", "pattern": "nonReentrant", "replacement": "", "functions": ["withdraw"]}
+    ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", config.Rules)
+	}
+	first := config.Rules[0]
+	if first.Name != "TxOriginMutation" || first.Pattern != "msg.sender" || first.Replacement != "tx.origin" || !reflect.DeepEqual(first.Files, []string{"src/**/*.sol"}) {
+		t.Fatalf("unexpected rule %+v", first)
+	}
+	if second := config.Rules[1]; second.Name != "DropNonReentrantMutation" || !reflect.DeepEqual(second.Functions, []string{"withdraw"}) {
+		t.Fatalf("unexpected rule %+v", second)
+	}
+}
+
+func TestLoadConfigSecurityOperators(t *testing.T) {
+	config, err := loadConfig(writeTestConfig(t, `{"security_operators": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.SecurityOperators.Operators) != 6 {
+		t.Fatalf("expected all 6 security operators, got %v", config.SecurityOperators.Operators)
+	}
+
+	config, err = loadConfig(writeTestConfig(t, `{"security_operators": ["ModifierRemovalMutation", "emit-removal-mutation"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"modifier-removal-mutation", "emit-removal-mutation"}
+	if !reflect.DeepEqual(config.SecurityOperators.Operators, want) {
+		t.Fatalf("got %v, want %v", config.SecurityOperators.Operators, want)
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// listMutants lists the mutants in the mutants directory, whether they are
+// stored as files or as diffs. Both are listed by the path of the mutant file
+// e.g. 'gambit_out/mutants/3/src/Vault.sol', which keys the slaying progress.
+func listMutants(mutantsDIR string) []SolidityFile {
+	var mutants []SolidityFile
+
+	filepath.Walk(mutantsDIR, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Error] There was a problem accessing path %q: %v\n", path, err)
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		path = strings.TrimSuffix(path, mutator.DiffSuffix)
+		if !strings.HasSuffix(path, ".sol") {
+			return nil
+		}
+		pathFromProjectRoot, err := filepath.Rel("./", path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Error] Error computing relative path to file: %s", err)
+			return nil
+		}
+		mutants = append(mutants, SolidityFile{
+			Filename:            filepath.Base(path),
+			PathFromProjectRoot: pathFromProjectRoot,
+		})
+		return nil
+	})
+
+	return mutants
+}
+
+// storeMutantsAsDiffs replaces the mutant files by their diffs with
+// --store-diffs. It runs on every start, so mutants generated without the flag
+// or added by 'higher-order' and 'import' get compacted too.
+func storeMutantsAsDiffs(p *Program) error {
+	if !*p.storeDiffs {
+		return nil
+	}
+
+	outdir := gambitOutDir(p)
+	if _, err := os.Stat(filepath.Join(outdir, "gambit_results.json")); err != nil {
+		fmt.Printf("\033[33m[Warning] No gambit_results.json in '%s', the mutants are kept as full files.\033[0m\n", outdir)
+		return nil
+	}
+
+	compacted, err := mutator.CompactMutants(outdir)
+	if err != nil {
+		return fmt.Errorf("Failed to store the mutants as diffs: %w", err)
+	}
+	if compacted > 0 {
+		fmt.Printf("[Info] Stored %d mutants as diffs against their original files.\n", compacted)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunTestsAgainstDiffMutant(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	original := "contract Vault {\n    function f(uint a) external {\n        require(a > 1);\n    }\n}\n"
+	diff := "--- original\n+++ mutant\n@@ -1,5 +1,5 @@\n contract Vault {\n     function f(uint a) external {\n-        require(a > 1);\n+        require(a >= 1);\n     }\n }\n"
+	for path, content := range map[string]string{
+		"src/Vault.sol": original,
+		"gambit_out/mutants/1/src/Vault.sol.diff": diff,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mutants := listMutants("gambit_out/mutants")
+	if len(mutants) != 1 || mutants[0].PathFromProjectRoot != filepath.Join("gambit_out", "mutants", "1", "src", "Vault.sol") {
+		t.Fatalf("unexpected mutants %+v", mutants)
+	}
+
+	// The tests fail, i.e. slay the mutant, if the mutation is in place.
+	testCMD := "! grep -q 'a >= 1' src/Vault.sol"
+	killMatrix := false
+	p := &Program{testCMD: &testCMD, killMatrix: &killMatrix}
+	run, err := runTestsAgainstMutant(p, mutants[0], "src/Vault.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.slain {
+		t.Fatal("expected the mutant to be written over the original and slain")
+	}
+
+	content, err := os.ReadFile("src/Vault.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Fatalf("original not restored, got:\n%s", content)
+	}
+	if _, err := os.Stat("src/Vault.sol.bak"); !os.IsNotExist(err) {
+		t.Fatal("expected the backup to be gone")
+	}
+	if entries, err := os.ReadDir("src"); err != nil || len(entries) != 1 {
+		t.Fatalf("expected only the original in src, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/distributed"
+)
+
+const (
+	defaultCoordinatorAddr = "127.0.0.1:7878"
+	// How long the coordinator keeps answering after the queue is finished,
+	// so that polling workers learn that they can stop.
+	coordinatorShutdownGrace = 5 * time.Second
+	// How many times in a row a worker retries an unreachable coordinator.
+	maxCoordinatorRetries = 5
+)
+
+// runServe implements 'checkmate serve'. It holds the queue of untested
+// mutants together with the analysis state and hands the mutants out to
+// workers started with 'checkmate worker'.
+func runServe(p *Program) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", defaultCoordinatorAddr, "Address the coordinator listens on.")
+	leaseTimeout := fs.Duration("lease-timeout", 10*time.Minute, "How long a worker can hold a mutant without a heartbeat before it's handed to another worker.")
+	if err := fs.Parse(p.commandArgs); err != nil {
+		return err
+	}
+	if *leaseTimeout <= 0 {
+		return fmt.Errorf("The lease timeout must be positive, got: %s", *leaseTimeout)
+	}
+
+	if !mutantsExist(p) {
+		return fmt.Errorf("No mutants to distribute. Run checkmate once to generate the mutants before starting the coordinator.")
+	}
+	if err := ensureMutantsUpToDate(p); err != nil {
+		return err
+	}
+	initializeGeneratedMutantStats(p)
+	if err := syncMutantRecords(p); err != nil {
+		return err
+	}
+	if p.dbState.SlayingProgress.MutantsProcessed == nil {
+		p.dbState.SlayingProgress.MutantsProcessed = make(map[string]bool)
+	}
+
+	var tasks []distributed.Task
+	for _, mutantFile := range listMutants(*p.mutantsDIR) {
+		if p.dbState.SlayingProgress.MutantsProcessed[mutantFile.PathFromProjectRoot] {
+			continue
+		}
+		originalFilePath := getOriginalFilePathFromMutantPath(mutantFile.PathFromProjectRoot, *p.mutantsDIR)
+		if originalFilePath == "" {
+			log.Printf("[Warning] Could not determine original file for mutant %s. Skipping.", mutantFile.PathFromProjectRoot)
+			continue
+		}
+		tasks = append(tasks, distributed.Task{MutantID: mutantFile.PathFromProjectRoot, OriginalFile: originalFilePath})
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("[Info] All mutants were already tested. Nothing to distribute.")
+		printMutationStats(p)
+		return nil
+	}
+
+	resultsReceived := 0
+	coordinator := distributed.NewCoordinator(tasks, *leaseTimeout, func(task distributed.Task, result distributed.Result) {
+		mutantFile := SolidityFile{Filename: filepath.Base(task.MutantID), PathFromProjectRoot: task.MutantID}
+		if result.Slain {
+			fmt.Printf("[Info] Mutant slain 🗡️ (%s) by worker '%s'\n", task.MutantID, result.Worker)
+		} else {
+			fmt.Printf("[Info] Test suite didn't catch the bug ❌ Mutant unslain: (%s) on worker '%s'\n", task.MutantID, result.Worker)
+		}
+		recordMutantResult(p, mutantFile, task.OriginalFile, mutantRun{
+			slain:        result.Slain,
+			killingTests: result.KillingTests,
+			command:      result.TestCommand,
+			exitCode:     result.ExitCode,
+			started:      result.Started,
+			duration:     result.Duration,
+		})
+
+		resultsReceived++
+		if resultsReceived%saveInterval == 0 {
+			recalculateOverallStats(&p.dbState.OverallStats)
+			if errSave := p.store.Save(&p.dbState); errSave != nil {
+				log.Printf("[Warning] Failed to save state on the coordinator: %v", errSave)
+			}
+		}
+	})
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("The coordinator couldn't listen on %s: %w", *addr, err)
+	}
+	server := &http.Server{Handler: coordinator.Handler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[Error] Coordinator server failed: %v", err)
+		}
+	}()
+
+	fmt.Printf("\033[32m[Info] Coordinator listening on http://%s with %d mutant(s) to test.\033[0m\n", listener.Addr(), len(tasks))
+	fmt.Printf("[Info] Start workers with: checkmate worker --coordinator http://%s\n", listener.Addr())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	select {
+	case <-coordinator.Done():
+		fmt.Println("[Info] All mutants were tested.")
+		time.Sleep(coordinatorShutdownGrace)
+	case <-signals:
+		fmt.Println("\n[Info] Stopping the coordinator. Mutants that weren't reported stay untested.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), coordinatorShutdownGrace)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+
+	// The handler above is no longer called, it's safe to touch the state.
+	recalculateOverallStats(&p.dbState.OverallStats)
+	if err := p.store.Save(&p.dbState); err != nil {
+		return fmt.Errorf("Failed to save the coordinator state: %w", err)
+	}
+	fmt.Println("\033[32m[Info] Final state saved successfully.\033[0m")
+
+	printMutationStats(p)
+	return nil
+}
+
+// runWorker implements 'checkmate worker'. It leases mutants from the
+// coordinator, tests them in the current checkout and reports the outcomes.
+func runWorker(p *Program) error {
+	hostname, _ := os.Hostname()
+
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	coordinatorURL := fs.String("coordinator", "", "URL of the coordinator started with 'checkmate serve' e.g. 'http://127.0.0.1:7878'.")
+	name := fs.String("name", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "Name of this worker shown by the coordinator.")
+	poll := fs.Duration("poll", 2*time.Second, "How long to wait before asking again when all mutants are leased out.")
+	if err := fs.Parse(p.commandArgs); err != nil {
+		return err
+	}
+	if *coordinatorURL == "" {
+		return fmt.Errorf("Specify the coordinator with --coordinator e.g. 'http://127.0.0.1:7878'.")
+	}
+
+	client := distributed.NewClient(*coordinatorURL, *name)
+
+	checkForAndRestoreInterruptedState(p)
+
+	fmt.Println("[Info] Attempting an initial test run to check if your test suite is ready for the mutation analysis.")
+	if !testSuitePasses(p, true) {
+		return fmt.Errorf("The test suite of this worker's checkout fails on the original code. Every mutant would look slain, refusing to start.")
+	}
+
+	var interrupted atomic.Bool
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		interrupted.Store(true)
+		fmt.Println("\n[Info] Stopping the worker after the current mutant...")
+	}()
+
+	fmt.Printf("[Info] Worker '%s' connected to %s.\n", client.Worker(), *coordinatorURL)
+
+	tested := 0
+	failedRequests := 0
+	for !interrupted.Load() {
+		lease, err := client.Lease()
+		if errors.Is(err, distributed.ErrQueueFinished) {
+			fmt.Printf("[Info] The coordinator has no more mutants. Worker tested %d mutant(s).\n", tested)
+			return nil
+		}
+		if err != nil {
+			failedRequests++
+			if failedRequests >= maxCoordinatorRetries {
+				return fmt.Errorf("Giving up after %d failed requests: %w", failedRequests, err)
+			}
+			log.Printf("[Warning] %v. Retrying...", err)
+			time.Sleep(*poll)
+			continue
+		}
+		failedRequests = 0
+
+		if lease == nil {
+			time.Sleep(*poll)
+			continue
+		}
+
+		result := testLeasedMutant(p, client, lease, &interrupted)
+		if err := client.Report(result); errors.Is(err, distributed.ErrLeaseExpired) {
+			log.Printf("[Warning] The lease of mutant %s expired before the result was reported. Consider a longer --lease-timeout.", lease.Task.MutantID)
+		} else if err != nil {
+			log.Printf("[Warning] Failed to report the result of mutant %s: %v", lease.Task.MutantID, err)
+		}
+		if result.Error == "" {
+			tested++
+		}
+	}
+
+	fmt.Printf("[Info] Worker stopped. Tested %d mutant(s).\n", tested)
+	return nil
+}
+
+// testLeasedMutant writes the leased mutant straight over its original file in
+// the local checkout, tests it and keeps the lease alive while the tests run.
+func testLeasedMutant(p *Program, client *distributed.Client, lease *distributed.Lease, interrupted *atomic.Bool) distributed.Result {
+	result := distributed.Result{LeaseID: lease.ID, MutantID: lease.Task.MutantID}
+
+	if _, err := os.Stat(lease.Task.OriginalFile); err != nil {
+		result.Error = fmt.Sprintf("original file %s not found in the worker's checkout: %v", lease.Task.OriginalFile, err)
+		return result
+	}
+
+	stopHeartbeat := keepLeaseAlive(client, lease)
+	defer stopHeartbeat()
+
+	fmt.Printf("[Info] Testing mutant %s\n", lease.Task.MutantID)
+	run, err := runTestsAgainstSource(p, []byte(lease.Source), lease.Task.OriginalFile)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// Ctrl+C also reaches the test command which then fails.
+	if interrupted.Load() {
+		result.Error = "worker was interrupted while testing"
+		return result
+	}
+
+	result.Slain = run.slain
+	result.KillingTests = run.killingTests
+	result.TestCommand = run.command
+	result.ExitCode = run.exitCode
+	result.Started = run.started
+	result.Duration = run.duration
+	return result
+}
+
+// keepLeaseAlive sends heartbeats at a third of the lease duration until the
+// returned stop function is called.
+func keepLeaseAlive(client *distributed.Client, lease *distributed.Lease) func() {
+	// The lease duration is used instead of the deadline, so that clock
+	// differences between the machines don't matter.
+	interval := lease.Timeout / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := client.Heartbeat(lease.ID); err != nil {
+					log.Printf("[Warning] Heartbeat for mutant %s failed: %v", lease.Task.MutantID, err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
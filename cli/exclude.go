@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// excludedFile is a Solidity file left out of the gambit config.
+type excludedFile struct {
+	path   string
+	reason string
+}
+
+// selectSolidityFiles applies the include and exclude globs from checkmate's
+// config and, unless disabled, excludes files that have nothing worth
+// mutating: tests, scripts, mocks, interfaces and abstract contracts without
+// implementations. Files configured under 'files' are never excluded
+// automatically.
+func selectSolidityFiles(p *Program, files []SolidityFile) ([]SolidityFile, []excludedFile, error) {
+	include, err := compileGlobs(p.config.Include)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid 'include' pattern in %s: %w", *p.configPath, err)
+	}
+	exclude, err := compileGlobs(p.config.Exclude)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid 'exclude' pattern in %s: %w", *p.configPath, err)
+	}
+	autoExclude := p.config.AutoExclude == nil || *p.config.AutoExclude
+
+	var selected []SolidityFile
+	var excluded []excludedFile
+	for _, file := range files {
+		path := filepath.ToSlash(filepath.Clean(file.PathFromProjectRoot))
+
+		reason := ""
+		if len(include) > 0 && !matchesAny(include, path) {
+			reason = "not matched by the 'include' patterns"
+		} else if pattern, ok := firstMatch(exclude, p.config.Exclude, path); ok {
+			reason = fmt.Sprintf("matched by the exclude pattern '%s'", pattern)
+		} else if _, configured := p.config.Files[filepath.Clean(path)]; autoExclude && !configured {
+			reason = automaticExclusionReason(path)
+		}
+
+		if reason != "" {
+			excluded = append(excluded, excludedFile{path: file.PathFromProjectRoot, reason: reason})
+			continue
+		}
+		selected = append(selected, file)
+	}
+
+	return selected, excluded, nil
+}
+
+// automaticExclusionReason returns why the file at path is not worth
+// mutating, or an empty string if it should be mutated.
+func automaticExclusionReason(path string) string {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".t.sol"):
+		return "test file"
+	case strings.HasSuffix(name, ".s.sol"):
+		return "script"
+	}
+
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		switch strings.ToLower(dir) {
+		case "test", "tests":
+			return fmt.Sprintf("in a '%s' folder", dir)
+		case "script", "scripts":
+			return fmt.Sprintf("in a '%s' folder", dir)
+		case "mock", "mocks":
+			return fmt.Sprintf("mock, in a '%s' folder", dir)
+		}
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		// Let gambit report unreadable files.
+		return ""
+	}
+	unit, err := solidity.Parse(string(source))
+	if err != nil {
+		fmt.Printf("\033[33m[Warning] Couldn't parse '%s' (%v), it won't be excluded automatically.\033[0m\n", path, err)
+		return ""
+	}
+
+	return classifySourceUnit(unit)
+}
+
+// classifySourceUnit returns why a parsed file is not worth mutating, or an
+// empty string if it has code to mutate.
+func classifySourceUnit(unit *solidity.SourceUnit) string {
+	for _, function := range unit.FreeFunctions {
+		if function.HasBody {
+			return ""
+		}
+	}
+	if len(unit.Contracts) == 0 {
+		return "no contracts or functions"
+	}
+
+	interfaces, abstract, mocks := 0, 0, 0
+	implemented := false
+	for _, contract := range unit.Contracts {
+		if isMockName(contract.Name) {
+			mocks++
+		}
+		switch {
+		case contract.Kind == solidity.KindInterface:
+			interfaces++
+		case contract.HasImplementation():
+			implemented = true
+		case contract.Abstract:
+			abstract++
+		}
+	}
+
+	switch {
+	case mocks == len(unit.Contracts):
+		return "mock, only declares mock contracts"
+	case implemented:
+		return ""
+	case interfaces == len(unit.Contracts):
+		return "only declares interfaces"
+	case interfaces+abstract == len(unit.Contracts):
+		return "only declares abstract contracts without function bodies"
+	default:
+		return "has no function bodies to mutate"
+	}
+}
+
+var mockNameRegex = regexp.MustCompile(`Mock([A-Z0-9_]|$)`)
+
+// isMockName matches contract names like 'MockERC20', 'ERC20Mock' or 'Mock'.
+func isMockName(name string) bool {
+	return mockNameRegex.MatchString(name)
+}
+
+// compileGlobs translates glob patterns with '**' support to regular
+// expressions matching slash separated paths.
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// globToRegexp supports '*' (any characters but '/'), '?' (a single
+// character but '/'), '**' (any number of folders) and '[...]' classes.
+// Patterns are matched against paths from the project's root e.g. 'src/Vault.sol'.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	glob := filepath.ToSlash(filepath.Clean(strings.TrimSpace(pattern)))
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("'%s' has an unclosed '['", pattern)
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid pattern: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, path string) bool {
+	_, ok := firstMatch(globs, nil, path)
+	return ok
+}
+
+// firstMatch returns the pattern of the first glob matching path. patterns
+// holds the original patterns of globs, it can be nil if they aren't needed.
+func firstMatch(globs []*regexp.Regexp, patterns []string, path string) (string, bool) {
+	for i, glob := range globs {
+		if glob.MatchString(path) {
+			if patterns != nil {
+				return patterns[i], true
+			}
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// printExcludedFiles lists the files left out of the gambit config.
+func printExcludedFiles(excluded []excludedFile) {
+	if len(excluded) == 0 {
+		return
+	}
+	fmt.Printf("[Info] Excluded %d file(s) from the gambit config:\n", len(excluded))
+	for _, file := range excluded {
+		fmt.Printf("  - %s: %s\n", file.path, file.reason)
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		others  []string
+	}{
+		{"src/**/*.sol", []string{"src/A.sol", "src/a/b/C.sol"}, []string{"lib/A.sol", "src/A.t.sol.bak"}},
+		{"./src/*.sol", []string{"src/A.sol"}, []string{"src/a/B.sol"}},
+		{"**/legacy/**", []string{"legacy/A.sol", "src/legacy/v1/A.sol"}, []string{"src/A.sol"}},
+		{"src/Vault?.sol", []string{"src/Vault2.sol"}, []string{"src/Vault.sol", "src/Vault/.sol"}},
+		{"src/[!I]*.sol", []string{"src/Vault.sol"}, []string{"src/IVault.sol"}},
+	}
+
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.pattern)
+		if err != nil {
+			t.Errorf("globToRegexp(%q): %v", tt.pattern, err)
+			continue
+		}
+		for _, path := range tt.matches {
+			if !re.MatchString(path) {
+				t.Errorf("%q should match %q", tt.pattern, path)
+			}
+		}
+		for _, path := range tt.others {
+			if re.MatchString(path) {
+				t.Errorf("%q should not match %q", tt.pattern, path)
+			}
+		}
+	}
+
+	if _, err := globToRegexp("src/[A.sol"); err == nil {
+		t.Error("expected an error for an unclosed '['")
+	}
+}
+
+func TestClassifySourceUnit(t *testing.T) {
+	tests := map[string]string{
+		"interface IA { function f() external; } interface IB {}":                      "only declares interfaces",
+		"abstract contract A { function f() public virtual; } interface IB {}":         "only declares abstract contracts without function bodies",
+		"contract MockToken { function f() public {} }":                                "mock, only declares mock contracts",
+		"contract TokenMock { function f() public {} } contract Mock {}":               "mock, only declares mock contracts",
+		"contract Vault { function f() public {} } interface IVault {}":                "",
+		"abstract contract Base { function f() internal { x = 1; } }":                  "",
+		"function helper(uint a) pure returns (uint) { return a; }":                    "",
+		"library Types { struct S { uint a; } }":                                       "has no function bodies to mutate",
+		"pragma solidity ^0.8.0; error Unauthorized(); struct Position { uint size; }": "no contracts or functions",
+		"contract Mockingbird { function f() public {} } contract Hammock { uint x; }": "",
+	}
+
+	for source, want := range tests {
+		unit, err := solidity.Parse(source)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", source, err)
+			continue
+		}
+		if got := classifySourceUnit(unit); got != want {
+			t.Errorf("classifySourceUnit(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestAutomaticExclusionByPath(t *testing.T) {
+	tests := map[string]string{
+		"src/Vault.t.sol":         "test file",
+		"script/Deploy.s.sol":     "script",
+		"contracts/test/Foo.sol":  "in a 'test' folder",
+		"contracts/mocks/Foo.sol": "mock, in a 'mocks' folder",
+	}
+	for path, want := range tests {
+		if got := automaticExclusionReason(path); got != want {
+			t.Errorf("automaticExclusionReason(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// fingerprintGeneration hashes the gambit config, the entries of every file
+// and the files themselves. Files that don't exist get an empty hash.
+func fingerprintGeneration(configPath string) (db.GenerationFingerprint, error) {
+	fingerprint := db.GenerationFingerprint{Entries: make(map[string]string), Sources: make(map[string]string)}
+
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return fingerprint, fmt.Errorf("Failed to read the gambit config: %w", err)
+	}
+	fingerprint.Config = hashBytes(config)
+
+	entries, err := mutator.LoadConfig(configPath)
+	if err != nil {
+		return fingerprint, err
+	}
+	fileEntries := make(map[string][]mutator.Entry)
+	for _, entry := range entries {
+		file := filepath.ToSlash(filepath.Clean(entry.Filename))
+		fileEntries[file] = append(fileEntries[file], entry)
+	}
+	for file, entries := range fileEntries {
+		encoded, err := json.Marshal(entries)
+		if err != nil {
+			return fingerprint, err
+		}
+		fingerprint.Entries[file] = hashBytes(encoded)
+
+		content, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			fingerprint.Sources[file] = ""
+			continue
+		} else if err != nil {
+			return fingerprint, fmt.Errorf("Failed to read '%s': %w", file, err)
+		}
+		fingerprint.Sources[file] = hashBytes(content)
+	}
+	return fingerprint, nil
+}
+
+func hashBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordFingerprint stores the fingerprint of the sources the mutants were
+// generated from in the state.
+func recordFingerprint(p *Program) error {
+	fingerprint, err := fingerprintGeneration(*p.gambitConfigPath)
+	if err != nil {
+		return fmt.Errorf("Failed to fingerprint the sources of the mutants: %w", err)
+	}
+	p.dbState.Fingerprint = &fingerprint
+	return nil
+}
+
+// generationDrift lists what changed since the mutants were generated: the
+// gambit config and the changed, removed or added files, sorted.
+type generationDrift struct {
+	config  bool
+	changed []string
+}
+
+func (d generationDrift) any() bool {
+	return d.config || len(d.changed) > 0
+}
+
+func (d generationDrift) String() string {
+	var parts []string
+	if d.config {
+		parts = append(parts, "the gambit config")
+	}
+	for _, file := range d.changed {
+		parts = append(parts, fmt.Sprintf("'%s'", file))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareFingerprints returns what changed between the recorded and the
+// current fingerprint. A file changed if its source or its entries in the
+// gambit config changed. The whole config only counts for fingerprints
+// recorded without the entries.
+func compareFingerprints(recorded, current db.GenerationFingerprint) generationDrift {
+	var drift generationDrift
+	if recorded.Entries == nil {
+		drift.config = recorded.Config != current.Config
+	}
+
+	changed := make(map[string]bool)
+	for _, pair := range [][2]map[string]string{
+		{recorded.Sources, current.Sources},
+		{current.Sources, recorded.Sources},
+	} {
+		for file, hash := range pair[0] {
+			if other, ok := pair[1][file]; !ok || other != hash {
+				changed[file] = true
+			}
+		}
+	}
+	if recorded.Entries != nil {
+		for file, hash := range current.Entries {
+			if recorded.Entries[file] != hash {
+				changed[file] = true
+			}
+		}
+	}
+
+	for file := range changed {
+		drift.changed = append(drift.changed, file)
+	}
+	sort.Strings(drift.changed)
+	return drift
+}
+
+// mutantsDrift returns what changed since the mutants were generated.
+// Mutants generated before fingerprints were recorded are fingerprinted as
+// they are.
+func mutantsDrift(p *Program) (generationDrift, error) {
+	if _, err := os.Stat(*p.mutantsDIR); err != nil {
+		return generationDrift{}, nil // No mutants to slay.
+	}
+	if _, err := os.Stat(*p.gambitConfigPath); err != nil {
+		return generationDrift{}, nil // Mutants imported or generated without a config, there is nothing to compare.
+	}
+	if p.dbState.Fingerprint == nil {
+		fmt.Println("[Info] Fingerprinting the sources of the existing mutants to detect later changes.")
+		return generationDrift{}, recordFingerprint(p)
+	}
+
+	current, err := fingerprintGeneration(*p.gambitConfigPath)
+	if err != nil {
+		return generationDrift{}, fmt.Errorf("Failed to fingerprint the sources of the mutants: %w", err)
+	}
+	return compareFingerprints(*p.dbState.Fingerprint, current), nil
+}
+
+// ensureMutantsUpToDate refuses to slay mutants generated from sources or a
+// gambit config that changed since: copying such a mutant over its original
+// would silently revert the newer code for that test run.
+func ensureMutantsUpToDate(p *Program) error {
+	drift, err := mutantsDrift(p)
+	if err != nil || !drift.any() {
+		return err
+	}
+	return staleMutantsError(p, drift, "Revert the changes, or run checkmate to regenerate the mutants of the changed files.")
+}
+
+func staleMutantsError(p *Program, drift generationDrift, remediation string) error {
+	return fmt.Errorf(`The mutants in '%s' are stale: %s changed since they were generated.
+        Testing them would overwrite the newer code with the old one.
+        %s`,
+		*p.mutantsDIR, drift, remediation)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+)
+
+func TestCompareFingerprints(t *testing.T) {
+	recorded := db.GenerationFingerprint{Config: "c", Sources: map[string]string{"src/A.sol": "a", "src/B.sol": "b", "src/C.sol": "c"}}
+	current := db.GenerationFingerprint{Config: "c", Sources: map[string]string{"src/A.sol": "a", "src/B.sol": "b2", "src/D.sol": "d"}}
+
+	drift := compareFingerprints(recorded, current)
+	if drift.config || strings.Join(drift.changed, ",") != "src/B.sol,src/C.sol,src/D.sol" {
+		t.Fatalf("unexpected drift %+v", drift)
+	}
+	if compareFingerprints(recorded, recorded).any() {
+		t.Fatal("expected no drift for the same fingerprint")
+	}
+	if drift := compareFingerprints(recorded, db.GenerationFingerprint{Config: "c2", Sources: recorded.Sources}); !drift.config || drift.String() != "the gambit config" {
+		t.Fatalf("unexpected drift %+v", drift)
+	}
+
+	// With the entries of each file, a config change only makes the files whose entries changed stale.
+	recorded.Entries = map[string]string{"src/A.sol": "ea", "src/B.sol": "eb", "src/C.sol": "ec"}
+	changedEntries := db.GenerationFingerprint{Config: "c2", Sources: recorded.Sources, Entries: map[string]string{"src/A.sol": "ea2", "src/B.sol": "eb", "src/C.sol": "ec"}}
+	if drift := compareFingerprints(recorded, changedEntries); drift.config || strings.Join(drift.changed, ",") != "src/A.sol" {
+		t.Fatalf("unexpected drift %+v", drift)
+	}
+}
+
+func TestEnsureMutantsUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	for path, content := range map[string]string{
+		"src/Vault.sol":                      "contract Vault {}",
+		"gambit_out/mutants/1/src/Vault.sol": "contract Vault { }",
+		"gambit_config.json":                 `[{"filename": "./src/Vault.sol"}]`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, mutants := "gambit_config.json", "gambit_out/mutants"
+	p := &Program{gambitConfigPath: &config, mutantsDIR: &mutants}
+
+	// Mutants without a fingerprint get one.
+	if err := ensureMutantsUpToDate(p); err != nil {
+		t.Fatal(err)
+	}
+	if p.dbState.Fingerprint == nil || p.dbState.Fingerprint.Sources["src/Vault.sol"] == "" {
+		t.Fatalf("expected a fingerprint of src/Vault.sol, got %+v", p.dbState.Fingerprint)
+	}
+	if err := ensureMutantsUpToDate(p); err != nil {
+		t.Fatalf("unexpected error for unchanged sources: %v", err)
+	}
+
+	if err := os.WriteFile("src/Vault.sol", []byte("contract Vault { uint x; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = ensureMutantsUpToDate(p)
+	if err == nil || !strings.Contains(err.Error(), "'src/Vault.sol' changed") {
+		t.Fatalf("expected stale mutants, got: %v", err)
+	}
+}
+
+func TestHigherOrderRefusesStaleMutants(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	for path, content := range map[string]string{
+		"src/Vault.sol":                      "contract Vault {}",
+		"gambit_out/mutants/1/src/Vault.sol": "contract Vault { }",
+		"gambit_config.json":                 `[{"filename": "./src/Vault.sol"}]`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, mutants := "gambit_config.json", "gambit_out/mutants"
+	p := &Program{gambitConfigPath: &config, mutantsDIR: &mutants}
+	if err := recordFingerprint(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("src/Vault.sol", []byte("contract Vault { uint x; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = runHigherOrder(p)
+	if err == nil || !strings.Contains(err.Error(), "'src/Vault.sol' changed") {
+		t.Fatalf("expected stale mutants, got: %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// gambitFailure is a class of Gambit failures recognized from its output.
+type gambitFailure struct {
+	summary     string
+	remediation string
+	patterns    []*regexp.Regexp
+	// fatal failures stop Gambit right away, the others only explain a
+	// non-zero exit code.
+	fatal bool
+}
+
+// gambitFailures are checked in order, the first match wins.
+var gambitFailures = []gambitFailure{
+	{
+		summary:     "The Solidity compiler version doesn't match the pragma of a file.",
+		remediation: "Remove the 'solc' key of that file in the gambit config to let checkmate pick an installed compiler that matches, or install one with 'solc-select install <version>' or 'svm install <version>'.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`Source file requires different compiler version`),
+		},
+		fatal: true,
+	},
+	{
+		summary:     "The Solidity compiler couldn't resolve an import.",
+		remediation: "Check the 'solc_remappings' and 'solc_allow_paths' of the file in the gambit config, and that the dependencies are installed (e.g. 'forge install' or 'npm install'). Remove the gambit config to regenerate the remappings.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`Source ".*" not found`),
+			regexp.MustCompile(`File outside of allowed directories`),
+			regexp.MustCompile(`File import callback not supported`),
+		},
+		fatal: true,
+	},
+	{
+		summary:     "The Solidity compiler of the gambit config can't be run.",
+		remediation: "Install solc (e.g. with 'solc-select' or 'svm') or fix the 'solc' key of the gambit config so that it points to an installed compiler.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`kind: NotFound`),
+			regexp.MustCompile(`(?i)solc.*(no such file or directory|not found|permission denied)`),
+		},
+		fatal: true,
+	},
+	{
+		summary:     "Gambit failed to validate the mutants.",
+		remediation: "Check that the original files compile with the 'solc' of the gambit config, or set 'skip_validate' in the gambit config to keep the mutants without compiling them.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)(fail|error).*validat|validat.*(fail|error)`),
+		},
+	},
+	{
+		summary:     "Solidity compilation failed during mutation.",
+		remediation: "The 'solc' set for a file in the gambit config may not match its 'pragma solidity'. Remove the 'solc' key of that file to let checkmate pick an installed compiler that matches, or install one with 'solc-select install <version>' or 'svm install <version>'.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`compiler returned exit code`),
+		},
+		fatal: true,
+	},
+}
+
+// gambitSummaryRegex matches Gambit's summary e.g. "Generated 34 mutants in 2.10 seconds".
+var gambitSummaryRegex = regexp.MustCompile(`(?i)generated \d+ mutants`)
+
+const (
+	gambitSnippetLines = 5  // Lines kept after a failure, e.g. the solc version mismatch error.
+	gambitTailLines    = 10 // Lines shown when Gambit fails for an unknown reason.
+)
+
+// gambitMonitor follows Gambit's output. It reports the progress per file of
+// the gambit config and classifies the failures.
+type gambitMonitor struct {
+	out     io.Writer
+	files   []string // Files of the gambit config, slash separated.
+	started map[string]bool
+
+	failure *gambitFailure
+	snippet []string // The failing line and the lines after it.
+	tail    []string // The last lines of the output.
+}
+
+func newGambitMonitor(out io.Writer, entries []mutator.Entry) *gambitMonitor {
+	m := &gambitMonitor{out: out, started: make(map[string]bool)}
+	for _, entry := range entries {
+		file := filepath.ToSlash(filepath.Clean(entry.Filename))
+		if _, ok := m.started[file]; !ok {
+			m.started[file] = false
+			m.files = append(m.files, file)
+		}
+	}
+	return m
+}
+
+// line processes a line of output. It returns true once a fatal failure and
+// its snippet were collected, Gambit can be stopped then.
+func (m *gambitMonitor) line(line string) bool {
+	m.tail = append(m.tail, line)
+	if len(m.tail) > gambitTailLines {
+		m.tail = m.tail[1:]
+	}
+
+	if m.failure != nil && m.failure.fatal {
+		m.snippet = append(m.snippet, line)
+		return len(m.snippet) > gambitSnippetLines
+	}
+	// A fatal failure overrides an earlier validation failure.
+	if failure := classifyGambitLine(line); failure != nil && (m.failure == nil || failure.fatal) {
+		m.failure = failure
+		m.snippet = []string{line}
+		return false
+	}
+	if m.failure != nil && len(m.snippet) <= gambitSnippetLines {
+		m.snippet = append(m.snippet, line)
+	}
+
+	// Gambit mentions a file when it starts mutating it.
+	for _, file := range m.files {
+		if !m.started[file] && strings.Contains(filepath.ToSlash(line), file) {
+			m.started[file] = true
+			fmt.Fprintf(m.out, "[Info] [%d/%d] Mutating %s\n", m.startedCount(), len(m.files), file)
+		}
+	}
+	if gambitSummaryRegex.MatchString(line) {
+		fmt.Fprintf(m.out, "[Info] Gambit: %s\n", strings.TrimSpace(line))
+	}
+	return false
+}
+
+// classifyGambitLine returns the failure a line of Gambit's output reports, nil
+// if there is none.
+func classifyGambitLine(line string) *gambitFailure {
+	for i := range gambitFailures {
+		for _, pattern := range gambitFailures[i].patterns {
+			if pattern.MatchString(line) {
+				return &gambitFailures[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (m *gambitMonitor) startedCount() int {
+	count := 0
+	for _, started := range m.started {
+		if started {
+			count++
+		}
+	}
+	return count
+}
+
+// err turns the outcome of Gambit into an error with a remediation. exitErr is
+// the error of the process, nil if it succeeded.
+func (m *gambitMonitor) err(exitErr error) error {
+	if m.failure == nil {
+		if exitErr == nil {
+			return nil
+		}
+		m.printSnippet("The last lines of Gambit's output are shown below:", m.tail)
+		return fmt.Errorf("Gambit exited with error: %w. Run 'gambit mutate --json <gambit config>' to see its full output.", exitErr)
+	}
+
+	if !m.failure.fatal && exitErr == nil {
+		// Gambit drops the mutants it can't validate and carries on.
+		fmt.Fprintf(m.out, "\033[33m[Warning] %s %s\033[0m\n", m.failure.summary, m.failure.remediation)
+		return nil
+	}
+	m.printSnippet("The error snippet is shown below:", m.snippet)
+	return fmt.Errorf("%s %s", m.failure.summary, m.failure.remediation)
+}
+
+func (m *gambitMonitor) printSnippet(header string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n\033[91m[Error] %s\033[0m\n", header)
+	for _, l := range lines {
+		fmt.Fprintln(os.Stderr, l)
+	}
+}
+
+// runGambit runs 'gambit mutate' on the gambit config. Gambit's stdout and
+// stderr are followed to show the progress per file and to stop it on the
+// first compilation error.
+func runGambit(p *Program) error {
+	entries, err := mutator.LoadConfig(*p.gambitConfigPath)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("gambit", "mutate", "--json", *p.gambitConfigPath)
+	// Gambit logs the files it mutates at the info level.
+	if os.Getenv("RUST_LOG") == "" {
+		cmd.Env = append(os.Environ(), "RUST_LOG=info")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Failed to read Gambit's output: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("Failed to read Gambit's output: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("Gambit is not installed or not on your PATH. Install it from https://github.com/Certora/gambit or use the native mutator with '--mutator native'.")
+		}
+		return fmt.Errorf("Failed to start gambit: %w", err)
+	}
+	fmt.Printf("\033[92m[Info] Mutating %d files with gambit, please wait...\033[0m\n", len(entries))
+
+	lines := make(chan string)
+	var wg sync.WaitGroup
+	for _, pipe := range []io.Reader{stdout, stderr} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner := bufio.NewScanner(pipe)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	monitor := newGambitMonitor(os.Stdout, entries)
+	for line := range lines {
+		if monitor.line(line) {
+			_ = cmd.Process.Kill()
+			// Children of Gambit, e.g. solc, may keep the pipes open.
+			cmd.WaitDelay = time.Second
+			go func() {
+				for range lines {
+				}
+			}()
+			break
+		}
+	}
+	waitErr := cmd.Wait()
+	if err := monitor.err(waitErr); err != nil {
+		return err
+	}
+
+	if len(listMutants(*p.mutantsDIR)) == 0 {
+		return fmt.Errorf("Gambit generated no mutants in '%s'. Check the 'mutations', 'functions' and 'contract' settings of the gambit config, and that its 'outdir' matches --mutants-dir.", *p.mutantsDIR)
+	}
+	fmt.Println("\n[Info] Mutants generated ✅")
+	return nil
+}
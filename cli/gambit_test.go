@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+func TestClassifyGambitLine(t *testing.T) {
+	tests := map[string]string{
+		`Error: Source file requires different compiler version (current compiler is 0.8.20) - note that nightly builds are considered to be strictly less than the released version`: "compiler version",
+		`ParserError: Source "@openzeppelin/contracts/token/ERC20/IERC20.sol" not found: File not found.`:                                                                             "resolve an import",
+		`Error: Os { code: 2, kind: NotFound, message: "No such file or directory" }`:                                                                                                 "can't be run",
+		`[ERROR gambit] Failed to validate mutant 3`:                                                                                                                                  "validate the mutants",
+		`thread 'main' panicked at 'compiler returned exit code 1'`:                                                                                                                   "compilation failed",
+		`[INFO gambit::mutator] Mutating src/Vault.sol`:                                                                                                                               "",
+	}
+
+	for line, want := range tests {
+		failure := classifyGambitLine(line)
+		if want == "" {
+			if failure != nil {
+				t.Errorf("classifyGambitLine(%q) = %q, want no failure", line, failure.summary)
+			}
+			continue
+		}
+		if failure == nil || !strings.Contains(failure.summary, want) {
+			t.Errorf("classifyGambitLine(%q) = %v, want a failure about %q", line, failure, want)
+		}
+	}
+}
+
+func TestGambitMonitor(t *testing.T) {
+	var out strings.Builder
+	m := newGambitMonitor(&out, []mutator.Entry{{Filename: "./src/Vault.sol"}, {Filename: "src/Token.sol"}, {Filename: "src/Vault.sol"}})
+
+	lines := []string{
+		"[INFO gambit] Mutating file /project/src/Vault.sol",
+		"[INFO gambit] Validating mutants of src/Vault.sol",
+		"[ERROR gambit] Mutant 2 failed validation",
+		"[INFO gambit] Mutating file src/Token.sol",
+		"Error: Source file requires different compiler version",
+		"1", "2", "3", "4",
+	}
+	for _, line := range lines {
+		if m.line(line) {
+			t.Fatalf("stopped early at %q", line)
+		}
+	}
+	if !m.line("5") {
+		t.Fatal("expected to stop once the snippet is collected")
+	}
+
+	want := "[Info] [1/2] Mutating src/Vault.sol\n[Info] [2/2] Mutating src/Token.sol\n"
+	if out.String() != want {
+		t.Errorf("got progress\n%s\nwant\n%s", out.String(), want)
+	}
+	// The compiler error overrides the validation failure.
+	err := m.err(nil)
+	if err == nil || !strings.Contains(err.Error(), "compiler version") || !strings.Contains(err.Error(), "solc-select install") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunGambitNotInstalled(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	config := filepath.Join(dir, "gambit_config.json")
+	if err := os.WriteFile(config, []byte(`[{"filename": "src/Vault.sol"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mutants := filepath.Join(dir, "gambit_out", "mutants")
+
+	err := runGambit(&Program{gambitConfigPath: &config, mutantsDIR: &mutants})
+	if err == nil || !strings.Contains(err.Error(), "--mutator native") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunGambitFailure(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	// The fake Gambit would hang after the error if it wasn't stopped.
+	script := "#!/bin/sh\necho 'Mutating src/Vault.sol'\necho 'ParserError: Source \"lib/A.sol\" not found: File not found.' >&2\nfor i in 1 2 3 4 5 6; do echo $i >&2; done\nsleep 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "gambit"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "gambit_config.json")
+	if err := os.WriteFile(config, []byte(`[{"filename": "src/Vault.sol"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mutants := filepath.Join(dir, "gambit_out", "mutants")
+
+	err := runGambit(&Program{gambitConfigPath: &config, mutantsDIR: &mutants})
+	if err == nil || !strings.Contains(err.Error(), "resolve an import") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
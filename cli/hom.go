@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// higherOrderOptions holds the arguments of the 'higher-order' command.
+type higherOrderOptions struct {
+	order int // Number of first-order mutants combined into each mutant.
+	max   int // Maximum number of mutants generated.
+}
+
+func parseHigherOrderFlags(args []string) (higherOrderOptions, error) {
+	var opts higherOrderOptions
+
+	fs := flag.NewFlagSet("higher-order", flag.ContinueOnError)
+	fs.IntVar(&opts.order, "order", 2, "Number of first-order mutants combined into each higher-order mutant.")
+	fs.IntVar(&opts.max, "max", 100, "Maximum number of higher-order mutants generated. 0 means no limit.")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() != 0 {
+		return opts, fmt.Errorf("Usage: checkmate higher-order [--order <n>] [--max <n>]")
+	}
+
+	return opts, nil
+}
+
+// runHigherOrder implements 'checkmate higher-order'. It combines first-order
+// mutants of the same function into higher-order mutants, which reveal the
+// tests that only check one of several interacting statements. The
+// combinations of survivors come first. The new mutants are added to the
+// mutants directory and slayed by the next run.
+func runHigherOrder(p *Program) error {
+	opts, err := parseHigherOrderFlags(p.commandArgs)
+	if err != nil {
+		return err
+	}
+	// The first-order mutants are located in the current originals, which
+	// must not have changed since they were generated.
+	if err := ensureMutantsUpToDate(p); err != nil {
+		return err
+	}
+
+	var entries []mutator.Entry
+	if _, err := os.Stat(*p.gambitConfigPath); err == nil {
+		if entries, err = mutator.LoadConfig(*p.gambitConfigPath); err != nil {
+			return err
+		}
+	}
+
+	survivors := make(map[string]bool)
+	for path, processed := range p.dbState.SlayingProgress.MutantsProcessed {
+		// Slain mutants are removed from the mutants directory, the rest survived.
+		if processed && mutator.MutantExists(path) {
+			survivors[mutantIDFromPath(path, *p.mutantsDIR)] = true
+		}
+	}
+	exclude := make(map[string]bool)
+	for _, file := range p.dbState.AnalyzedFiles {
+		for id := range file.EquivalentMutants {
+			exclude[id] = true
+		}
+	}
+
+	outdir := gambitOutDir(p)
+	fmt.Printf("[Info] Combining first-order mutants of order %d (%d survivors), please wait...\n", opts.order, len(survivors))
+	results, err := mutator.GenerateHigherOrder(outdir, entries, mutator.HigherOrderOptions{
+		Order:     opts.order,
+		Max:       opts.max,
+		Survivors: survivors,
+		Exclude:   exclude,
+	})
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("[Info] No new higher-order mutants were generated.")
+		return nil
+	}
+
+	countGeneratedMutants(p, results)
+	if p.dbState.OverallStats.MutantsTotalGenerated > 0 {
+		if err := p.store.Save(&p.dbState); err != nil {
+			return fmt.Errorf("Failed to save the state: %w", err)
+		}
+	}
+
+	fmt.Printf("\n[Info] %d higher-order mutants added to '%s' ✅\n", len(results), *p.mutantsDIR)
+	fmt.Println("[Info] Run checkmate to slay them.")
+	return nil
+}
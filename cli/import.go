@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/importer"
+)
+
+// importOptions holds the arguments of the 'import' command.
+type importOptions struct {
+	tool  string // Name of the mutation tool e.g. "slither".
+	path  string // Output of the tool to import.
+	force bool   // Replace existing mutants.
+}
+
+func parseImportFlags(args []string) (importOptions, error) {
+	var opts importOptions
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.BoolVar(&opts.force, "force", false, "Replace the mutants that are already in the mutants directory.")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() != 2 {
+		return opts, fmt.Errorf("Usage: checkmate import [--force] <%s> <path to the tool's output>", strings.Join(importer.Names(), "|"))
+	}
+	opts.tool, opts.path = fs.Arg(0), fs.Arg(1)
+
+	return opts, nil
+}
+
+// runImport implements 'checkmate import'. It converts the mutants of another
+// mutation tool into Gambit's layout so that the next run slays them instead
+// of generating new ones.
+func runImport(p *Program) error {
+	opts, err := parseImportFlags(p.commandArgs)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(*p.mutantsDIR); err == nil && len(listMutants(*p.mutantsDIR)) > 0 && !opts.force {
+		return fmt.Errorf("The mutants directory '%s' already contains mutants. Pass --force to replace them or remove them with 'checkmate clean --mutants'.", *p.mutantsDIR)
+	}
+
+	outdir := gambitOutDir(p)
+	results, err := importer.Import(opts.tool, opts.path, outdir, importer.Options{SourceDir: *p.contractsDIR})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n[Info] %d mutants imported to '%s' ✅\n", len(results), *p.mutantsDIR)
+	if _, err := os.Stat(p.store.Path()); err == nil {
+		fmt.Printf("\033[33m[Warning] '%s' holds the results of previous mutants. Run 'checkmate clean --state' before slaying the imported ones.\033[0m\n", p.store.Path())
+	}
+	fmt.Println("[Info] Run checkmate to slay the imported mutants.")
+	return nil
+}
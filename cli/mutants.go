@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// mutantFilter selects the records listed by 'checkmate mutants'. Empty
+// fields match every record.
+type mutantFilter struct {
+	status   string
+	file     string
+	operator string
+	function string // "name" or "Contract.name".
+}
+
+func (f mutantFilter) matches(record db.MutantRecord) bool {
+	if f.status != "" && record.Status != f.status {
+		return false
+	}
+	if f.file != "" && record.OriginalFile != f.file {
+		return false
+	}
+	if f.operator != "" && record.Operator != f.operator {
+		return false
+	}
+	if f.function != "" && record.Function != f.function &&
+		qualifiedFunction(record.Contract, record.Function) != f.function {
+		return false
+	}
+	return true
+}
+
+// runMutants implements 'checkmate mutants'. It lists the mutant records of
+// the state, optionally filtered, as a table or as JSON.
+func runMutants(p *Program) error {
+	var filter mutantFilter
+	fs := flag.NewFlagSet("mutants", flag.ContinueOnError)
+	fs.StringVar(&filter.status, "status", "", "Only list the mutants with this status: pending, slain, unslain, equivalent or duplicate.")
+	fs.StringVar(&filter.file, "file", "", "Only list the mutants of this original file e.g. 'src/Vault.sol'.")
+	fs.StringVar(&filter.operator, "operator", "", "Only list the mutants of this operator e.g. 'require-mutation'.")
+	fs.StringVar(&filter.function, "function", "", "Only list the mutants in this function, 'name' or 'Contract.name'.")
+	asJSON := fs.Bool("json", false, "Print the records as JSON, keyed by the path of the mutant.")
+	if err := fs.Parse(p.commandArgs); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("Unexpected arguments: %s. Filter the mutants with the flags, see 'checkmate mutants -h'.", strings.Join(fs.Args(), " "))
+	}
+
+	filter.status = strings.ToUpper(filter.status)
+	switch filter.status {
+	case "", db.MutantPending, db.MutantSlain, db.MutantUnslain, db.MutantEquivalent, db.MutantDuplicate:
+	default:
+		return fmt.Errorf("Unknown status '%s', use pending, slain, unslain, equivalent or duplicate.", strings.ToLower(filter.status))
+	}
+
+	if len(p.dbState.Mutants) == 0 {
+		return fmt.Errorf("No mutant records in '%s'. Run checkmate to generate the mutants first.", p.store.Path())
+	}
+
+	selected := make(map[string]db.MutantRecord)
+	for path, record := range p.dbState.Mutants {
+		if filter.matches(record) {
+			selected[path] = record
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false) // Diffs are full of '<' and '>'.
+		return encoder.Encode(selected)
+	}
+	writeMutantRecords(os.Stdout, selected)
+	fmt.Printf("\n%d of %d mutants listed.\n", len(selected), len(p.dbState.Mutants))
+	return nil
+}
+
+// writeMutantRecords writes a table of the records, by mutant ID.
+func writeMutantRecords(out io.Writer, records map[string]db.MutantRecord) {
+	paths := make([]string, 0, len(records))
+	for path := range records {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if a, b := records[paths[i]].ID, records[paths[j]].ID; a != b {
+			return mutator.LessID(a, b)
+		}
+		return paths[i] < paths[j]
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tStatus\tLocation\tFunction\tOperator\tDuration\n")
+	for _, path := range paths {
+		record := records[path]
+		location := record.OriginalFile
+		if record.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", record.OriginalFile, record.Line, record.Column)
+		}
+		function := "-"
+		if record.Function != "" {
+			function = qualifiedFunction(record.Contract, record.Function)
+		}
+		duration := "-"
+		if record.ExitCode != nil {
+			duration = roundDuration(time.Duration(record.DurationMs) * time.Millisecond).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", record.ID, record.Status, location, function, record.Operator, duration)
+	}
+	w.Flush()
+}
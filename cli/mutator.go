@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// Mutator generates the mutants described by the gambit config into the
+// mutants directory, in Gambit's output layout.
+type Mutator interface {
+	// Name is the human readable name of the mutator e.g. "Gambit".
+	Name() string
+
+	// Generate creates the mutants, replacing previously generated ones.
+	Generate(p *Program) error
+}
+
+// gambitMutator runs the 'gambit' binary.
+type gambitMutator struct{}
+
+func (gambitMutator) Name() string { return "Gambit" }
+
+func (gambitMutator) Generate(p *Program) error { return runGambit(p) }
+
+// nativeMutator generates the mutants with checkmate's own mutator, without
+// Gambit or a Rust toolchain.
+type nativeMutator struct{}
+
+func (nativeMutator) Name() string { return "native mutator" }
+
+func (nativeMutator) Generate(p *Program) error {
+	entries, err := mutator.LoadConfig(*p.gambitConfigPath)
+	if err != nil {
+		return err
+	}
+
+	outdir := mutator.DefaultOutdir
+	if len(entries) > 0 && entries[0].Outdir != "" {
+		outdir = entries[0].Outdir
+	}
+	if filepath.Clean(filepath.Join(outdir, "mutants")) != filepath.Clean(*p.mutantsDIR) {
+		fmt.Printf("\033[33m[Warning] The mutants are written to '%s' but checkmate reads them from '%s'. Set --mutants-dir to match the 'outdir' of the gambit config.\033[0m\n", filepath.Join(outdir, "mutants"), *p.mutantsDIR)
+	}
+
+	fmt.Println("[Info] Mutating the code with the native mutator, please wait...")
+	results, err := mutator.Generate(entries, mutator.Options{})
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("The native mutator generated no mutants. Check the 'mutations', 'functions' and 'contract' settings of the gambit config.")
+	}
+
+	fmt.Printf("\n[Info] %d mutants generated ✅\n", len(results))
+	return nil
+}
+
+// selectMutator returns the mutator with the given name. "auto" picks Gambit
+// if it is installed and falls back to the native mutator otherwise.
+func selectMutator(name string) (Mutator, error) {
+	switch strings.ToLower(name) {
+	case "gambit":
+		return gambitMutator{}, nil
+	case "native":
+		return nativeMutator{}, nil
+	case "auto", "":
+		if _, err := exec.LookPath("gambit"); err == nil {
+			return gambitMutator{}, nil
+		}
+		fmt.Println("[Info] Gambit is not installed, using the native mutator. Install Gambit or pass '--mutator gambit' to use it.")
+		return nativeMutator{}, nil
+	default:
+		return nil, fmt.Errorf("Unknown mutator '%s', available mutators are: auto, gambit, native.", name)
+	}
+}
+
+// generateExtraMutants adds the mutants of the security pack and the rules
+// in checkmate's config to the generated ones. Both apply to the files of
+// the gambit config.
+func generateExtraMutants(p *Program) error {
+	if len(p.config.SecurityOperators.Operators) == 0 && len(p.config.Rules) == 0 {
+		return nil
+	}
+
+	entries, err := mutator.LoadConfig(*p.gambitConfigPath)
+	if err != nil {
+		return err
+	}
+	outdir := gambitOutDir(p)
+
+	if operators := p.config.SecurityOperators.Operators; len(operators) > 0 {
+		selected := make(map[string]bool)
+		for _, name := range operators {
+			selected[mutator.SecurityOperators[name]] = true
+		}
+
+		fmt.Printf("[Info] Applying %d security operator(s): %s...\n", len(operators), strings.Join(operators, ", "))
+		results, err := mutator.AppendMutants(outdir, entries, "security", mutator.SecurityFinder(selected), mutator.Options{})
+		if err != nil {
+			return fmt.Errorf("Failed to apply the security operators: %w", err)
+		}
+		fmt.Printf("[Info] %d mutants generated by the security operators ✅\n", len(results))
+	}
+
+	if len(p.config.Rules) > 0 {
+		rules := make(map[string][]mutator.Rule)
+		for i, rule := range p.config.Rules {
+			files, err := compileGlobs(rule.Files)
+			if err != nil {
+				return fmt.Errorf("Invalid 'files' pattern of rule #%d in %s: %w", i+1, *p.configPath, err)
+			}
+			for _, entry := range entries {
+				if len(files) == 0 || matchesAny(files, filepath.ToSlash(filepath.Clean(entry.Filename))) {
+					rules[entry.Filename] = append(rules[entry.Filename], rule.Rule)
+				}
+			}
+		}
+
+		fmt.Printf("[Info] Applying %d mutation rule(s) from %s...\n", len(p.config.Rules), *p.configPath)
+		results, err := mutator.AppendMutants(outdir, entries, "rule", mutator.RuleFinder(rules), mutator.Options{})
+		if err != nil {
+			return fmt.Errorf("Failed to apply the mutation rules: %w", err)
+		}
+		fmt.Printf("[Info] %d mutants generated from the rules ✅\n", len(results))
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// previewFunctionsShown is the number of functions listed in the preview, the
+// ones with the most mutants first.
+const previewFunctionsShown = 20
+
+// outsideFunctions groups the mutants that are not in a function e.g. in a
+// state variable, or whose function can't be found.
+const outsideFunctions = "(outside of functions)"
+
+// mutantDistribution counts the mutants by file, operator and function.
+type mutantDistribution struct {
+	total      int
+	byFile     map[string]int
+	byOperator map[string]int
+	byFunction map[string]int // Keyed by "file: Contract.function".
+}
+
+// distributeMutants counts the mutants. Their functions are found from their
+// diffs against the original files.
+func distributeMutants(results []mutator.Result) mutantDistribution {
+	d := mutantDistribution{
+		byFile:     make(map[string]int),
+		byOperator: make(map[string]int),
+		byFunction: make(map[string]int),
+	}
+
+	locator := newMutantLocator()
+	for _, result := range results {
+		d.total++
+		d.byFile[result.Original]++
+		d.byOperator[result.Description]++
+
+		function := outsideFunctions
+		if location, ok := locator.locate(result); ok && location.function != "" {
+			function = qualifiedFunction(location.contract, location.function)
+		}
+		d.byFunction[result.Original+": "+function]++
+	}
+	return d
+}
+
+// qualifiedFunction returns "Contract.function", or the function alone for
+// free functions.
+func qualifiedFunction(contract, function string) string {
+	if contract == "" {
+		return function
+	}
+	return contract + "." + function
+}
+
+// untestedResults returns the mutants of gambit_results.json that are still
+// in the mutants directory and weren't tested yet.
+func untestedResults(p *Program) ([]mutator.Result, error) {
+	outdir := gambitOutDir(p)
+	results, err := mutator.ReadResults(outdir)
+	if err != nil {
+		return nil, err
+	}
+
+	var untested []mutator.Result
+	for _, result := range results {
+		path := filepath.Join(outdir, result.Name)
+		if p.dbState.SlayingProgress.MutantsProcessed[path] {
+			continue
+		}
+		if mutator.MutantExists(path) {
+			untested = append(untested, result)
+		}
+	}
+	return untested, nil
+}
+
+// printMutantDistribution shows where the untested mutants are and how long
+// slaying them should take, given the duration of the baseline test run.
+func printMutantDistribution(p *Program, baseline time.Duration) {
+	results, err := untestedResults(p)
+	if err != nil {
+		fmt.Printf("\033[33m[Warning] Can't preview the mutant distribution: %v\033[0m\n", err)
+		return
+	}
+	if len(results) == 0 {
+		return
+	}
+	writeMutantDistribution(os.Stdout, distributeMutants(results), baseline, *p.killMatrix)
+}
+
+func writeMutantDistribution(out io.Writer, d mutantDistribution, baseline time.Duration, killMatrix bool) {
+	fmt.Fprintf(out, "\n--------- Mutant Distribution ---------\n\n")
+	fmt.Fprintf(out, "Mutants to slay: %d\n", d.total)
+
+	// Mutants are tested with the fail-fast command, which usually stops well
+	// before the full suite does. The whole suite runs with --kill-matrix.
+	estimate := roundDuration(baseline * time.Duration(d.total))
+	if killMatrix {
+		fmt.Fprintf(out, "Estimated slaying time: %s (%s per mutant, the baseline run)\n", estimate, roundDuration(baseline))
+	} else {
+		fmt.Fprintf(out, "Estimated slaying time: up to %s (%s per mutant, the baseline run)\n", estimate, roundDuration(baseline))
+	}
+
+	writeCounts(out, "File", d.byFile, d.total, 0)
+	writeCounts(out, "Operator", d.byOperator, d.total, 0)
+	writeCounts(out, "Function", d.byFunction, d.total, previewFunctionsShown)
+
+	fmt.Fprintln(out, "\nPrune 'files', 'functions' or 'mutations' in the gambit config (or checkmate.json) and regenerate the mutants to slay fewer of them.")
+	fmt.Fprintln(out, "\n----------------------------------------")
+}
+
+// roundDuration rounds to the second, or to the millisecond below a second.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
+
+// writeCounts writes a table of the counts, the biggest first. limit caps the
+// number of rows, 0 means no limit.
+func writeCounts(out io.Writer, title string, counts map[string]int, total, limit int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	shown := keys
+	if limit > 0 && len(keys) > limit {
+		shown = keys[:limit]
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tMutants\tShare\n", title)
+	for _, key := range shown {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", key, counts[key], float64(counts[key])/float64(total)*100)
+	}
+	w.Flush()
+	if len(shown) < len(keys) {
+		fmt.Fprintf(out, "... and %d more\n", len(keys)-len(shown))
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+func TestDistributeMutants(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	source := `pragma solidity ^0.8.0;
+
+contract Vault {
+    uint256 public limit = 10 + 1;
+
+    function withdraw(uint256 amount) external {
+        require(amount <= limit);
+        if (amount > 0) revert();
+    }
+}
+`
+	if err := os.WriteFile("Vault.sol", []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries := []mutator.Entry{{Filename: "Vault.sol", Mutations: []string{"require-mutation", "binary-op-mutation"}, SkipValidate: true}}
+	results, err := mutator.Generate(entries, mutator.Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := distributeMutants(results)
+	if d.total != len(results) || d.byFile["Vault.sol"] != len(results) {
+		t.Fatalf("unexpected totals %+v for %d mutants", d, len(results))
+	}
+	if d.byOperator[mutator.Require] != 2 {
+		t.Errorf("expected 2 require mutants, got %d", d.byOperator[mutator.Require])
+	}
+	outside := d.byFunction["Vault.sol: "+outsideFunctions]
+	if outside == 0 || outside+d.byFunction["Vault.sol: Vault.withdraw"] != len(results) {
+		t.Errorf("unexpected functions %v", d.byFunction)
+	}
+
+	var out strings.Builder
+	writeMutantDistribution(&out, d, 2*time.Second, false)
+	for _, want := range []string{
+		"Estimated slaying time: up to ",
+		"Vault.withdraw",
+		"RequireMutation",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the preview:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteCounts(t *testing.T) {
+	var out strings.Builder
+	writeCounts(&out, "Operator", map[string]int{"A": 1, "B": 3, "C": 1}, 5, 2)
+
+	want := "\nOperator  Mutants  Share\nB         3        60.0%\nA         1        20.0%\n... and 1 more\n"
+	if out.String() != want {
+		t.Errorf("got\n%q\nwant\n%q", out.String(), want)
+	}
+}
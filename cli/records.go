@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// mutantLocation is where a mutant changes its original file.
+type mutantLocation struct {
+	line, column int
+	contract     string // Empty for free functions and outside of contracts.
+	function     string // Empty outside of functions.
+}
+
+// mutantLocator finds the mutations in their original files, which are read
+// and parsed once.
+type mutantLocator struct {
+	sources map[string]string
+	units   map[string]*solidity.SourceUnit // nil if the file can't be read or parsed.
+}
+
+func newMutantLocator() *mutantLocator {
+	return &mutantLocator{
+		sources: make(map[string]string),
+		units:   make(map[string]*solidity.SourceUnit),
+	}
+}
+
+// locate recovers the mutation from the diff of the mutant. It reports false
+// if the original file can't be parsed or the diff doesn't apply to it.
+func (l *mutantLocator) locate(result mutator.Result) (mutantLocation, bool) {
+	if _, ok := l.units[result.Original]; !ok {
+		l.units[result.Original] = nil
+		if content, err := os.ReadFile(result.Original); err == nil {
+			if unit, err := solidity.Parse(string(content)); err == nil {
+				l.sources[result.Original] = string(content)
+				l.units[result.Original] = unit
+			}
+		}
+	}
+	unit := l.units[result.Original]
+	if unit == nil {
+		return mutantLocation{}, false
+	}
+
+	source := l.sources[result.Original]
+	mutation, ok, err := mutator.Recover(source, result)
+	if err != nil || !ok {
+		return mutantLocation{}, false
+	}
+	location := mutantLocation{
+		line:   strings.Count(source[:mutation.Start], "\n") + 1,
+		column: mutation.Start - strings.LastIndexByte(source[:mutation.Start], '\n'),
+	}
+
+	token := sort.Search(len(unit.Tokens), func(i int) bool { return unit.Tokens[i].End() > mutation.Start })
+	contract, function, inFunction := unit.FunctionAt(token)
+	if contract != nil {
+		location.contract = contract.Name
+	}
+	if inFunction {
+		location.function = function.Name
+	}
+	return location, true
+}
+
+// syncMutantRecords keeps a record of every mutant of gambit_results.json.
+// New mutants get one, and the records of mutants that are gone are dropped.
+// Mutants tested before the records existed get their status from the state:
+// survivors are still in the mutants directory, slain mutants were removed.
+func syncMutantRecords(p *Program) error {
+	outdir := gambitOutDir(p)
+	results, err := mutator.ReadResults(outdir)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil // Without results the counters are all there is.
+	}
+	if p.dbState.Mutants == nil {
+		p.dbState.Mutants = make(map[string]db.MutantRecord)
+	}
+
+	locator := newMutantLocator()
+	listed := make(map[string]bool)
+	added := 0
+	for _, result := range results {
+		path := filepath.Join(outdir, result.Name)
+		listed[path] = true
+		// A different diff means the path was reused by a regenerated mutant.
+		if record, ok := p.dbState.Mutants[path]; ok && record.Diff == result.Diff {
+			continue
+		}
+		p.dbState.Mutants[path] = newMutantRecord(p, path, result, locator)
+		added++
+	}
+	for path := range p.dbState.Mutants {
+		if !listed[path] {
+			delete(p.dbState.Mutants, path)
+		}
+	}
+	if added > 0 {
+		fmt.Printf("[Info] Recorded %d mutant(s) from '%s'.\n", added, filepath.Join(outdir, "gambit_results.json"))
+	}
+
+	deriveStats(p)
+	return nil
+}
+
+// newMutantRecord describes a mutant of gambit_results.json, with the status
+// the state already knows of.
+func newMutantRecord(p *Program, path string, result mutator.Result, locator *mutantLocator) db.MutantRecord {
+	record := db.MutantRecord{
+		ID:           result.ID,
+		Operator:     result.Description,
+		OriginalFile: result.Original,
+		Diff:         result.Diff,
+		Status:       db.MutantPending,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	if location, ok := locator.locate(result); ok {
+		record.Line = location.line
+		record.Column = location.column
+		record.Contract = location.contract
+		record.Function = location.function
+	}
+
+	if equivalence, ok := p.dbState.AnalyzedFiles[result.Original].EquivalentMutants[result.ID]; ok {
+		record.Status = equivalence.Status
+	} else if p.dbState.SlayingProgress.MutantsProcessed[path] {
+		record.Status = db.MutantSlain
+		if mutator.MutantExists(path) {
+			record.Status = db.MutantUnslain
+		}
+		record.KillingTests = p.dbState.SlayingProgress.KillingTests[path]
+	}
+	return record
+}
+
+// recordMutantRun keeps the outcome of a test run in the record of the
+// mutant.
+func recordMutantRun(p *Program, path string, run mutantRun) {
+	record, ok := p.dbState.Mutants[path]
+	if !ok {
+		return
+	}
+	record.Status = db.MutantUnslain
+	if run.slain {
+		record.Status = db.MutantSlain
+	}
+	record.TestCommand = run.command
+	exitCode := run.exitCode
+	record.ExitCode = &exitCode
+	record.DurationMs = run.duration.Milliseconds()
+	record.KillingTests = run.killingTests
+	if !run.started.IsZero() {
+		record.StartedAt = run.started.UTC().Format(time.RFC3339)
+		record.FinishedAt = run.started.Add(run.duration).UTC().Format(time.RFC3339)
+	}
+	p.dbState.Mutants[path] = record
+}
+
+// deriveStats computes the overall and per-file statistics from the mutant
+// records, so that they can't drift from the results. Without records, e.g.
+// for a mutants directory without gambit_results.json, the counters are kept.
+func deriveStats(p *Program) {
+	if len(p.dbState.Mutants) == 0 {
+		return
+	}
+
+	files := make(map[string]*db.FileSpecificStats)
+	for file := range p.dbState.AnalyzedFiles {
+		files[file] = &db.FileSpecificStats{}
+	}
+	for _, record := range p.dbState.Mutants {
+		stats, ok := files[record.OriginalFile]
+		if !ok {
+			stats = &db.FileSpecificStats{}
+			files[record.OriginalFile] = stats
+		}
+		stats.MutantsTotalGenerated++
+		switch record.Status {
+		case db.MutantSlain:
+			stats.MutantsTotalSlain++
+		case db.MutantEquivalent:
+			stats.MutantsTotalEquivalent++
+		case db.MutantDuplicate:
+			stats.MutantsTotalDuplicate++
+		}
+	}
+
+	var overall db.OverallStats
+	for file, stats := range files {
+		recalculateFileStats(stats)
+		entry, ok := p.dbState.AnalyzedFiles[file]
+		if !ok {
+			entry = db.AnalyzedFile{
+				FileSpecificRecommendations: make([]string, 0),
+				LLMAnalysisOutcomes:         make(map[string]db.MutantLLMAnalysisOutcome),
+			}
+		}
+		entry.FileSpecificStats = *stats
+		p.dbState.AnalyzedFiles[file] = entry
+
+		overall.MutantsTotalGenerated += stats.MutantsTotalGenerated
+		overall.MutantsTotalSlain += stats.MutantsTotalSlain
+		overall.MutantsTotalEquivalent += stats.MutantsTotalEquivalent
+		overall.MutantsTotalDuplicate += stats.MutantsTotalDuplicate
+	}
+	recalculateOverallStats(&overall)
+	p.dbState.OverallStats = overall
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+func TestMutantRecords(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	source := `pragma solidity ^0.8.0;
+
+contract Vault {
+    uint256 public limit = 10 + 1;
+
+    function withdraw(uint256 amount) external {
+        require(amount <= limit);
+        if (amount > 0) revert();
+    }
+}
+`
+	if err := os.WriteFile("Vault.sol", []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries := []mutator.Entry{{Filename: "Vault.sol", Mutations: []string{"require-mutation", "binary-op-mutation"}, SkipValidate: true}}
+	results, err := mutator.Generate(entries, mutator.Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var require, binary []string
+	for _, result := range results {
+		path := filepath.Join(mutator.DefaultOutdir, result.Name)
+		if result.Description == mutator.Require {
+			require = append(require, path)
+		} else {
+			binary = append(binary, path)
+		}
+	}
+	if len(require) != 2 || len(binary) == 0 {
+		t.Fatalf("unexpected mutants %+v", results)
+	}
+
+	// A state from before the records: the first require mutant was slain and
+	// removed, the second survived. The counters drifted.
+	if err := os.RemoveAll(filepath.Dir(require[0])); err != nil {
+		t.Fatal(err)
+	}
+	mutantsDIR := filepath.Join(mutator.DefaultOutdir, "mutants")
+	killMatrix := false
+	p := &Program{mutantsDIR: &mutantsDIR, killMatrix: &killMatrix}
+	p.dbState = db.MutationAnalysis{
+		OverallStats:          db.OverallStats{MutantsTotalGenerated: 3, MutantsTotalSlain: 5},
+		AnalyzedFiles:         map[string]db.AnalyzedFile{},
+		SlayingProgress:       db.SlayingProgress{MutantsProcessed: map[string]bool{require[0]: true, require[1]: true}},
+		LanguageModelProgress: db.LanguageModelProgress{MutantsProcessed: map[string]bool{}},
+	}
+
+	if err := syncMutantRecords(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.dbState.Mutants) != len(results) {
+		t.Fatalf("got %d records for %d mutants", len(p.dbState.Mutants), len(results))
+	}
+	for path, want := range map[string]string{require[0]: db.MutantSlain, require[1]: db.MutantUnslain, binary[0]: db.MutantPending} {
+		if got := p.dbState.Mutants[path].Status; got != want {
+			t.Errorf("%s: got status %s, want %s", path, got, want)
+		}
+	}
+	record := p.dbState.Mutants[require[1]]
+	if record.Line != 7 || record.Column == 0 || record.Contract != "Vault" || record.Function != "withdraw" || record.Diff == "" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if stats := p.dbState.OverallStats; stats.MutantsTotalGenerated != int32(len(results)) || stats.MutantsTotalSlain != 1 {
+		t.Errorf("statistics not computed from the records: %+v", stats)
+	}
+
+	// Testing a mutant fills its record in.
+	started := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mutantFile := SolidityFile{Filename: "Vault.sol", PathFromProjectRoot: binary[0]}
+	recordMutantResult(p, mutantFile, "Vault.sol", mutantRun{slain: true, command: "forge test", exitCode: 1, started: started, duration: 1500 * time.Millisecond})
+	record = p.dbState.Mutants[binary[0]]
+	if record.Status != db.MutantSlain || record.ExitCode == nil || *record.ExitCode != 1 || record.TestCommand != "forge test" ||
+		record.DurationMs != 1500 || record.StartedAt != "2026-10-18T12:00:00Z" || record.FinishedAt != "2026-10-18T12:00:01Z" {
+		t.Errorf("unexpected record after testing %+v", record)
+	}
+	if stats := p.dbState.AnalyzedFiles["Vault.sol"].FileSpecificStats; stats.MutantsTotalSlain != 2 || stats.MutantsTotalUnslain != int32(len(results)-2) {
+		t.Errorf("unexpected file statistics %+v", stats)
+	}
+
+	// The filters of 'checkmate mutants' work on the records.
+	count := func(filter mutantFilter) int {
+		n := 0
+		for _, record := range p.dbState.Mutants {
+			if filter.matches(record) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(mutantFilter{status: db.MutantSlain}); n != 2 {
+		t.Errorf("got %d slain mutants, want 2", n)
+	}
+	if n := count(mutantFilter{operator: mutator.Require, function: "Vault.withdraw"}); n != 2 {
+		t.Errorf("got %d require mutants in Vault.withdraw, want 2", n)
+	}
+}
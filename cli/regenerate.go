@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// regenerateDIR is where the mutants of the changed files are generated
+// before they are merged into the mutants directory, next to it.
+const regenerateDIR = ".regenerate"
+
+// refreshStaleMutants regenerates the mutants of the files that changed since
+// the mutants were generated. The results of the mutants of the other files
+// are kept. A change to the whole gambit config, or --skip-gambit, can't be
+// handled file by file, so stale mutants are refused then.
+func refreshStaleMutants(p *Program) error {
+	drift, err := mutantsDrift(p)
+	if err != nil || !drift.any() {
+		return err
+	}
+	if drift.config {
+		return staleMutantsError(p, drift, "Revert the changes, or regenerate all mutants with 'checkmate clean --mutants --state' and re-run checkmate.")
+	}
+	if *p.skipGambit {
+		return staleMutantsError(p, drift, "Revert the changes, or re-run checkmate without --skip-gambit to regenerate the mutants of the changed files.")
+	}
+
+	fmt.Printf("\033[33m[Info] %d file(s) changed since the mutants were generated: %s. Regenerating their mutants, the results of the other files are kept.\033[0m\n", len(drift.changed), drift)
+	return regenerateMutants(p, drift.changed)
+}
+
+// regenerateMutants replaces the mutants of the files and drops their results
+// from the state. Files that are no longer in the gambit config or no longer
+// exist only lose their mutants.
+func regenerateMutants(p *Program, files []string) error {
+	changed := make(map[string]bool)
+	for _, file := range files {
+		changed[file] = true
+	}
+
+	outdir := gambitOutDir(p)
+	removed, err := mutator.RemoveMutants(outdir, changed)
+	if err != nil {
+		return fmt.Errorf("Failed to remove the stale mutants: %w", err)
+	}
+	for _, result := range removed {
+		forgetMutant(p, outdir, result)
+		if err := os.RemoveAll(filepath.Join(outdir, excludedDIR, result.ID)); err != nil {
+			return fmt.Errorf("Failed to remove the excluded mutant %s: %w", result.ID, err)
+		}
+	}
+	for _, file := range files {
+		forgetFile(p, file)
+	}
+	fmt.Printf("[Info] Removed %d stale mutants.\n", len(removed))
+
+	entries, err := mutator.LoadConfig(*p.gambitConfigPath)
+	if err != nil {
+		return err
+	}
+	tmpOutdir := filepath.Join(outdir, regenerateDIR)
+	var regenerate []mutator.Entry
+	for _, entry := range entries {
+		file := filepath.ToSlash(filepath.Clean(entry.Filename))
+		if !changed[file] {
+			continue
+		}
+		if _, err := os.Stat(entry.Filename); err != nil {
+			fmt.Printf("\033[33m[Warning] '%s' is in the gambit config but can't be read, it gets no mutants: %v\033[0m\n", entry.Filename, err)
+			continue
+		}
+		entry.Outdir = tmpOutdir
+		regenerate = append(regenerate, entry)
+	}
+
+	if len(regenerate) > 0 {
+		added, err := generateInto(p, tmpOutdir, regenerate)
+		if err != nil {
+			return err
+		}
+		countGeneratedMutants(p, added)
+		fmt.Printf("[Info] %d mutants regenerated for %d file(s) ✅\n", len(added), len(regenerate))
+	}
+
+	if err := recordFingerprint(p); err != nil {
+		return err
+	}
+	if err := p.store.Save(&p.dbState); err != nil {
+		return fmt.Errorf("Failed to save the state after regenerating the mutants: %w", err)
+	}
+	return nil
+}
+
+// generateInto runs the mutator and the extra operators on the entries into
+// tmpOutdir, then merges the mutants into the mutants directory.
+func generateInto(p *Program, tmpOutdir string, entries []mutator.Entry) ([]mutator.Result, error) {
+	if err := os.RemoveAll(tmpOutdir); err != nil {
+		return nil, fmt.Errorf("Failed to clear '%s': %w", tmpOutdir, err)
+	}
+	if err := os.MkdirAll(tmpOutdir, 0o755); err != nil {
+		return nil, fmt.Errorf("Failed to create '%s': %w", tmpOutdir, err)
+	}
+	defer os.RemoveAll(tmpOutdir)
+
+	// Gambit resolves the paths of a config from its directory, so the config of
+	// the changed files goes next to the full one.
+	config := filepath.Join(filepath.Dir(*p.gambitConfigPath), ".gambit_config.regenerate.json")
+	defer os.Remove(config)
+	content, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(config, content, 0o644); err != nil {
+		return nil, fmt.Errorf("Failed to write the gambit config of the changed files: %w", err)
+	}
+
+	// The mutators read the config and write the mutants where the program
+	// points them to.
+	regen := *p
+	mutantsDIR := filepath.Join(tmpOutdir, "mutants")
+	regen.gambitConfigPath = &config
+	regen.mutantsDIR = &mutantsDIR
+
+	selected, err := selectMutator(*p.mutatorName)
+	if err != nil {
+		return nil, err
+	}
+	if err := selected.Generate(&regen); err != nil {
+		return nil, err
+	}
+	if err := generateExtraMutants(&regen); err != nil {
+		return nil, err
+	}
+
+	return mutator.MergeMutants(gambitOutDir(p), tmpOutdir)
+}
+
+// forgetMutant drops the record and the slaying and LLM results of a removed
+// mutant.
+func forgetMutant(p *Program, outdir string, result mutator.Result) {
+	path := filepath.Join(outdir, result.Name)
+	delete(p.dbState.Mutants, path)
+	delete(p.dbState.SlayingProgress.MutantsProcessed, path)
+	delete(p.dbState.SlayingProgress.KillingTests, path)
+	delete(p.dbState.LanguageModelProgress.MutantsProcessed, result.ID)
+}
+
+// forgetFile drops the statistics, equivalences and LLM outcomes of a file
+// and takes its mutants out of the overall statistics.
+func forgetFile(p *Program, file string) {
+	entry, ok := p.dbState.AnalyzedFiles[file]
+	if !ok {
+		return
+	}
+	stats := &p.dbState.OverallStats
+	stats.MutantsTotalGenerated -= entry.FileSpecificStats.MutantsTotalGenerated
+	stats.MutantsTotalSlain -= entry.FileSpecificStats.MutantsTotalSlain
+	stats.MutantsTotalEquivalent -= entry.FileSpecificStats.MutantsTotalEquivalent
+	stats.MutantsTotalDuplicate -= entry.FileSpecificStats.MutantsTotalDuplicate
+	recalculateOverallStats(stats)
+	delete(p.dbState.AnalyzedFiles, file)
+}
+
+// countGeneratedMutants adds new mutants to the generated counts. Without
+// stats the mutants directory is counted from scratch later on.
+func countGeneratedMutants(p *Program, results []mutator.Result) {
+	if p.dbState.OverallStats.MutantsTotalGenerated == 0 && len(p.dbState.AnalyzedFiles) == 0 {
+		return
+	}
+	for _, result := range results {
+		p.dbState.OverallStats.MutantsTotalGenerated++
+		fileAnalysisEntry := p.dbState.AnalyzedFiles[result.Original]
+		fileAnalysisEntry.FileSpecificStats.MutantsTotalGenerated++
+		recalculateFileStats(&fileAnalysisEntry.FileSpecificStats)
+		p.dbState.AnalyzedFiles[result.Original] = fileAnalysisEntry
+	}
+	recalculateOverallStats(&p.dbState.OverallStats)
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+)
+
+// compilerSelector picks a solc binary for each file from its 'pragma
+// solidity' range. The installed compilers are looked up once, on first use.
+type compilerSelector struct {
+	binDir        string        // Extra folder with solc binaries from checkmate's config.
+	configPath    string        // Path to checkmate's config, for the hints.
+	preferred     *solc.Version // Version pinned by the framework's config, used when the pragma allows it.
+	installations []solc.Installation
+	loaded        bool
+
+	selected map[string][]string // Files per selected compiler, for the summary.
+	missing  []string            // Files without a matching compiler, with their pragma range.
+}
+
+func newCompilerSelector(p *Program) *compilerSelector {
+	selector := &compilerSelector{
+		binDir:     p.config.SolcBinaries,
+		configPath: *p.configPath,
+		selected:   make(map[string][]string),
+	}
+	if version, err := solc.ParseVersion(p.buildSettings.SolcVersion); err == nil {
+		selector.preferred = &version
+	}
+	return selector
+}
+
+// selectFor returns the path of the compiler for the Solidity file at path.
+// ok is false if the file has no pragma or no installed compiler matches it,
+// the latter is reported by err() once all files were processed.
+func (s *compilerSelector) selectFor(path string) (string, bool) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("\033[33m[Warning] Couldn't read '%s' to detect its compiler version: %v\033[0m\n", path, err)
+		return "", false
+	}
+
+	versionRange, found, err := solc.PragmaRange(string(source))
+	if err != nil {
+		fmt.Printf("\033[33m[Warning] Couldn't detect the compiler version of '%s': %v\033[0m\n", path, err)
+		return "", false
+	}
+	if !found {
+		fmt.Printf("[Info] '%s' has no 'pragma solidity', it will be compiled with the default solc.\n", path)
+		return "", false
+	}
+
+	if !s.loaded {
+		s.loaded = true
+		s.installations, err = solc.Installed(s.binDir)
+		if err != nil {
+			fmt.Printf("\033[33m[Warning] %v\033[0m\n", err)
+		}
+	}
+
+	installation, ok := solc.Select(versionRange, s.installations, s.preferred)
+	if !ok {
+		s.missing = append(s.missing, fmt.Sprintf("%s (pragma solidity %s)", path, versionRange))
+		return "", false
+	}
+
+	label := fmt.Sprintf("solc %s (%s)", installation.Version, installation.Source)
+	s.selected[label] = append(s.selected[label], path)
+	return installation.Path, true
+}
+
+// printSummary lists how many files are compiled with each compiler.
+func (s *compilerSelector) printSummary() {
+	labels := make([]string, 0, len(s.selected))
+	for label := range s.selected {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Printf("[Info] %d file(s) will be compiled with %s.\n", len(s.selected[label]), label)
+	}
+}
+
+// err reports the files for which no installed compiler matches the pragma.
+func (s *compilerSelector) err() error {
+	if len(s.missing) == 0 {
+		return nil
+	}
+
+	// Without any compiler there is nothing to choose from. The files are
+	// left to the default 'solc' rather than failing, the native mutator
+	// works without one.
+	if len(s.installations) == 0 {
+		fmt.Printf("\033[33m[Warning] No Solidity compiler found (solc-select, svm, 'solc_binaries' or 'solc' on the PATH). %d file(s) have no compiler set.\033[0m\n", len(s.missing))
+		return nil
+	}
+
+	var installed []string
+	for _, installation := range s.installations {
+		installed = append(installed, installation.Version.String())
+	}
+
+	return fmt.Errorf(`No installed Solidity compiler matches the pragma of:
+        - %s
+        Installed versions: %s.
+        Install a matching version with 'solc-select install <version>' or 'svm install <version>',
+        or point 'solc_binaries' in %s at a folder with solc binaries.`,
+		strings.Join(s.missing, "\n        - "), strings.Join(installed, ", "), s.configPath)
+}
+
+// ensureGambitCompilers fills in the 'solc' of the entries in an existing
+// gambit config that don't set one, so configs generated before the compiler
+// was detected (or edited by hand) work too. Other keys are kept as they are.
+func ensureGambitCompilers(p *Program) error {
+	content, err := os.ReadFile(*p.gambitConfigPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read the gambit config: %w", err)
+	}
+
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return fmt.Errorf("Failed to parse the gambit config '%s': %w", *p.gambitConfigPath, err)
+	}
+
+	selector := newCompilerSelector(p)
+	updated := false
+	for _, entry := range entries {
+		if _, ok := entry["solc"]; ok {
+			continue
+		}
+		var filename string
+		if err := json.Unmarshal(entry["filename"], &filename); err != nil || filename == "" {
+			continue
+		}
+		if path, ok := selector.selectFor(filename); ok {
+			entry["solc"], _ = json.Marshal(path)
+			updated = true
+		}
+	}
+	selector.printSummary()
+	if err := selector.err(); err != nil {
+		return err
+	}
+	if !updated {
+		return nil
+	}
+
+	jsonData, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return fmt.Errorf("[Error] There was a problem marshalling gambit entries: %s", err)
+	}
+	if err := os.WriteFile(*p.gambitConfigPath, jsonData, 0o644); err != nil {
+		return fmt.Errorf("Failed to update the gambit config: %w", err)
+	}
+	fmt.Printf("[Info] Added the detected compilers to %s.\n", *p.gambitConfigPath)
+
+	return nil
+}
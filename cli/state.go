@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+)
+
+const stateUsage = "Usage: checkmate state validate [<path to the state file>] | checkmate state schema"
+
+// runState implements 'checkmate state'. 'validate' checks a state file
+// against the JSON Schema of the current schema version, migrating older
+// states in memory first, and 'schema' prints the schema. The state file
+// isn't loaded beforehand, so that broken states can be validated too.
+func runState(p *Program) error {
+	if len(p.commandArgs) == 0 {
+		return fmt.Errorf(stateUsage)
+	}
+
+	switch p.commandArgs[0] {
+	case "validate":
+		fs := flag.NewFlagSet("state validate", flag.ContinueOnError)
+		if err := fs.Parse(p.commandArgs[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 1 {
+			return fmt.Errorf(stateUsage)
+		}
+		path := p.store.Path()
+		if fs.NArg() == 1 {
+			path = fs.Arg(0)
+		}
+		return validateStateFile(path)
+	case "schema":
+		if len(p.commandArgs) != 1 {
+			return fmt.Errorf(stateUsage)
+		}
+		fmt.Print(string(db.StateSchema()))
+		return nil
+	default:
+		return fmt.Errorf("Unknown state command: '%s'. %s", p.commandArgs[0], stateUsage)
+	}
+}
+
+func validateStateFile(path string) error {
+	content, err := db.StateDocument(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read the state file: %w", err)
+	}
+
+	version, problems, err := db.ValidateState(content)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid state file: %w", path, err)
+	}
+	if version < db.SchemaVersion {
+		fmt.Printf("[Info] '%s' has schema version %d, checkmate migrates it to version %d on the next run. It was validated as migrated.\n", path, version, db.SchemaVersion)
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+		return fmt.Errorf("'%s' doesn't match the schema of version %d, %d problem(s) found.", path, db.SchemaVersion, len(problems))
+	}
+
+	fmt.Printf("[Info] '%s' is a valid state file of schema version %d ✅\n", path, db.SchemaVersion)
+	return nil
+}
+
+// newStore returns the state store selected with --state-store.
+func newStore(name string) (db.Store, error) {
+	path := stateFileName
+	if name == db.LogStoreName {
+		path = stateLogFileName
+	}
+	return db.NewStore(name, path)
+}
+
+// convertStoredState moves the state kept by the other store into store, if
+// store has none yet, so that switching stores keeps the results.
+func convertStoredState(store db.Store) error {
+	if _, err := os.Stat(store.Path()); err == nil {
+		return nil
+	}
+	otherName := db.LogStoreName
+	if _, ok := store.(*db.LogStore); ok {
+		otherName = db.JSONStoreName
+	}
+	other, err := newStore(otherName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(other.Path()); err != nil {
+		return nil
+	}
+
+	state, err := other.Load()
+	if err != nil {
+		return fmt.Errorf("Failed to load '%s' to convert it: %w", other.Path(), err)
+	}
+	if err := store.Save(&state); err != nil {
+		return fmt.Errorf("Failed to convert '%s' to '%s': %w", other.Path(), store.Path(), err)
+	}
+	// Left in place, the old file would be converted again after a
+	// 'checkmate clean --state'.
+	if err := os.Remove(other.Path()); err != nil {
+		return fmt.Errorf("Failed to remove '%s' after converting it: %w", other.Path(), err)
+	}
+	fmt.Printf("[Info] Converted the state in '%s' to '%s'.\n", other.Path(), store.Path())
+	return nil
+}
+
+// readOnlyCommand reports whether the command only reads the state, so it
+// can run while another process holds the lock. Workers get their mutants
+// from the coordinator and don't use the state at all. 'checkmate state'
+// validates the state or prints its schema.
+func readOnlyCommand(p *Program) bool {
+	switch p.command {
+	case "status", "mutants", "worker", "state":
+		return true
+	}
+	return *p.printReport
+}
+
+// lockState takes the lock of the state before it's loaded, so that two
+// processes don't overwrite each other's progress. Read-only commands don't
+// take it, their store refuses to save instead.
+func lockState(p *Program) error {
+	if readOnlyCommand(p) {
+		p.store = db.ReadOnly(p.store)
+		if holder, err := db.ReadLock(stateLockFileName); err == nil && holder != nil && *p.printReport {
+			fmt.Printf("[Info] %s is working on the state, showing the progress it saved last.\n", holder)
+		}
+		return nil
+	}
+
+	lock, stale, err := db.AcquireLock(stateLockFileName, strings.Join(os.Args, " "))
+	var locked *db.LockedError
+	if errors.As(err, &locked) {
+		return fmt.Errorf("Another checkmate process is working on the state: %s.\n"+
+			"           Wait for it to finish, or follow its progress with 'checkmate status' or 'checkmate --print'.\n"+
+			"           If it no longer runs, e.g. it was on another host, remove '%s'.", locked.Holder, locked.Path)
+	} else if err != nil {
+		return err
+	}
+	if stale != nil {
+		fmt.Printf("\033[33m[Warning] Took over the lock of %s, which no longer runs. Its unsaved progress is lost.\033[0m\n", stale)
+	}
+	p.lock = lock
+
+	if err := convertStoredState(p.store); err != nil {
+		releaseStateLock(p)
+		return err
+	}
+	return nil
+}
+
+// releaseStateLock releases the lock of the state, if the process holds it.
+// A lock that another process took over in the meantime is left to it.
+func releaseStateLock(p *Program) {
+	if p.lock == nil {
+		return
+	}
+	if err := p.lock.Release(); err != nil {
+		log.Printf("[Warning] %v", err)
+	}
+	p.lock = nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+)
+
+// runStatus implements 'checkmate status': who is working on the state, if
+// anyone, and how far the analysis got. It only reads the state, so it can
+// follow a run going on in another terminal.
+func runStatus(p *Program) error {
+	if len(p.commandArgs) > 0 {
+		return fmt.Errorf("Usage: checkmate status")
+	}
+
+	holder, err := db.ReadLock(stateLockFileName)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		fmt.Println("[Info] No checkmate process is working on the state.")
+	} else {
+		fmt.Printf("[Info] Working on the state: %s\n", holder)
+	}
+
+	stats := p.dbState.OverallStats
+	if stats.MutantsTotalGenerated == 0 && len(p.dbState.AnalyzedFiles) == 0 {
+		fmt.Printf("[Info] No analysis data found in '%s'.\n", p.store.Path())
+		return nil
+	}
+
+	scored := stats.MutantsTotalGenerated - stats.MutantsTotalEquivalent - stats.MutantsTotalDuplicate
+	fmt.Printf("Mutants tested: %d of %d\n", len(p.dbState.SlayingProgress.MutantsProcessed), scored)
+	fmt.Printf("Mutants slain: %d, unslain: %d, score: %.2f%%\n", stats.MutantsTotalSlain, stats.MutantsTotalUnslain, stats.MutationScore)
+	if analyzed := len(p.dbState.LanguageModelProgress.MutantsProcessed); analyzed > 0 {
+		fmt.Printf("Unslain mutants analyzed by the LLM: %d of %d\n", analyzed, stats.MutantsTotalUnslain)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+	"github.com/ChmielewskiKamil/checkmate/subsumption"
+)
+
+// analyzeSubsumption runs the subsumption analysis on the tested mutants. Slain
+// mutants without kill data, e.g. tested without --kill-matrix or killed by a
+// compilation error, are left out and counted in missing. ok is false if
+// there is no kill data at all.
+func analyzeSubsumption(p *Program) (analysis subsumption.Analysis, missing int, ok bool) {
+	killingTests := p.dbState.SlayingProgress.KillingTests
+	if len(killingTests) == 0 {
+		return analysis, 0, false
+	}
+
+	var mutants []subsumption.Mutant
+	for path, processed := range p.dbState.SlayingProgress.MutantsProcessed {
+		if !processed {
+			continue
+		}
+		id := mutantIDFromPath(path, *p.mutantsDIR)
+		if tests, killed := killingTests[path]; killed {
+			mutants = append(mutants, subsumption.Mutant{ID: id, Tests: tests})
+		} else if mutator.MutantExists(path) {
+			// Slain mutants are removed from the mutants directory, the rest survived.
+			mutants = append(mutants, subsumption.Mutant{ID: id})
+		} else {
+			missing++
+		}
+	}
+
+	return subsumption.Analyze(mutants), missing, true
+}
+
+// mutantIDFromPath extracts the ID of a mutant from its path e.g. "15" from
+// "gambit_out/mutants/15/src/Vault.sol".
+func mutantIDFromPath(path, mutantsBaseDir string) string {
+	rel, err := filepath.Rel(filepath.Clean(mutantsBaseDir), filepath.Clean(path))
+	if err != nil {
+		return path
+	}
+	return strings.Split(rel, string(filepath.Separator))[0]
+}
+
+func printSubsumptionReport(p *Program) {
+	analysis, missing, ok := analyzeSubsumption(p)
+	if !ok {
+		return
+	}
+
+	details := make(map[string]mutator.Result)
+	if results, err := mutator.ReadResults(gambitOutDir(p)); err == nil {
+		for _, result := range results {
+			details[result.ID] = result
+		}
+	}
+
+	fmt.Printf("\n## Subsumption Analysis\n\n")
+	fmt.Printf("- Dominator mutants (killed): %d\n", len(analysis.Dominators))
+	fmt.Printf("- Subsumed mutants: %d\n", len(analysis.Subsumed))
+	fmt.Printf("- Survivors: %d\n", len(analysis.Survivors))
+	fmt.Printf("- Subsumption-adjusted Mutation Score: %.2f%%\n", analysis.Score)
+	if missing > 0 {
+		fmt.Printf("- Slain mutants without kill data (not analyzed): %d\n", missing)
+	}
+
+	if len(analysis.Dominators) == 0 {
+		return
+	}
+	fmt.Printf("\n### Dominator Mutants\n")
+	fmt.Println("Every other slain mutant is killed by all tests killing one of these, they are the ones worth reviewing.")
+	for _, group := range analysis.Dominators {
+		id := group.Mutants[0]
+		line := fmt.Sprintf("- Mutant ID `%s`", id)
+		if result, found := details[id]; found {
+			line += fmt.Sprintf(" (%s in `%s`)", result.Description, result.Original)
+		}
+		line += fmt.Sprintf(": killed by %s", strings.Join(group.Tests, ", "))
+		if redundant := group.Mutants[1:]; len(redundant) > 0 {
+			line += fmt.Sprintf(". Redundant: %s", strings.Join(redundant, ", "))
+		}
+		fmt.Println(line)
+	}
+}
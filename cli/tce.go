@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// excludedDIR is where the equivalent and duplicate mutants are moved to,
+// next to the mutants directory.
+const excludedDIR = "excluded"
+
+// runTCE excludes the equivalent and duplicate mutants found by trivial
+// compiler equivalence from slaying. They are moved out of the mutants
+// directory to '<outdir>/excluded' and recorded in the state, so that they
+// count neither as survivors nor in the mutation score.
+func runTCE(p *Program) error {
+	var entries []mutator.Entry
+	if _, err := os.Stat(*p.gambitConfigPath); err == nil {
+		if entries, err = mutator.LoadConfig(*p.gambitConfigPath); err != nil {
+			return err
+		}
+	}
+
+	outdir := gambitOutDir(p)
+	results, err := mutator.ReadResults(outdir)
+	if err != nil {
+		return err
+	}
+
+	// Only the untested mutants still in the mutants directory are compared.
+	var candidates []mutator.Result
+	for _, result := range results {
+		path := filepath.Join(outdir, result.Name)
+		if p.dbState.SlayingProgress.MutantsProcessed[path] {
+			continue
+		}
+		if mutator.MutantExists(path) {
+			candidates = append(candidates, result)
+		}
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("\033[33m[Warning] No untested mutants listed in '%s'. Skipping the TCE pre-pass.\033[0m\n", filepath.Join(outdir, "gambit_results.json"))
+		return nil
+	}
+
+	fmt.Printf("[Info] Compiling %d mutants to find the equivalent and duplicate ones (TCE), please wait...\n", len(candidates))
+	equivalences, err := mutator.TCE(outdir, entries, candidates, mutator.Options{})
+	if err != nil {
+		return fmt.Errorf("Trivial compiler equivalence failed: %w", err)
+	}
+
+	equivalent, duplicate := 0, 0
+	for _, e := range equivalences {
+		from := filepath.Join(*p.mutantsDIR, e.ID)
+		to := filepath.Join(outdir, excludedDIR, e.ID)
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return fmt.Errorf("Failed to create '%s': %w", filepath.Dir(to), err)
+		}
+		if err := os.RemoveAll(to); err != nil {
+			return fmt.Errorf("Failed to replace '%s': %w", to, err)
+		}
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("Failed to move mutant %s to '%s': %w", e.ID, to, err)
+		}
+
+		recordEquivalence(p, e)
+		if e.Status == mutator.Equivalent {
+			equivalent++
+		} else {
+			duplicate++
+		}
+	}
+	recalculateOverallStats(&p.dbState.OverallStats)
+
+	fmt.Printf("\033[32m[Info] TCE excluded %d equivalent and %d duplicate mutants, they were moved to '%s'.\033[0m\n",
+		equivalent, duplicate, filepath.Join(outdir, excludedDIR))
+	if err := p.store.Save(&p.dbState); err != nil {
+		fmt.Fprintf(os.Stderr, "[Warning] Failed to save the state after the TCE pre-pass: %v\n", err)
+	}
+	return nil
+}
+
+// recordEquivalence stores the outcome of an excluded mutant, in its record
+// too, and takes it out of the score of its file.
+func recordEquivalence(p *Program, e mutator.Equivalence) {
+	fileAnalysisEntry := p.dbState.AnalyzedFiles[e.Original]
+	if fileAnalysisEntry.EquivalentMutants == nil {
+		fileAnalysisEntry.EquivalentMutants = make(map[string]db.MutantEquivalence)
+	}
+	fileAnalysisEntry.EquivalentMutants[e.ID] = db.MutantEquivalence{
+		MutantID:    e.ID,
+		Status:      e.Status,
+		DuplicateOf: e.DuplicateOf,
+	}
+
+	if e.Status == mutator.Equivalent {
+		p.dbState.OverallStats.MutantsTotalEquivalent++
+		fileAnalysisEntry.FileSpecificStats.MutantsTotalEquivalent++
+	} else {
+		p.dbState.OverallStats.MutantsTotalDuplicate++
+		fileAnalysisEntry.FileSpecificStats.MutantsTotalDuplicate++
+	}
+	recalculateFileStats(&fileAnalysisEntry.FileSpecificStats)
+
+	p.dbState.AnalyzedFiles[e.Original] = fileAnalysisEntry
+
+	path := filepath.Join(*p.mutantsDIR, e.ID, e.Original)
+	if record, ok := p.dbState.Mutants[path]; ok {
+		record.Status = e.Status
+		p.dbState.Mutants[path] = record
+		deriveStats(p)
+	}
+}
@@ -0,0 +1,382 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/framework"
+)
+
+// watchOptions holds the settings of the 'watch' command.
+type watchOptions struct {
+	testsDIR string        // Path to the folder with the test files. Default is "test/".
+	interval time.Duration // How often the watched directories are polled for changes.
+}
+
+// fileSnapshot maps a Solidity file path to its last modification time.
+type fileSnapshot map[string]time.Time
+
+func parseWatchFlags(args []string) (watchOptions, error) {
+	var opts watchOptions
+
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.StringVar(&opts.testsDIR, "tests-path", "./test", "Specify the path to the folder with your test files.")
+	fs.DurationVar(&opts.interval, "interval", time.Second, "How often to check the watched directories for changes.")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("Unexpected arguments for watch: %v", fs.Args())
+	}
+	if opts.interval <= 0 {
+		return opts, fmt.Errorf("The watch interval must be positive, got: %s", opts.interval)
+	}
+
+	return opts, nil
+}
+
+// runWatch implements 'checkmate watch'. It monitors the tests and contracts
+// directories and, whenever a test file is saved, re-tests the surviving
+// mutants of the contracts that this test file (transitively) imports.
+func runWatch(p *Program) error {
+	opts, err := parseWatchFlags(p.commandArgs)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(opts.testsDIR); err != nil {
+		return fmt.Errorf("Can't watch the tests directory '%s': %w", opts.testsDIR, err)
+	}
+	if _, err := os.Stat(*p.contractsDIR); err != nil {
+		return fmt.Errorf("Can't watch the contracts directory '%s': %w", *p.contractsDIR, err)
+	}
+	if len(p.dbState.SlayingProgress.MutantsProcessed) == 0 {
+		return fmt.Errorf("No slaying results found in '%s'. Run checkmate once to test all mutants before using watch mode.", p.store.Path())
+	}
+	checkForAndRestoreInterruptedState(p)
+	if err := ensureMutantsUpToDate(p); err != nil {
+		return err
+	}
+	lines, err := p.framework.Remappings(*p.contractsDIR)
+	if err != nil {
+		return err
+	}
+	remappings := parseRemappings(lines)
+
+	// Ctrl+C is handled between mutants so that the original sources are
+	// always restored before exiting.
+	var interrupted atomic.Bool
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		interrupted.Store(true)
+		fmt.Println("\n[Info] Stopping watch mode...")
+	}()
+
+	fmt.Printf("[Info] Watching '%s' and '%s' for changes. Press Ctrl+C to stop.\n", opts.testsDIR, *p.contractsDIR)
+	printLiveScore(p)
+
+	// Contracts changed since their mutants were generated. Testing their
+	// mutants would revert the changes, so they are skipped until the
+	// mutants are regenerated or the changes reverted.
+	stale := make(map[string]bool)
+
+	previous := snapshotSolidityFiles(opts.testsDIR, *p.contractsDIR)
+	for !interrupted.Load() {
+		time.Sleep(opts.interval)
+
+		current := snapshotSolidityFiles(opts.testsDIR, *p.contractsDIR)
+		changed := changedFiles(previous, current)
+		previous = current
+		if len(changed) == 0 {
+			continue
+		}
+
+		var changedTests []string
+		contractsChanged := false
+		for _, path := range changed {
+			// Tests can live inside the contracts directory e.g. 'src/test/'.
+			if isWithinDir(path, opts.testsDIR) || !isWithinDir(path, *p.contractsDIR) {
+				changedTests = append(changedTests, path)
+			} else {
+				contractsChanged = true
+			}
+		}
+
+		if contractsChanged {
+			if stale, err = staleContracts(p, stale); err != nil {
+				return err
+			}
+		}
+
+		if len(changedTests) > 0 {
+			if err := retestSurvivors(p, changedTests, remappings, stale, &interrupted); err != nil {
+				return err
+			}
+			// Slaying writes to the contracts directory, take a fresh snapshot
+			// of it so that our own writes are not reported as changes. Test
+			// files saved during the re-test are picked up on the next poll.
+			previous.refresh(*p.contractsDIR, opts.testsDIR)
+		}
+	}
+
+	if err := p.store.Save(&p.dbState); err != nil {
+		return fmt.Errorf("Failed to save state when leaving watch mode: %w", err)
+	}
+	fmt.Println("\033[32m[Info] Final state saved successfully.\033[0m")
+
+	return nil
+}
+
+// staleContracts checks the contracts against the fingerprint of their
+// mutants after a contract changed and returns the ones that drifted. It
+// reports the contracts that became stale and the ones back in sync with
+// their mutants. A changed gambit config stales every mutant, which stops
+// watch mode.
+func staleContracts(p *Program, previous map[string]bool) (map[string]bool, error) {
+	drift, err := mutantsDrift(p)
+	if err != nil {
+		return previous, err
+	}
+	if drift.config {
+		return previous, staleMutantsError(p, drift, "Revert the changes, or run checkmate to regenerate the mutants and restart watch mode.")
+	}
+
+	stale := make(map[string]bool)
+	for _, file := range drift.changed {
+		stale[file] = true
+		if !previous[file] {
+			fmt.Printf("\033[33m[Warning] Contract '%s' changed since its mutants were generated. Its survivors are skipped until you run checkmate to regenerate them, or revert the changes.\033[0m\n", file)
+		}
+	}
+	for file := range previous {
+		if !stale[file] {
+			fmt.Printf("[Info] Contract '%s' matches its mutants again, its survivors are re-tested.\n", file)
+		}
+	}
+	return stale, nil
+}
+
+// retestSurvivors re-runs the survivors of the contracts imported by the
+// changed test files and updates the statistics of those that got slain. The
+// survivors of stale contracts are skipped.
+func retestSurvivors(p *Program, changedTests []string, remappings []framework.Remapping, stale map[string]bool, interrupted *atomic.Bool) error {
+	contracts := importedFiles(changedTests, remappings)
+
+	var survivors []SolidityFile
+	skipped := 0
+	for _, mutantFile := range listMutants(*p.mutantsDIR) {
+		if !p.dbState.SlayingProgress.MutantsProcessed[mutantFile.PathFromProjectRoot] {
+			continue // Not tested yet, the main slaying run will take care of it.
+		}
+		originalFilePath := getOriginalFilePathFromMutantPath(mutantFile.PathFromProjectRoot, *p.mutantsDIR)
+		if !contracts[filepath.Clean(originalFilePath)] {
+			continue
+		}
+		if stale[filepath.ToSlash(filepath.Clean(originalFilePath))] {
+			skipped++
+			continue
+		}
+		survivors = append(survivors, mutantFile)
+	}
+	if skipped > 0 {
+		fmt.Printf("\033[33m[Warning] Skipping %d survivor(s) of contracts that changed since their mutants were generated.\033[0m\n", skipped)
+	}
+
+	fmt.Printf("\n[Info] Changed: %s\n", strings.Join(changedTests, ", "))
+	if len(survivors) == 0 {
+		fmt.Println("[Info] No survivors belong to the contracts imported by the changed test files.")
+		return nil
+	}
+
+	// The test suite has to pass on the original code. Otherwise every
+	// mutant would look slain.
+	if !testSuitePasses(p, true) {
+		fmt.Println("\033[33m[Warning] The test suite fails on the original code. Fix the tests and save again.\033[0m")
+		return nil
+	}
+
+	fmt.Printf("[Info] Re-testing %d survivor(s).\n", len(survivors))
+	slainCount := 0
+	for _, mutantFile := range survivors {
+		if interrupted.Load() {
+			break
+		}
+
+		originalFilePath := getOriginalFilePathFromMutantPath(mutantFile.PathFromProjectRoot, *p.mutantsDIR)
+		run, err := runTestsAgainstMutant(p, mutantFile, originalFilePath)
+		if err != nil {
+			return err
+		}
+		// Ctrl+C also reaches the test command which then fails. Such a
+		// result says nothing about the mutant.
+		if interrupted.Load() {
+			break
+		}
+		if !run.slain {
+			continue
+		}
+
+		fmt.Printf("\033[32m[Info] Mutant slain 🗡️ (%s)\033[0m\n", mutantFile.PathFromProjectRoot)
+		recordMutantResult(p, mutantFile, originalFilePath, run)
+		slainCount++
+	}
+	recalculateOverallStats(&p.dbState.OverallStats)
+
+	fmt.Printf("[Info] Slain %d out of %d re-tested survivor(s).\n", slainCount, len(survivors))
+	printLiveScore(p)
+
+	if err := p.store.Save(&p.dbState); err != nil {
+		log.Printf("[Warning] Failed to save state in watch mode: %v", err)
+	}
+
+	return nil
+}
+
+func printLiveScore(p *Program) {
+	stats := p.dbState.OverallStats
+	fmt.Printf("\033[36m[Score] %.2f%% (%d slain, %d unslain, %d generated)\033[0m\n",
+		stats.MutationScore, stats.MutantsTotalSlain, stats.MutantsTotalUnslain, stats.MutantsTotalGenerated)
+}
+
+// parseRemappings parses the remappings of the framework, one per line. The
+// invalid ones were reported when the mutants were generated, they are skipped.
+func parseRemappings(lines string) []framework.Remapping {
+	var remappings []framework.Remapping
+	for _, line := range strings.Split(lines, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if remapping, err := framework.ParseRemapping(line); err == nil {
+			remappings = append(remappings, remapping)
+		}
+	}
+	return remappings
+}
+
+// importedFiles follows the imports of the given files transitively and
+// returns the set of project files they depend on. Relative imports ('./',
+// '../') are resolved against the importing file. The others are remapped,
+// e.g. 'forge-std/Test.sol', or resolved against the project root, which
+// covers the default 'src/' style of Foundry imports. Imports that can't be
+// found in the project are ignored.
+func importedFiles(files []string, remappings []framework.Remapping) map[string]bool {
+	visited := make(map[string]bool)
+	queue := append([]string{}, files...)
+
+	for len(queue) > 0 {
+		current := filepath.Clean(queue[0])
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		content, err := os.ReadFile(current)
+		if err != nil {
+			continue
+		}
+
+		for _, importPath := range framework.Imports(string(content)) {
+			if resolved := resolveImport(current, importPath, remappings); resolved != "" && !visited[resolved] {
+				queue = append(queue, resolved)
+			}
+		}
+	}
+
+	return visited
+}
+
+// resolveImport returns the file imported by importingFile, or "" if it's not
+// in the project. Like solc, the remapping with the longest context and then
+// the longest prefix wins.
+func resolveImport(importingFile, importPath string, remappings []framework.Remapping) string {
+	candidates := []string{importPath}
+	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		candidates = []string{filepath.Join(filepath.Dir(importingFile), importPath)}
+	} else {
+		importer := filepath.ToSlash(filepath.Clean(importingFile))
+		var best *framework.Remapping
+		for i, r := range remappings {
+			if !strings.HasPrefix(importPath, r.Prefix) || !strings.HasPrefix(importer, r.Context) {
+				continue
+			}
+			if best == nil || len(r.Context) > len(best.Context) ||
+				(len(r.Context) == len(best.Context) && len(r.Prefix) > len(best.Prefix)) {
+				best = &remappings[i]
+			}
+		}
+		if best != nil {
+			candidates = []string{best.Target + strings.TrimPrefix(importPath, best.Prefix)}
+		}
+	}
+
+	for _, candidate := range candidates {
+		candidate = filepath.Clean(candidate)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// refresh replaces the files of dir with a fresh snapshot. The files of
+// except, e.g. tests next to the contracts, are kept as they are.
+func (s fileSnapshot) refresh(dir, except string) {
+	for path := range s {
+		if isWithinDir(path, dir) && !isWithinDir(path, except) {
+			delete(s, path)
+		}
+	}
+	for path, modTime := range snapshotSolidityFiles(dir) {
+		if !isWithinDir(path, except) {
+			s[path] = modTime
+		}
+	}
+}
+
+func snapshotSolidityFiles(dirs ...string) fileSnapshot {
+	snapshot := make(fileSnapshot)
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Files can disappear while we walk, ignore them.
+			}
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".sol") {
+				snapshot[filepath.Clean(path)] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return snapshot
+}
+
+// changedFiles returns the sorted paths that were added or modified between
+// two snapshots.
+func changedFiles(previous, current fileSnapshot) []string {
+	var changed []string
+	for path, modTime := range current {
+		if prevModTime, ok := previous[path]; !ok || !prevModTime.Equal(modTime) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
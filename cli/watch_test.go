@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/framework"
+)
+
+func TestResolveImport(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	for _, path := range []string{
+		"src/Vault.sol",
+		"src/lib/Math.sol",
+		"lib/forge-std/src/Test.sol",
+		"lib/openzeppelin-contracts/contracts/token/ERC20.sol",
+		"lib/solmate/lib/oz/contracts/token/ERC20.sol",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remappings := parseRemappings(`forge-std/=lib/forge-std/src/
+@openzeppelin/=lib/openzeppelin-contracts/
+@openzeppelin/contracts/=lib/openzeppelin-contracts/contracts/
+lib/solmate:@openzeppelin/contracts/=lib/solmate/lib/oz/contracts/
+invalid`)
+
+	tests := []struct {
+		importingFile, importPath, want string
+	}{
+		{"test/Vault.t.sol", "../src/Vault.sol", "src/Vault.sol"},
+		{"src/Vault.sol", "./lib/Math.sol", "src/lib/Math.sol"},
+		{"test/Vault.t.sol", "src/Vault.sol", "src/Vault.sol"},
+		{"test/Vault.t.sol", "forge-std/Test.sol", "lib/forge-std/src/Test.sol"},
+		// The longest prefix wins.
+		{"src/Vault.sol", "@openzeppelin/contracts/token/ERC20.sol", "lib/openzeppelin-contracts/contracts/token/ERC20.sol"},
+		// The longest context wins.
+		{"lib/solmate/src/Token.sol", "@openzeppelin/contracts/token/ERC20.sol", "lib/solmate/lib/oz/contracts/token/ERC20.sol"},
+		{"test/Vault.t.sol", "../src/Missing.sol", ""},
+		{"test/Vault.t.sol", "ds-test/test.sol", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveImport(tt.importingFile, tt.importPath, remappings); got != filepath.FromSlash(tt.want) {
+			t.Errorf("resolveImport(%q, %q) = %q, want %q", tt.importingFile, tt.importPath, got, tt.want)
+		}
+	}
+}
+
+func TestImportedFiles(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	files := map[string]string{
+		"test/Vault.t.sol":           `import {Test} from "forge-std/Test.sol"; import "../src/Vault.sol";`,
+		"test/Token.t.sol":           `import * as T from "src/Token.sol";`,
+		"src/Vault.sol":              `import {Math} from "./Math.sol"; import "@oz/Ownable.sol";`,
+		"src/Math.sol":               `import "./Vault.sol";`, // Cycles are followed once.
+		"src/Token.sol":              ``,
+		"lib/forge-std/src/Test.sol": ``,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remappings := []framework.Remapping{{Prefix: "forge-std/", Target: "lib/forge-std/src/"}}
+
+	tests := []struct {
+		files []string
+		want  []string
+	}{
+		{[]string{"test/Vault.t.sol"}, []string{"lib/forge-std/src/Test.sol", "src/Math.sol", "src/Vault.sol", "test/Vault.t.sol"}},
+		{[]string{"test/Token.t.sol"}, []string{"src/Token.sol", "test/Token.t.sol"}},
+		{[]string{"src/Math.sol"}, []string{"src/Math.sol", "src/Vault.sol"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for path := range importedFiles(tt.files, remappings) {
+			got = append(got, filepath.ToSlash(path))
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("importedFiles(%v) = %v, want %v", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	later := start.Add(time.Second)
+
+	tests := []struct {
+		name              string
+		previous, current fileSnapshot
+		want              []string
+	}{
+		{"unchanged", fileSnapshot{"a.sol": start}, fileSnapshot{"a.sol": start}, nil},
+		{"modified", fileSnapshot{"a.sol": start, "b.sol": start}, fileSnapshot{"a.sol": later, "b.sol": start}, []string{"a.sol"}},
+		{"added", fileSnapshot{"b.sol": start}, fileSnapshot{"b.sol": start, "a.sol": start}, []string{"a.sol"}},
+		{"removed", fileSnapshot{"a.sol": start}, fileSnapshot{}, nil},
+		{"sorted", fileSnapshot{}, fileSnapshot{"c.sol": start, "a.sol": start, "b.sol": later}, []string{"a.sol", "b.sol", "c.sol"}},
+	}
+	for _, tt := range tests {
+		if got := changedFiles(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshotRefresh(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"src/Vault.sol", "src/test/Vault.t.sol"} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contracts, tests := filepath.Join(dir, "src"), filepath.Join(dir, "src", "test")
+	previous := snapshotSolidityFiles(contracts)
+
+	// A mutant was written to the contract and a test was saved meanwhile.
+	later := time.Now().Add(time.Hour)
+	for _, path := range []string{"src/Vault.sol", "src/test/Vault.t.sol"} {
+		if err := os.Chtimes(filepath.Join(dir, path), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous.refresh(contracts, tests)
+
+	changed := changedFiles(previous, snapshotSolidityFiles(contracts))
+	if want := []string{filepath.Join(dir, "src", "test", "Vault.t.sol")}; !reflect.DeepEqual(changed, want) {
+		t.Fatalf("got changes %v, want %v", changed, want)
+	}
+}
+
+func TestStaleContracts(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	for path, content := range map[string]string{
+		"src/Vault.sol":                      "contract Vault {}",
+		"gambit_out/mutants/1/src/Vault.sol": "contract Vault { }",
+		"gambit_config.json":                 `[{"filename": "./src/Vault.sol"}]`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, mutants := "gambit_config.json", "gambit_out/mutants"
+	p := &Program{gambitConfigPath: &config, mutantsDIR: &mutants}
+	if err := recordFingerprint(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile("src/Vault.sol", []byte("contract Vault { uint x; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := staleContracts(p, map[string]bool{})
+	if err != nil || len(stale) != 1 || !stale["src/Vault.sol"] {
+		t.Fatalf("expected src/Vault.sol to be stale, got %v, %v", stale, err)
+	}
+
+	// Reverting the change brings the survivors back.
+	if err := os.WriteFile("src/Vault.sol", []byte("contract Vault {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err = staleContracts(p, stale); err != nil || len(stale) != 0 {
+		t.Fatalf("expected no stale contracts, got %v, %v", stale, err)
+	}
+
+	// A changed config stales every mutant.
+	if err := os.WriteFile("gambit_config.json", []byte(`[{"filename": "./src/Vault.sol"}, {"filename": "./src/Pool.sol"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p.dbState.Fingerprint.Entries = nil // Recorded by an older version, without the entries.
+	if _, err := staleContracts(p, stale); err == nil || !strings.Contains(err.Error(), "are stale") {
+		t.Fatalf("expected stale mutants, got: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockHolder identifies the process holding the lock of a state.
+type LockHolder struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Command  string    `json:"command"` // Command line of the process e.g. 'checkmate --analyze'.
+	Started  time.Time `json:"started"`
+}
+
+func (h LockHolder) String() string {
+	if h.PID == 0 {
+		return "an unknown process (unreadable lock)"
+	}
+	return fmt.Sprintf("'%s' (PID %d on %s, started %s)", h.Command, h.PID, h.Hostname, h.Started.Format(time.DateTime))
+}
+
+// LockedError is returned when the state is locked by another live process.
+type LockedError struct {
+	Path   string
+	Holder LockHolder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("the state is locked by %s, remove %s if it no longer runs", e.Holder, e.Path)
+}
+
+// Lock is an advisory lock of the state, so that only one checkmate process
+// works on it at a time. It's a file holding the LockHolder, created
+// exclusively. Processes that only read the state don't need it.
+type Lock struct {
+	path    string
+	content []byte // The LockHolder written to the file.
+}
+
+// staleTakeover is the age of a takeover file left behind by a process that
+// crashed while taking over a lock. A takeover takes milliseconds.
+const staleTakeover = time.Minute
+
+// staleUnknownHolder is the age of a lock that can't be read, after which it's
+// stale. A lock is written right after it's created, so one still unreadable
+// by then was left half written by a process that crashed.
+const staleUnknownHolder = time.Minute
+
+// AcquireLock creates the lock file at path for the current process. A lock
+// left behind by a process of this host that no longer runs, or one that
+// can't be read for longer than staleUnknownHolder, is stale and taken over,
+// reported with the returned holder. A live holder, or one on another host
+// sharing the directory, gives a *LockedError.
+func AcquireLock(path, command string) (*Lock, *LockHolder, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the hostname for the lock: %w", err)
+	}
+	holder := LockHolder{PID: os.Getpid(), Hostname: hostname, Command: command, Started: time.Now()}
+	content, err := json.MarshalIndent(holder, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	lock := &Lock{path: path, content: content}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = file.Write(content)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, nil, fmt.Errorf("failed to write the lock %s: %w", path, err)
+			}
+			return lock, nil, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, nil, fmt.Errorf("failed to create the lock %s: %w", path, err)
+		}
+
+		current, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue // Released in the meantime.
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read the lock %s: %w", path, err)
+		}
+		stale := parseLock(current)
+		if !staleHolder(path, stale, hostname) {
+			return nil, nil, &LockedError{Path: path, Holder: *stale}
+		}
+		// The holder crashed or was killed before releasing the lock.
+		took, err := takeOver(path, current, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		if took {
+			return lock, stale, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to acquire the lock %s, another process keeps taking it", path)
+}
+
+// staleHolder reports whether the holder of the lock at path no longer runs.
+// The liveness of a process of another host can't be checked. An unknown
+// holder is either still writing the lock or crashed doing so, which the age
+// of the lock tells apart.
+func staleHolder(path string, holder *LockHolder, hostname string) bool {
+	if holder.PID == 0 {
+		info, err := os.Stat(path)
+		return err == nil && time.Since(info.ModTime()) > staleUnknownHolder
+	}
+	return holder.Hostname == hostname && !processAlive(holder.PID)
+}
+
+// takeOver replaces the stale lock at path, read as stale, with content. The
+// processes taking over a lock take turns with an exclusive takeover file,
+// and the lock is only replaced while it still holds what was read: one that
+// another process took over or released in the meantime is left alone. It
+// reports whether the lock was replaced.
+func takeOver(path string, stale, content []byte) (bool, error) {
+	turn := path + ".takeover"
+	file, err := os.OpenFile(turn, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		// Another process is taking over the lock, unless it crashed doing so.
+		if info, err := os.Stat(turn); err == nil && time.Since(info.ModTime()) > staleTakeover {
+			os.Remove(turn)
+		}
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to take over the lock %s: %w", path, err)
+	}
+	file.Close()
+	defer os.Remove(turn)
+
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read the lock %s: %w", path, err)
+	}
+	if !bytes.Equal(current, stale) {
+		return false, nil
+	}
+
+	// Written aside and renamed, so that the lock is never missing or partial.
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return false, fmt.Errorf("failed to take over the lock %s: %w", path, err)
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return false, fmt.Errorf("failed to take over the lock %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// ReadLock returns the holder of the lock at path, or nil if there is none.
+// A lock file that can't be read, e.g. one written halfway, has an unknown
+// holder with PID 0.
+func ReadLock(path string) (*LockHolder, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the lock %s: %w", path, err)
+	}
+	return parseLock(content), nil
+}
+
+// parseLock returns the holder written in a lock file.
+func parseLock(content []byte) *LockHolder {
+	var holder LockHolder
+	if err := json.Unmarshal(content, &holder); err != nil {
+		return &LockHolder{Command: "unknown"}
+	}
+	return &holder
+}
+
+// Release removes the lock, unless it no longer holds this process, e.g.
+// because it was taken over in the meantime.
+func (l *Lock) Release() error {
+	content, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read the lock %s: %w", l.path, err)
+	}
+	if !bytes.Equal(content, l.content) {
+		return fmt.Errorf("the lock %s was taken over by %s", l.path, parseLock(content))
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release the lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// ErrReadOnly is returned when saving a state opened with ReadOnly.
+var ErrReadOnly = errors.New("the state was opened read-only")
+
+// ReadOnly wraps a store so that it can't be saved, for the processes that
+// only read a state, e.g. while another process holds its lock.
+func ReadOnly(store Store) Store {
+	return readOnlyStore{store}
+}
+
+type readOnlyStore struct {
+	Store
+}
+
+func (s readOnlyStore) Save(*MutationAnalysis) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, path string, holder LockHolder) {
+	t.Helper()
+	content, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.lock")
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, stale, err := AcquireLock(path, "checkmate")
+	if err != nil || stale != nil {
+		t.Fatalf("got stale %v, err %v", stale, err)
+	}
+	holder, err := ReadLock(path)
+	if err != nil || holder == nil || holder.PID != os.Getpid() || holder.Hostname != hostname {
+		t.Fatalf("got holder %v, err %v", holder, err)
+	}
+
+	// A second process is refused while the holder runs.
+	var locked *LockedError
+	if _, _, err := AcquireLock(path, "checkmate --analyze"); !errors.As(err, &locked) || locked.Holder.Command != "checkmate" {
+		t.Fatalf("got err %v, want a LockedError", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if holder, err := ReadLock(path); err != nil || holder != nil {
+		t.Fatalf("the lock is still held by %v, err %v", holder, err)
+	}
+}
+
+func TestAcquireLockTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.lock")
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A process of this host that no longer runs.
+	writeLock(t, path, LockHolder{PID: 1 << 30, Hostname: hostname, Command: "checkmate", Started: time.Now()})
+	lock, stale, err := AcquireLock(path, "checkmate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale == nil || stale.PID != 1<<30 {
+		t.Fatalf("got stale holder %v", stale)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The liveness of a process of another host can't be checked.
+	writeLock(t, path, LockHolder{PID: 1 << 30, Hostname: hostname + "-other", Command: "checkmate"})
+	var locked *LockedError
+	if _, _, err := AcquireLock(path, "checkmate"); !errors.As(err, &locked) {
+		t.Fatalf("got err %v, want a LockedError", err)
+	}
+}
+
+func TestAcquireLockWithUnknownHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.lock")
+
+	// An unreadable lock might still be being written.
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var locked *LockedError
+	if _, _, err := AcquireLock(path, "checkmate"); !errors.As(err, &locked) || !strings.Contains(err.Error(), "remove "+path) {
+		t.Fatalf("got err %v, want a LockedError naming %s", err, path)
+	}
+
+	// Once old, it was left half written by a crashed process.
+	old := time.Now().Add(-2 * staleUnknownHolder)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	lock, stale, err := AcquireLock(path, "checkmate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale == nil || stale.PID != 0 {
+		t.Fatalf("got stale holder %v", stale)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadOnlyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.json")
+	state := initializeMutationAnalysis()
+	state.OverallStats.MutantsTotalGenerated = 3
+	if err := NewJSONStore(path).Save(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	store := ReadOnly(NewJSONStore(path))
+	loaded, err := store.Load()
+	if err != nil || loaded.OverallStats.MutantsTotalGenerated != 3 {
+		t.Fatalf("got %+v, err %v", loaded.OverallStats, err)
+	}
+	loaded.OverallStats.MutantsTotalGenerated = 0
+	if err := store.Save(&loaded); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("got err %v, want ErrReadOnly", err)
+	}
+}
+
+func TestAcquireLockConcurrentTakeOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.lock")
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLock(t, path, LockHolder{PID: 1 << 30, Hostname: hostname, Command: "checkmate", Started: time.Now()})
+
+	// Every goroutine runs in this process: once one of them took the stale
+	// lock over, the others find a live holder.
+	var wg sync.WaitGroup
+	locks := make(chan *Lock, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, _, err := AcquireLock(path, "checkmate")
+			var locked *LockedError
+			if err != nil && !errors.As(err, &locked) {
+				t.Error(err)
+			}
+			if lock != nil {
+				locks <- lock
+			}
+		}()
+	}
+	wg.Wait()
+	close(locks)
+
+	var held []*Lock
+	for lock := range locks {
+		held = append(held, lock)
+	}
+	if len(held) != 1 {
+		t.Fatalf("the stale lock was taken over %d times", len(held))
+	}
+	if _, err := os.Stat(path + ".takeover"); !os.IsNotExist(err) {
+		t.Fatalf("the takeover file was left behind, err %v", err)
+	}
+	if err := held[0].Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReleaseChecksTheHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.lock")
+	lock, _, err := AcquireLock(path, "checkmate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process took the lock over, e.g. after this one was suspended.
+	writeLock(t, path, LockHolder{PID: os.Getpid(), Hostname: "other", Command: "checkmate --analyze"})
+	if err := lock.Release(); err == nil {
+		t.Fatal("expected an error releasing a lock taken over")
+	}
+	if holder, err := ReadLock(path); err != nil || holder == nil || holder.Command != "checkmate --analyze" {
+		t.Fatalf("the lock of the other process was removed: %v, err %v", holder, err)
+	}
+}
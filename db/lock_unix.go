@@ -0,0 +1,19 @@
+//go:build !windows
+
+package db
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the PID runs on this host.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// Signal 0 only checks that the process exists. EPERM means it exists
+	// but belongs to another user.
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package db
+
+import "os"
+
+// processAlive reports whether a process with the PID runs on this host.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// On Windows FindProcess opens the process, which fails if it's gone.
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
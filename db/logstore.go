@@ -0,0 +1,475 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// Kinds of the records of a LogStore. Each record holds one part of the state,
+// so that a tested mutant or an LLM outcome is saved as a single line.
+const (
+	recordSchemaVersion = "schemaVersion"
+	recordOverallStats  = "overallStats"
+	recordFingerprint   = "fingerprint"
+	recordFile          = "file"         // Key: original file. Its LLM outcomes are records of their own.
+	recordLLMOutcome    = "llmOutcome"   // Key: original file, Mutant: mutant ID.
+	recordProcessed     = "processed"    // Key: mutant path e.g. 'gambit_out/mutants/3/src/Vault.sol'.
+	recordKillingTests  = "killingTests" // Key: mutant path.
+	recordLLMProcessed  = "llmProcessed" // Key: mutant ID.
+	recordMutant        = "mutant"       // Key: mutant path.
+)
+
+const (
+	// The log is compacted when it holds compactRatio times more lines than
+	// live records, and at least compactMinLines lines.
+	compactRatio    = 2
+	compactMinLines = 1000
+)
+
+// logRecord is a line of a LogStore: the latest value of a part of the state,
+// or its removal.
+type logRecord struct {
+	Kind    string          `json:"kind"`
+	Key     string          `json:"key,omitempty"`
+	Mutant  string          `json:"mutant,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
+
+// recordKey identifies the part of the state a record holds.
+type recordKey struct {
+	kind, key, mutant string
+}
+
+func (r logRecord) id() recordKey {
+	return recordKey{kind: r.Kind, key: r.Key, mutant: r.Mutant}
+}
+
+// LogStore keeps the state in an append-only log of JSON records, one per
+// line e.g. 'checkmate_analysis_state.jsonl'. A save compares the state with
+// the values of the last load or save and only marshals and appends the
+// records that changed, instead of rewriting every mutant and every raw LLM
+// response. The latest record of each part wins when the log is
+// loaded, and the log is rewritten with only those once it has grown enough.
+type LogStore struct {
+	path   string
+	live   map[recordKey][]byte // Line of the latest record of each part.
+	lines  int                  // Number of lines in the log, including outdated records.
+	valid  int                  // Length of the log up to its last complete record.
+	torn   bool                 // Whether the log ends with an incomplete record.
+	loaded bool
+	// A copy of the value each part was last saved or loaded with. Only the
+	// parts that differ from it are marshaled on save.
+	saved map[recordKey]any
+	// The state as it was before its migration, backed up on the first save.
+	original *migratedState
+}
+
+// NewLogStore returns a LogStore keeping the state in path.
+func NewLogStore(path string) *LogStore {
+	return &LogStore{path: path}
+}
+
+func (s *LogStore) Path() string {
+	return s.path
+}
+
+func (s *LogStore) Load() (MutationAnalysis, error) {
+	if err := s.replay(); err != nil {
+		return MutationAnalysis{}, err
+	}
+	if len(s.live) == 0 {
+		return initializeMutationAnalysis(), nil
+	}
+
+	document, err := s.document()
+	if err != nil {
+		return MutationAnalysis{}, err
+	}
+	state, original, err := parseState(s.path, document)
+	if err != nil {
+		return state, err
+	}
+	s.original = original
+	if original == nil {
+		// The records hold the loaded state. Those of a migrated state are
+		// written again on the next save.
+		s.saved = make(map[recordKey]any, len(s.live))
+		forEachPart(&state, func(key recordKey, value any) error {
+			if _, ok := s.live[key]; ok {
+				s.saved[key] = clonePart(value)
+			}
+			return nil
+		})
+	}
+	return state, nil
+}
+
+func (s *LogStore) Save(state *MutationAnalysis) error {
+	if state == nil {
+		return fmt.Errorf("cannot save nil MutationAnalysis data")
+	}
+	if !s.loaded {
+		// The changes are relative to what the log holds.
+		if err := s.replay(); err != nil {
+			return err
+		}
+	}
+	if err := s.original.backup(); err != nil {
+		return err
+	}
+	s.original = nil
+
+	// The state is always written in the current structure.
+	state.SchemaVersion = SchemaVersion
+
+	changed := make(map[recordKey][]byte)
+	saved := make(map[recordKey]any)
+	present, added := 0, 0 // Parts of the state with a record, and without.
+	err := forEachPart(state, func(key recordKey, value any) error {
+		if _, ok := s.live[key]; ok {
+			present++
+			if previous, ok := s.saved[key]; ok && reflect.DeepEqual(previous, value) {
+				return nil
+			}
+		} else {
+			added++
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the %s record of '%s': %w", key.kind, key.key, err)
+		}
+		line, err := json.Marshal(logRecord{Kind: key.kind, Key: key.key, Mutant: key.mutant, Value: raw})
+		if err != nil {
+			return err
+		}
+		saved[key] = clonePart(value)
+		if !bytes.Equal(s.live[key], line) {
+			changed[key] = line
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var changes bytes.Buffer
+	for _, key := range sortedKeys(changed) {
+		changes.Write(changed[key])
+		changes.WriteByte('\n')
+	}
+	var deleted []recordKey
+	if present < len(s.live) {
+		for _, key := range sortedKeys(s.live) {
+			if hasPart(state, key) {
+				continue
+			}
+			line, err := json.Marshal(logRecord{Kind: key.kind, Key: key.key, Mutant: key.mutant, Deleted: true})
+			if err != nil {
+				return err
+			}
+			changes.Write(line)
+			changes.WriteByte('\n')
+			deleted = append(deleted, key)
+		}
+	}
+	appended := len(changed) + len(deleted)
+	if appended == 0 {
+		maps.Copy(s.saved, saved)
+		return nil
+	}
+	if s.torn {
+		// A crash while appending leaves an incomplete last line. The records
+		// before it are intact and the changes hold the lost ones. It's only
+		// dropped when saving, a reader may see a record being appended.
+		if err := os.Truncate(s.path, int64(s.valid)); err != nil {
+			return fmt.Errorf("failed to drop the incomplete record of %s: %w", s.path, err)
+		}
+		s.torn = false
+	}
+
+	if lines := s.lines + appended; lines >= compactMinLines && lines > compactRatio*(len(s.live)+added-len(deleted)) {
+		live := maps.Clone(s.live)
+		maps.Copy(live, changed)
+		for _, key := range deleted {
+			delete(live, key)
+		}
+		if err := s.compact(live); err != nil {
+			return err
+		}
+		s.live = live
+	} else {
+		if err := s.append(changes.Bytes()); err != nil {
+			return err
+		}
+		s.lines += appended
+		s.valid += changes.Len()
+		maps.Copy(s.live, changed)
+		for _, key := range deleted {
+			delete(s.live, key)
+		}
+	}
+	if s.saved == nil {
+		s.saved = make(map[recordKey]any)
+	}
+	maps.Copy(s.saved, saved)
+	for _, key := range deleted {
+		delete(s.saved, key)
+	}
+	return nil
+}
+
+// append writes the lines at the end of the log in a single write.
+func (s *LogStore) append(lines []byte) error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	_, err = file.Write(lines)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append to %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// compact rewrites the log with only the given records.
+func (s *LogStore) compact(records map[recordKey][]byte) error {
+	var content bytes.Buffer
+	for _, id := range sortedKeys(records) {
+		content.Write(records[id])
+		content.WriteByte('\n')
+	}
+	if err := writeFileAtomic(s.path, content.Bytes()); err != nil {
+		return fmt.Errorf("failed to compact %s: %w", s.path, err)
+	}
+	s.lines = len(records)
+	s.valid = content.Len()
+	return nil
+}
+
+// replay reads the log and keeps the latest record of each part.
+func (s *LogStore) replay() error {
+	s.live = make(map[recordKey][]byte)
+	s.saved = nil
+	s.lines, s.valid, s.torn, s.loaded = 0, 0, false, true
+
+	content, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read state file %s: %w", s.path, err)
+	}
+
+	for offset := 0; offset < len(content); {
+		end := bytes.IndexByte(content[offset:], '\n')
+		if end < 0 {
+			// Every record is written with its newline, so this one was cut short.
+			s.torn = true
+			return nil
+		}
+		line := content[offset : offset+end]
+		offset += end + 1
+
+		var record logRecord
+		if err := json.Unmarshal(line, &record); err != nil || record.Kind == "" {
+			return fmt.Errorf("invalid record on line %d of %s: %s", s.lines+1, s.path, line)
+		}
+		if record.Deleted {
+			delete(s.live, record.id())
+		} else {
+			s.live[record.id()] = append([]byte{}, line...)
+		}
+		s.lines++
+		s.valid = offset
+	}
+	return nil
+}
+
+// document assembles the live records into the JSON of the whole state, as
+// the JSONStore would have written it, so that it goes through the same
+// migrations.
+func (s *LogStore) document() ([]byte, error) {
+	document := make(map[string]any)
+	files := make(map[string]map[string]any)
+	outcomes := make(map[string]map[string]json.RawMessage)
+	processed := make(map[string]json.RawMessage)
+	killingTests := make(map[string]json.RawMessage)
+	llmProcessed := make(map[string]json.RawMessage)
+	mutants := make(map[string]json.RawMessage)
+
+	for _, line := range s.live {
+		var record logRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("invalid record in %s: %w", s.path, err)
+		}
+		switch record.Kind {
+		case recordSchemaVersion, recordOverallStats, recordFingerprint:
+			document[record.Kind] = record.Value
+		case recordFile:
+			file, err := decodeState(record.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid record of %s in %s: %w", record.Key, s.path, err)
+			}
+			files[record.Key] = file
+		case recordLLMOutcome:
+			if outcomes[record.Key] == nil {
+				outcomes[record.Key] = make(map[string]json.RawMessage)
+			}
+			outcomes[record.Key][record.Mutant] = record.Value
+		case recordProcessed:
+			processed[record.Key] = record.Value
+		case recordKillingTests:
+			killingTests[record.Key] = record.Value
+		case recordLLMProcessed:
+			llmProcessed[record.Key] = record.Value
+		case recordMutant:
+			mutants[record.Key] = record.Value
+		default:
+			return nil, fmt.Errorf("unknown record kind '%s' in %s", record.Kind, s.path)
+		}
+	}
+
+	for path, file := range files {
+		if fileOutcomes, ok := outcomes[path]; ok {
+			file["llmAnalysisOutcomes"] = fileOutcomes
+		}
+	}
+	document["analyzedFiles"] = files
+	slaying := map[string]any{"mutantsProcessed": processed}
+	if len(killingTests) > 0 {
+		slaying["killingTests"] = killingTests
+	}
+	document["slayingProgress"] = slaying
+	document["languageModelProgress"] = map[string]any{"mutantsProcessed": llmProcessed}
+	if len(mutants) > 0 {
+		document["mutants"] = mutants
+	}
+	return json.Marshal(document)
+}
+
+// forEachPart calls fn with every part of the state that has a record of its
+// own, and the value the record holds.
+func forEachPart(state *MutationAnalysis, fn func(key recordKey, value any) error) error {
+	if err := fn(recordKey{kind: recordSchemaVersion}, state.SchemaVersion); err != nil {
+		return err
+	}
+	if err := fn(recordKey{kind: recordOverallStats}, state.OverallStats); err != nil {
+		return err
+	}
+	if state.Fingerprint != nil {
+		if err := fn(recordKey{kind: recordFingerprint}, state.Fingerprint); err != nil {
+			return err
+		}
+	}
+	for path, file := range state.AnalyzedFiles {
+		for id, outcome := range file.LLMAnalysisOutcomes {
+			if err := fn(recordKey{kind: recordLLMOutcome, key: path, mutant: id}, outcome); err != nil {
+				return err
+			}
+		}
+		file.LLMAnalysisOutcomes = nil
+		if err := fn(recordKey{kind: recordFile, key: path}, file); err != nil {
+			return err
+		}
+	}
+	for path, done := range state.SlayingProgress.MutantsProcessed {
+		if err := fn(recordKey{kind: recordProcessed, key: path}, done); err != nil {
+			return err
+		}
+	}
+	for path, tests := range state.SlayingProgress.KillingTests {
+		if err := fn(recordKey{kind: recordKillingTests, key: path}, tests); err != nil {
+			return err
+		}
+	}
+	for id, done := range state.LanguageModelProgress.MutantsProcessed {
+		if err := fn(recordKey{kind: recordLLMProcessed, key: id}, done); err != nil {
+			return err
+		}
+	}
+	for path, mutant := range state.Mutants {
+		if err := fn(recordKey{kind: recordMutant, key: path}, mutant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPart reports whether the state still has the part of a record.
+func hasPart(state *MutationAnalysis, key recordKey) bool {
+	var ok bool
+	switch key.kind {
+	case recordSchemaVersion, recordOverallStats:
+		ok = true
+	case recordFingerprint:
+		ok = state.Fingerprint != nil
+	case recordFile:
+		_, ok = state.AnalyzedFiles[key.key]
+	case recordLLMOutcome:
+		_, ok = state.AnalyzedFiles[key.key].LLMAnalysisOutcomes[key.mutant]
+	case recordProcessed:
+		_, ok = state.SlayingProgress.MutantsProcessed[key.key]
+	case recordKillingTests:
+		_, ok = state.SlayingProgress.KillingTests[key.key]
+	case recordLLMProcessed:
+		_, ok = state.LanguageModelProgress.MutantsProcessed[key.key]
+	case recordMutant:
+		_, ok = state.Mutants[key.key]
+	}
+	return ok
+}
+
+// clonePart copies the value of a part, so that changes to the state don't
+// change the copy kept as saved.
+func clonePart(value any) any {
+	switch v := value.(type) {
+	case *GenerationFingerprint:
+		fingerprint := *v
+		fingerprint.Entries = maps.Clone(v.Entries)
+		fingerprint.Sources = maps.Clone(v.Sources)
+		return &fingerprint
+	case AnalyzedFile:
+		v.FileSpecificRecommendations = slices.Clone(v.FileSpecificRecommendations)
+		v.EquivalentMutants = maps.Clone(v.EquivalentMutants)
+		return v
+	case []string:
+		return slices.Clone(v)
+	case MutantRecord:
+		v.KillingTests = slices.Clone(v.KillingTests)
+		if v.ExitCode != nil {
+			exitCode := *v.ExitCode
+			v.ExitCode = &exitCode
+		}
+		return v
+	default:
+		// Numbers, booleans, the statistics and the LLM outcomes hold no references.
+		return v
+	}
+}
+
+func sortedKeys(m map[recordKey][]byte) []recordKey {
+	keys := make([]recordKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		return a.mutant < b.mutant
+	})
+	return keys
+}
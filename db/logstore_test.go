@@ -0,0 +1,186 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(content, []byte("\n"))
+}
+
+func TestLogStoreAppendsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.jsonl")
+	store := NewLogStore(path)
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.OverallStats.MutantsTotalGenerated = 2
+	state.AnalyzedFiles["src/Vault.sol"] = AnalyzedFile{
+		FileSpecificStats: FileSpecificStats{MutantsTotalGenerated: 2},
+		LLMAnalysisOutcomes: map[string]MutantLLMAnalysisOutcome{
+			"2": {MutantID: "2", Status: "COMPLETED", LLMResponse: "Add a test of the owner check.", Timestamp: "t"},
+		},
+	}
+	state.SlayingProgress.MutantsProcessed["gambit_out/mutants/1/src/Vault.sol"] = true
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	// schemaVersion, overallStats, the file, its LLM outcome and the processed mutant.
+	if lines := countLines(t, path); lines != 5 {
+		t.Fatalf("got %d lines after the first save, want 5", lines)
+	}
+
+	// Testing a mutant appends the changed records only.
+	state.OverallStats.MutantsTotalSlain = 1
+	state.SlayingProgress.MutantsProcessed["gambit_out/mutants/2/src/Vault.sol"] = true
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	if lines := countLines(t, path); lines != 7 {
+		t.Fatalf("got %d lines after testing a mutant, want 7", lines)
+	}
+
+	// Removed parts are appended as deletions.
+	delete(state.SlayingProgress.MutantsProcessed, "gambit_out/mutants/1/src/Vault.sol")
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewLogStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Fatalf("got state:\n%+v\nwant:\n%+v", loaded, state)
+	}
+
+	// The assembled state matches the schema.
+	document, err := StateDocument(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, problems, err := ValidateState(document); err != nil || len(problems) > 0 {
+		t.Fatalf("problems %v, err %v", problems, err)
+	}
+}
+
+func TestLogStoreDropsTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.jsonl")
+	store := NewLogStore(path)
+	state := initializeMutationAnalysis()
+	state.SlayingProgress.MutantsProcessed["gambit_out/mutants/1/src/Vault.sol"] = true
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash while appending leaves half a record.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"kind":"processed","key":"gambit_out/mut`)
+	file.Close()
+
+	store = NewLogStore(path)
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.SlayingProgress.MutantsProcessed["gambit_out/mutants/1/src/Vault.sol"] {
+		t.Fatalf("lost the complete records: %+v", loaded)
+	}
+
+	loaded.SlayingProgress.MutantsProcessed["gambit_out/mutants/2/src/Vault.sol"] = true
+	if err := store.Save(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLogStore(path).Load(); err != nil {
+		t.Fatalf("the log is corrupt after appending to a torn record: %v", err)
+	}
+}
+
+func TestLogStoreCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.jsonl")
+	store := NewLogStore(path)
+	state := initializeMutationAnalysis()
+	for i := 0; i < compactMinLines; i++ {
+		state.OverallStats.MutantsTotalSlain = int32(i)
+		state.SlayingProgress.MutantsProcessed[fmt.Sprintf("gambit_out/mutants/%d/src/Vault.sol", i%10)] = true
+		if err := store.Save(&state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// schemaVersion, overallStats and 10 processed mutants, plus the saves since the compaction.
+	if lines := countLines(t, path); lines >= compactMinLines {
+		t.Fatalf("the log wasn't compacted, %d lines", lines)
+	}
+	loaded, err := NewLogStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Fatalf("got state:\n%+v\nwant:\n%+v", loaded, state)
+	}
+}
+
+func TestLogStoreSavesInPlaceChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.jsonl")
+	store := NewLogStore(path)
+	state := initializeMutationAnalysis()
+	mutant := "gambit_out/mutants/1/src/Vault.sol"
+	state.SlayingProgress.KillingTests = map[string][]string{mutant: {"test_owner"}}
+	state.AnalyzedFiles["src/Vault.sol"] = AnalyzedFile{EquivalentMutants: map[string]MutantEquivalence{"3": {MutantID: "3", Status: MutantEquivalent}}}
+	exitCode := 0
+	state.Mutants = map[string]MutantRecord{mutant: {ID: "1", Status: MutantUnslain, ExitCode: &exitCode}}
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	// A loaded state is saved from the values it was loaded with.
+	store = NewLogStore(path)
+	state, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := countLines(t, path)
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	if got := countLines(t, path); got != lines {
+		t.Fatalf("saving the loaded state appended %d lines", got-lines)
+	}
+
+	// Values changed in place, not replaced, are saved too.
+	state.SlayingProgress.KillingTests[mutant][0] = "test_withdraw"
+	state.AnalyzedFiles["src/Vault.sol"].EquivalentMutants["2"] = MutantEquivalence{MutantID: "2", Status: MutantEquivalent}
+	*state.Mutants[mutant].ExitCode = 1
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	if got := countLines(t, path); got != lines+3 {
+		t.Fatalf("got %d lines after changing 3 parts, want %d", got, lines+3)
+	}
+	loaded, err := NewLogStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Fatalf("got state:\n%+v\nwant:\n%+v", loaded, state)
+	}
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SchemaVersion is the version of the state structure written by this version
+// of checkmate. Bump it, register a migration and update state.schema.json
+// whenever a change to the structs in this package would make older states
+// fail to load or load with a different meaning.
+const SchemaVersion = 2
+
+// migration upgrades a state from version from to from+1. It works on the
+// decoded JSON so that it can still read fields that were renamed, moved or
+// restructured since.
+type migration struct {
+	from        int
+	description string
+	migrate     func(state map[string]any) error
+}
+
+// migrations holds one migration per schema version, in order. States are
+// upgraded one step at a time until they reach SchemaVersion.
+var migrations = []migration{
+	{
+		from:        0,
+		description: "add the schema version",
+		// Version 0 is every state written before the schema version existed.
+		// Its structure is the same, the fields added since are all optional.
+		migrate: func(state map[string]any) error { return nil },
+	},
+	{
+		from:        1,
+		description: "add the per-mutant records",
+		// The records are built from gambit_results.json and the slaying
+		// progress on the next run, older versions of checkmate would drop them.
+		migrate: func(state map[string]any) error { return nil },
+	},
+}
+
+// decodeState decodes a state generically, keeping the numbers as they are
+// written.
+func decodeState(content []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var state map[string]any
+	if err := decoder.Decode(&state); err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, errors.New("the state is not a JSON object")
+	}
+	return state, nil
+}
+
+// stateVersion returns the schema version of a decoded state, 0 if it has
+// none.
+func stateVersion(state map[string]any) (int, error) {
+	value, ok := state["schemaVersion"]
+	if !ok {
+		return 0, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schemaVersion must be a number, got %v", value)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("schemaVersion must be a non-negative integer, got %s", number)
+	}
+	return int(version), nil
+}
+
+// migrateState upgrades a decoded state to SchemaVersion and returns the
+// descriptions of the applied migrations. States written by a newer version
+// of checkmate are refused, since their fields may mean something else.
+func migrateState(state map[string]any) ([]string, error) {
+	version, err := stateVersion(state)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("the state has schema version %d but this version of checkmate only reads up to version %d, upgrade checkmate", version, SchemaVersion)
+	}
+
+	var applied []string
+	for ; version < SchemaVersion; version++ {
+		step := migrations[version]
+		if step.from != version {
+			panic(fmt.Sprintf("migration %d is registered for version %d", version, step.from))
+		}
+		if err := step.migrate(state); err != nil {
+			return applied, fmt.Errorf("failed to migrate the state from version %d to %d (%s): %w", version, version+1, step.description, err)
+		}
+		state["schemaVersion"] = json.Number(fmt.Sprint(version + 1))
+		applied = append(applied, fmt.Sprintf("v%d → v%d: %s", version, version+1, step.description))
+	}
+	return applied, nil
+}
+
+// backupState keeps a copy of a state file before it is migrated, e.g.
+// 'checkmate_analysis_state.json.v0.bak'. An existing backup is the oldest
+// copy, so it isn't overwritten.
+func backupState(filename string, version int, content []byte) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", filename, version)
+	file, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return backup, nil
+	} else if err != nil {
+		return "", err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(backup)
+		return "", err
+	}
+	return backup, nil
+}
+
+// migratedState is a state file as it was before its migration. It's backed
+// up before the migrated state first overwrites it, so that loading an older
+// state only to read it, e.g. for 'checkmate status', leaves no backup behind.
+type migratedState struct {
+	filename string
+	version  int
+	content  []byte
+}
+
+// backup keeps the original of a migrated state, if there is one.
+func (m *migratedState) backup() error {
+	if m == nil {
+		return nil
+	}
+	backup, err := backupState(m.filename, m.version, m.content)
+	if err != nil {
+		return fmt.Errorf("failed to back up state file %s before overwriting it with the migrated state: %w", m.filename, err)
+	}
+	fmt.Printf("[Info] Saving %s with schema version %d, the original is kept in %s.\n", m.filename, SchemaVersion, backup)
+	return nil
+}
+
+// migrateStateFile upgrades the content of a state file to SchemaVersion, in
+// memory. The returned original is nil if the state was up to date, the
+// migrated state is only written, after a backup, on the next save.
+func migrateStateFile(filename string, content []byte) ([]byte, *migratedState, error) {
+	state, err := decodeState(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal JSON data from %s: %w", filename, err)
+	}
+	version, err := stateVersion(state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid state file %s: %w", filename, err)
+	}
+	if version == SchemaVersion {
+		return content, nil, nil
+	}
+
+	applied, err := migrateState(state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state file %s: %w", filename, err)
+	}
+	fmt.Printf("[Info] Migrating %s from schema version %d to %d.\n", filename, version, SchemaVersion)
+	for _, step := range applied {
+		fmt.Printf("[Info]   %s\n", step)
+	}
+	migrated, err := json.Marshal(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrated, &migratedState{filename: filename, version: version, content: content}, nil
+}
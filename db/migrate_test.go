@@ -0,0 +1,156 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyState is a state written before the schema version existed.
+const legacyState = `{
+  "overallStats": {"mutantsTotalGenerated": 2, "mutantsTotalSlain": 1, "mutantsTotalUnslain": 1, "mutationScore": 50},
+  "analyzedFiles": {
+    "src/Vault.sol": {
+      "fileSpecificStats": {"mutantsTotalGenerated": 2, "mutantsTotalSlain": 1, "mutantsTotalUnslain": 1, "mutationScore": 50},
+      "fileSpecificRecommendations": [],
+      "llmAnalysisOutcomes": null
+    }
+  },
+  "slayingProgress": {"mutantsProcessed": {"gambit_out/mutants/1/src/Vault.sol": true}},
+  "languageModelProgress": {"mutantsProcessed": {}}
+}`
+
+func TestLoadMigratesLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.json")
+	if err := os.WriteFile(path, []byte(legacyState), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewJSONStore(path)
+	state, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SchemaVersion != SchemaVersion || state.OverallStats.MutantsTotalSlain != 1 ||
+		!state.SlayingProgress.MutantsProcessed["gambit_out/mutants/1/src/Vault.sol"] {
+		t.Fatalf("unexpected migrated state %+v", state)
+	}
+
+	// Reading the state leaves the file as it is.
+	if _, err := ReadOnly(NewJSONStore(path)).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Fatalf("the state was backed up without being saved, err %v", err)
+	}
+
+	if err := store.Save(&state); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("expected a backup of the original: %v", err)
+	}
+	if string(backup) != legacyState {
+		t.Fatalf("the backup differs from the original:\n%s", backup)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, problems, err := ValidateState(content); err != nil || version != SchemaVersion || len(problems) > 0 {
+		t.Fatalf("saved state is invalid: version %d, problems %v, err %v", version, problems, err)
+	}
+}
+
+func TestLoadRefusesNewerState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.json")
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStateFromFile(path); err == nil || !strings.Contains(err.Error(), "upgrade checkmate") {
+		t.Fatalf("expected a newer state to be refused, got: %v", err)
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("%d migrations registered for schema version %d", len(migrations), SchemaVersion)
+	}
+	for i, step := range migrations {
+		if step.from != i {
+			t.Fatalf("migration %d upgrades version %d", i, step.from)
+		}
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	// A legacy state is validated as migrated.
+	version, problems, err := ValidateState([]byte(legacyState))
+	if err != nil || version != 0 || len(problems) > 0 {
+		t.Fatalf("version %d, problems %v, err %v", version, problems, err)
+	}
+
+	broken := strings.NewReplacer(
+		`"mutantsTotalSlain": 1,`, `"mutantsTotalSlain": "1",`,
+		`"fileSpecificRecommendations": []`, `"fileSpecificRecommendations": [], "notes": "x"`,
+		`"languageModelProgress": {"mutantsProcessed": {}}`, `"languageModelProgress": {}`,
+	).Replace(legacyState)
+	_, problems, err = ValidateState([]byte(broken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`/analyzedFiles/src~1Vault.sol/fileSpecificStats/mutantsTotalSlain: expected integer, got string`,
+		`/analyzedFiles/src~1Vault.sol/notes: unknown property`,
+		`/languageModelProgress: missing required property "mutantsProcessed"`,
+		`/overallStats/mutantsTotalSlain: expected integer, got string`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got problems:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, _, err := ValidateState([]byte("[]")); err == nil {
+		t.Fatal("expected an error for a state that isn't an object")
+	}
+}
+
+// TestSchemaCoversEveryField fails when a field is added to the state without
+// updating state.schema.json.
+func TestSchemaCoversEveryField(t *testing.T) {
+	state := initializeMutationAnalysis()
+	stats := FileSpecificStats{MutantsTotalGenerated: 3, MutantsTotalSlain: 1, MutantsTotalUnslain: 1, MutantsTotalEquivalent: 1, MutationScore: 50}
+	state.OverallStats = OverallStats(stats)
+	state.AnalyzedFiles["src/Vault.sol"] = AnalyzedFile{
+		FileSpecificStats:           stats,
+		FileSpecificRecommendations: []string{"Test the owner check."},
+		LLMAnalysisOutcomes: map[string]MutantLLMAnalysisOutcome{
+			"2": {MutantID: "2", Status: "COMPLETED", LLMResponse: "r", ErrorMessage: "e", Timestamp: "t", ModelUsed: "m"},
+		},
+		EquivalentMutants: map[string]MutantEquivalence{"3": {MutantID: "3", Status: "DUPLICATE", DuplicateOf: "1"}},
+	}
+	state.SlayingProgress.MutantsProcessed["gambit_out/mutants/1/src/Vault.sol"] = true
+	state.SlayingProgress.KillingTests = map[string][]string{"gambit_out/mutants/1/src/Vault.sol": {"test_owner"}}
+	state.LanguageModelProgress.MutantsProcessed["2"] = true
+	state.Fingerprint = &GenerationFingerprint{Config: "c", Entries: map[string]string{"src/Vault.sol": "e"}, Sources: map[string]string{"src/Vault.sol": "s"}}
+	exitCode := 1
+	state.Mutants = map[string]MutantRecord{"gambit_out/mutants/1/src/Vault.sol": {
+		ID: "1", Operator: "require-mutation", OriginalFile: "src/Vault.sol", Line: 12, Column: 17,
+		Contract: "Vault", Function: "withdraw", Diff: "d", Status: MutantSlain, TestCommand: "forge test",
+		ExitCode: &exitCode, DurationMs: 1200, KillingTests: []string{"test_owner"},
+		CreatedAt: "c", StartedAt: "s", FinishedAt: "f",
+	}}
+
+	path := filepath.Join(t.TempDir(), "checkmate_analysis_state.json")
+	if err := SaveStateToFile(path, &state); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, problems, err := ValidateState(content); err != nil || len(problems) > 0 {
+		t.Fatalf("problems %v, err %v", problems, err)
+	}
+}
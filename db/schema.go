@@ -0,0 +1,243 @@
+package db
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stateSchema is the JSON Schema of the state file at SchemaVersion.
+//
+//go:embed state.schema.json
+var stateSchema []byte
+
+// StateSchema returns the JSON Schema of the state file.
+func StateSchema() []byte {
+	return stateSchema
+}
+
+// ValidateState checks the content of a state file against its JSON Schema.
+// Older states are migrated in memory first, the way checkmate loads them. It
+// returns the version the state was written with and the problems found, each
+// prefixed with the JSON pointer of the offending value. An error means the
+// state couldn't be read at all.
+func ValidateState(content []byte) (int, []string, error) {
+	state, err := decodeState(content)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	version, err := stateVersion(state)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := migrateState(state); err != nil {
+		return version, nil, err
+	}
+
+	schema, err := decodeState(stateSchema)
+	if err != nil {
+		return version, nil, fmt.Errorf("invalid embedded schema: %w", err)
+	}
+	v := validator{defs: asObject(schema["$defs"])}
+	v.validate("", state, schema)
+	return version, v.problems, nil
+}
+
+// validator checks values against the subset of JSON Schema used by
+// state.schema.json: type, const, enum, required, properties,
+// additionalProperties, items, minimum, maximum and local $refs.
+type validator struct {
+	defs     map[string]any
+	problems []string
+}
+
+func (v *validator) fail(pointer, format string, args ...any) {
+	if pointer == "" {
+		pointer = "/"
+	}
+	v.problems = append(v.problems, pointer+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validate(pointer string, value any, schema map[string]any) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := asObject(v.defs[strings.TrimPrefix(ref, "#/$defs/")])
+		if def == nil {
+			v.fail(pointer, "unknown schema reference %s", ref)
+			return
+		}
+		v.validate(pointer, value, def)
+		return
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(value, types) {
+		v.fail(pointer, "expected %s, got %s", describeTypes(types), typeOf(value))
+		return
+	}
+	if expected, ok := schema["const"]; ok && !equalJSON(value, expected) {
+		v.fail(pointer, "expected %v, got %v", expected, value)
+	}
+	if options, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, option := range options {
+			found = found || equalJSON(value, option)
+		}
+		if !found {
+			v.fail(pointer, "%v is not one of %v", value, options)
+		}
+	}
+	if number, ok := value.(json.Number); ok {
+		n, _ := number.Float64()
+		if minimum, ok := schema["minimum"].(json.Number); ok {
+			if m, _ := minimum.Float64(); n < m {
+				v.fail(pointer, "%s is less than %s", number, minimum)
+			}
+		}
+		if maximum, ok := schema["maximum"].(json.Number); ok {
+			if m, _ := maximum.Float64(); n > m {
+				v.fail(pointer, "%s is greater than %s", number, maximum)
+			}
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range asSlice(schema["required"]) {
+			if _, ok := value[key.(string)]; !ok {
+				v.fail(pointer, "missing required property %q", key)
+			}
+		}
+		properties := asObject(schema["properties"])
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + escapePointer(key)
+			if property := asObject(properties[key]); property != nil {
+				v.validate(child, value[key], property)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					v.fail(child, "unknown property")
+				}
+			case map[string]any:
+				v.validate(child, value[key], additional)
+			}
+		}
+	case []any:
+		if items := asObject(schema["items"]); items != nil {
+			for i, item := range value {
+				v.validate(fmt.Sprintf("%s/%d", pointer, i), item, items)
+			}
+		}
+	}
+}
+
+func matchesType(value any, types any) bool {
+	for _, t := range asSlice(types) {
+		switch t {
+		case "object":
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "number":
+			if _, ok := value.(json.Number); ok {
+				return true
+			}
+		case "integer":
+			if number, ok := value.(json.Number); ok {
+				if _, err := number.Int64(); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describeTypes(types any) string {
+	var names []string
+	for _, t := range asSlice(types) {
+		names = append(names, fmt.Sprint(t))
+	}
+	return strings.Join(names, " or ")
+}
+
+// equalJSON compares decoded JSON values, numbers by their value.
+func equalJSON(a, b any) bool {
+	if x, ok := a.(json.Number); ok {
+		if y, ok := b.(json.Number); ok {
+			m, _ := x.Float64()
+			n, _ := y.Float64()
+			return m == n
+		}
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// asSlice returns a schema value that is either a list or a single item as a
+// list, e.g. "type": "object" and "type": ["object", "null"].
+func asSlice(value any) []any {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return value
+	default:
+		return []any{value}
+	}
+}
+
+func asObject(value any) map[string]any {
+	object, _ := value.(map[string]any)
+	return object
+}
+
+// escapePointer escapes a key for a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
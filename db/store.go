@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Store persists the MutationAnalysis state between runs. Load returns a new,
+// initialized state if nothing was saved yet. Save may be called often, e.g.
+// every few tested mutants, so implementations are free to write only what
+// changed since the last Load or Save.
+type Store interface {
+	Load() (MutationAnalysis, error)
+	Save(state *MutationAnalysis) error
+	// Path is the file the state is kept in, for messages and cleanup.
+	Path() string
+}
+
+// Names of the Store implementations, as selected with --state-store.
+const (
+	JSONStoreName = "json"
+	LogStoreName  = "log"
+)
+
+// NewStore returns the Store implementation with the given name, keeping the
+// state in path.
+func NewStore(name, path string) (Store, error) {
+	switch name {
+	case JSONStoreName, "":
+		return NewJSONStore(path), nil
+	case LogStoreName:
+		return NewLogStore(path), nil
+	default:
+		return nil, fmt.Errorf("unknown state store '%s', use '%s' or '%s'", name, JSONStoreName, LogStoreName)
+	}
+}
+
+// JSONStore keeps the whole state in a single JSON file, rewritten on every
+// save. It's easy to read and to edit, but slow to save for large analyses.
+type JSONStore struct {
+	path     string
+	original *migratedState // Backed up on the first save, nil if the state was up to date.
+}
+
+// NewJSONStore returns a JSONStore keeping the state in path e.g.
+// 'checkmate_analysis_state.json'.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{path: path}
+}
+
+func (s *JSONStore) Load() (MutationAnalysis, error) {
+	state, original, err := loadStateFile(s.path)
+	s.original = original
+	return state, err
+}
+
+func (s *JSONStore) Save(state *MutationAnalysis) error {
+	if err := s.original.backup(); err != nil {
+		return err
+	}
+	s.original = nil
+	return SaveStateToFile(s.path, state)
+}
+
+func (s *JSONStore) Path() string {
+	return s.path
+}
+
+// StateDocument returns the state kept in path as a single JSON document, the
+// way it's validated against the JSON Schema. Logs of a LogStore are
+// recognized by their '.jsonl' extension.
+func StateDocument(path string) ([]byte, error) {
+	if strings.HasSuffix(path, ".jsonl") {
+		log := NewLogStore(path)
+		if err := log.replay(); err != nil {
+			return nil, err
+		}
+		return log.document()
+	}
+	return os.ReadFile(path)
+}
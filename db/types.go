@@ -11,6 +11,10 @@ import (
 // of the mutation analysis process, including overall statistics, per-file analysis,
 // and progress tracking for mutant slaying and language model review.
 type MutationAnalysis struct {
+	// SchemaVersion is the version of this structure the state was written with.
+	// States without it are version 0 and get migrated on load (see migrate.go).
+	SchemaVersion int `json:"schemaVersion"`
+
 	// OverallStats summarizes the mutation testing results across the entire codebase.
 	OverallStats OverallStats `json:"overallStats"`
 
@@ -23,6 +27,53 @@ type MutationAnalysis struct {
 
 	// LanguageModelProgress tracks which surviving mutants have been reviewed by an LLM.
 	LanguageModelProgress LanguageModelProgress `json:"languageModelProgress"`
+
+	// Fingerprint identifies the sources and the gambit config the mutants were generated from.
+	// Nil until mutants are generated or found.
+	Fingerprint *GenerationFingerprint `json:"fingerprint,omitempty"`
+
+	// Mutants holds a record of every mutant listed in gambit_results.json, keyed like
+	// SlayingProgress.MutantsProcessed (e.g., "gambit_out/mutants/3/src/Vault.sol").
+	// The statistics are computed from these records.
+	Mutants map[string]MutantRecord `json:"mutants,omitempty"`
+}
+
+// Statuses of a MutantRecord.
+const (
+	MutantPending    = "PENDING"    // Not tested yet.
+	MutantSlain      = "SLAIN"      // The test suite failed against it.
+	MutantUnslain    = "UNSLAIN"    // The test suite passed against it, a survivor.
+	MutantEquivalent = "EQUIVALENT" // Excluded by TCE, compiles to the same code as the original.
+	MutantDuplicate  = "DUPLICATE"  // Excluded by TCE, compiles to the same code as another mutant.
+)
+
+// MutantRecord is everything known about a single mutant: what it changes, where, and
+// how it was tested. Lines and columns are 1-based, 0 if the mutation couldn't be located.
+type MutantRecord struct {
+	ID           string   `json:"id"`                     // Mutant ID from gambit_results.json e.g. "3"
+	Operator     string   `json:"operator"`               // e.g. "binary-op-mutation"
+	OriginalFile string   `json:"originalFile"`           // e.g. "src/Vault.sol"
+	Line         int      `json:"line,omitempty"`         // Line of the mutation in the original file
+	Column       int      `json:"column,omitempty"`       // Column of the mutation, in bytes
+	Contract     string   `json:"contract,omitempty"`     // Enclosing contract; empty for free functions and outside of contracts
+	Function     string   `json:"function,omitempty"`     // Enclosing function, modifier or constructor
+	Diff         string   `json:"diff"`                   // Unified diff against the original file
+	Status       string   `json:"status"`                 // One of the Mutant* statuses
+	TestCommand  string   `json:"testCommand,omitempty"`  // Command the mutant was last tested with
+	ExitCode     *int     `json:"exitCode,omitempty"`     // Exit code of the test command, -1 if it couldn't run; nil until tested
+	DurationMs   int64    `json:"durationMs,omitempty"`   // How long the test command ran
+	KillingTests []string `json:"killingTests,omitempty"` // Tests that failed against it, only known with --kill-matrix
+	CreatedAt    string   `json:"createdAt"`              // When the mutant was first recorded
+	StartedAt    string   `json:"startedAt,omitempty"`    // When its last test run started
+	FinishedAt   string   `json:"finishedAt,omitempty"`   // When its last test run finished
+}
+
+// GenerationFingerprint holds the SHA-256 hashes of the inputs of mutant generation, so that
+// mutants based on outdated sources are detected before they overwrite newer code.
+type GenerationFingerprint struct {
+	Config  string            `json:"config"`            // Hash of gambit_config.json
+	Entries map[string]string `json:"entries,omitempty"` // Hash of the gambit config entries of every file, keyed like Sources
+	Sources map[string]string `json:"sources"`           // Hash of every file of the gambit config, keyed by its path (e.g., "src/Vault.sol")
 }
 
 // OverallStats includes information that should be presented for the whole
@@ -38,8 +89,14 @@ type OverallStats struct {
 	// MutantsTotalUnslain is the total number of mutants that survived test execution so far.
 	MutantsTotalUnslain int32 `json:"mutantsTotalUnslain"`
 
+	// MutantsTotalEquivalent is the number of mutants that compile to the same code as the original.
+	MutantsTotalEquivalent int32 `json:"mutantsTotalEquivalent,omitempty"`
+
+	// MutantsTotalDuplicate is the number of mutants that compile to the same code as another mutant.
+	MutantsTotalDuplicate int32 `json:"mutantsTotalDuplicate,omitempty"`
+
 	// MutationScore represents the effectiveness of the test suite in killing mutants,
-	// calculated as (MutantsTotalSlain / MutantsTotalGenerated).
+	// calculated as (MutantsTotalSlain / (MutantsTotalGenerated - MutantsTotalEquivalent - MutantsTotalDuplicate)).
 	MutationScore float32 `json:"mutationScore"`
 }
 
@@ -65,6 +122,17 @@ type AnalyzedFile struct {
 	// LLMAnalysisOutcomes stores the detailed outcome of each LLM analysis attempt for mutants in this file.
 	// This is where status and error messages will live. Keyed by Mutant ID.
 	LLMAnalysisOutcomes map[string]MutantLLMAnalysisOutcome `json:"llmAnalysisOutcomes"`
+
+	// EquivalentMutants holds the mutants of this file that trivial compiler equivalence
+	// excluded from slaying and from the score. Keyed by Mutant ID.
+	EquivalentMutants map[string]MutantEquivalence `json:"equivalentMutants,omitempty"`
+}
+
+// MutantEquivalence records why a mutant was excluded by trivial compiler equivalence.
+type MutantEquivalence struct {
+	MutantID    string `json:"mutantId"`              // The ID of the mutant
+	Status      string `json:"status"`                // "EQUIVALENT" or "DUPLICATE"
+	DuplicateOf string `json:"duplicateOf,omitempty"` // ID of the mutant with the same bytecode; ONLY populated on Status == "DUPLICATE"
 }
 
 // FileSpecificStats contains mutation testing metrics for an individual source file.
@@ -78,6 +146,12 @@ type FileSpecificStats struct {
 	// MutantsTotalUnslain is the number of mutants that survived tests within this specific file.
 	MutantsTotalUnslain int32 `json:"mutantsTotalUnslain"`
 
+	// MutantsTotalEquivalent is the number of equivalent mutants of this specific file.
+	MutantsTotalEquivalent int32 `json:"mutantsTotalEquivalent,omitempty"`
+
+	// MutantsTotalDuplicate is the number of duplicate mutants of this specific file.
+	MutantsTotalDuplicate int32 `json:"mutantsTotalDuplicate,omitempty"`
+
 	// MutationScore is the mutation score for this specific file.
 	MutationScore float32 `json:"mutationScore"`
 }
@@ -88,6 +162,10 @@ type SlayingProgress struct {
 	// if the mutant has been tested. This helps in resuming the slaying process
 	// in a situation when the program is stopped.
 	MutantsProcessed map[string]bool `json:"mutantsProcessed"`
+
+	// KillingTests maps the mutants slain with --kill-matrix, keyed like MutantsProcessed,
+	// to the names of all tests that failed against them. It feeds the subsumption analysis.
+	KillingTests map[string][]string `json:"killingTests,omitempty"`
 }
 
 // LanguageModelProgress indicates which surviving mutants have been reviewed by the LLM.
@@ -111,6 +189,9 @@ func SaveStateToFile(filename string, data *MutationAnalysis) (err error) {
 		return
 	}
 
+	// The state is always written in the current structure.
+	data.SchemaVersion = SchemaVersion
+
 	var jsonData []byte
 	jsonData, err = json.MarshalIndent(data, "", "  ") // Using indent for readability
 	if err != nil {
@@ -118,6 +199,13 @@ func SaveStateToFile(filename string, data *MutationAnalysis) (err error) {
 		return
 	}
 
+	return writeFileAtomic(filename, jsonData)
+}
+
+// writeFileAtomic writes data to a temporary file next to filename and renames
+// it over filename, so that a crash never leaves a half written file behind.
+// It uses a named return 'err' to reliably manage cleanup operations in defers.
+func writeFileAtomic(filename string, jsonData []byte) (err error) {
 	// Create a temporary file in the same directory as the target file.
 	// This is important for os.Rename to be atomic on most systems.
 	dir := filepath.Dir(filename)
@@ -148,7 +236,7 @@ func SaveStateToFile(filename string, data *MutationAnalysis) (err error) {
 			tempFile.Close()
 		}
 
-		// If the SaveStateToFile function is returning an error (err != nil),
+		// If the writeFileAtomic function is returning an error (err != nil),
 		// attempt to remove the temporary file.
 		if err != nil {
 			// No need to check if tempFile is nil here because if CreateTemp failed,
@@ -195,7 +283,16 @@ func SaveStateToFile(filename string, data *MutationAnalysis) (err error) {
 // If the file does not exist, it returns a zero-valued MutationAnalysis struct and no error,
 // allowing the caller to initialize a new state.
 // If the file exists but an error occurs during reading or unmarshalling, an error is returned.
+// States written with an older SchemaVersion are migrated in memory, the file is
+// left as it is. Use a JSONStore to back it up before it's overwritten.
 func LoadStateFromFile(filename string) (MutationAnalysis, error) {
+	data, _, err := loadStateFile(filename)
+	return data, err
+}
+
+// loadStateFile is LoadStateFromFile, also returning the original of a
+// migrated state.
+func loadStateFile(filename string) (MutationAnalysis, *migratedState, error) {
 	var data MutationAnalysis
 
 	fileData, err := os.ReadFile(filename)
@@ -204,21 +301,36 @@ func LoadStateFromFile(filename string) (MutationAnalysis, error) {
 			// File doesn't exist, return a new, empty (zero-value) struct.
 			// The caller can then initialize it as needed.
 			// Ensure all maps are initialized to be usable.
-			return initializeMutationAnalysis(), nil
+			return initializeMutationAnalysis(), nil, nil
 		}
 		// Another error occurred (e.g., permission issue)
-		return data, fmt.Errorf("failed to read state file %s: %w", filename, err)
+		return data, nil, fmt.Errorf("failed to read state file %s: %w", filename, err)
 	}
 
 	// If file is empty, json.Unmarshal might not error but data would be zero-value.
 	// This is usually fine and handled like a new state.
 	if len(fileData) == 0 {
-		return initializeMutationAnalysis(), nil
+		return initializeMutationAnalysis(), nil, nil
+	}
+
+	return parseState(filename, fileData)
+}
+
+// parseState decodes the JSON of a state, migrating it to SchemaVersion first.
+// filename is where the state comes from, used for the messages and the
+// backup of migrated states. The original of a migrated state is returned,
+// nil if the state was up to date.
+func parseState(filename string, fileData []byte) (MutationAnalysis, *migratedState, error) {
+	var data MutationAnalysis
+
+	fileData, original, err := migrateStateFile(filename, fileData)
+	if err != nil {
+		return data, nil, err
 	}
 
 	err = json.Unmarshal(fileData, &data)
 	if err != nil {
-		return data, fmt.Errorf("failed to unmarshal JSON data from %s: %w", filename, err)
+		return data, nil, fmt.Errorf("failed to unmarshal JSON data from %s: %w", filename, err)
 	}
 
 	// Ensure maps are not nil after unmarshalling, even if they were empty in JSON.
@@ -235,12 +347,13 @@ func LoadStateFromFile(filename string) (MutationAnalysis, error) {
 		data.LanguageModelProgress.MutantsProcessed = make(map[string]bool)
 	}
 
-	return data, nil
+	return data, original, nil
 }
 
 // initializeMutationAnalysis creates a new MutationAnalysis struct with its maps initialized.
 func initializeMutationAnalysis() MutationAnalysis {
 	return MutationAnalysis{
+		SchemaVersion: SchemaVersion,
 		AnalyzedFiles: make(map[string]AnalyzedFile),
 		// Initialize boolans to false
 		SlayingProgress: SlayingProgress{
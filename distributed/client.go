@@ -0,0 +1,140 @@
+package distributed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrQueueFinished is returned by Client.Lease when the coordinator has no
+// more mutants to hand out and none are in progress.
+var ErrQueueFinished = errors.New("the coordinator's queue is finished")
+
+// ErrLeaseExpired is returned when the coordinator no longer recognizes a lease.
+var ErrLeaseExpired = errors.New("the lease expired")
+
+// Client talks to a Coordinator on behalf of a worker.
+type Client struct {
+	baseURL    string
+	worker     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the coordinator at baseURL, e.g. "http://127.0.0.1:7878".
+func NewClient(baseURL, worker string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		worker:     worker,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Worker returns the name the client reports to the coordinator.
+func (c *Client) Worker() string {
+	return c.worker
+}
+
+// Lease asks for the next mutant. It returns a nil lease and no error when all
+// remaining mutants are leased to other workers and it's worth asking again later.
+func (c *Client) Lease() (*Lease, error) {
+	resp, err := c.post("/lease", leaseRequest{Worker: c.worker})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var lease Lease
+		if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+			return nil, fmt.Errorf("failed to decode the lease: %w", err)
+		}
+		return &lease, nil
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusGone:
+		return nil, ErrQueueFinished
+	default:
+		return nil, unexpectedStatus(resp)
+	}
+}
+
+// Heartbeat extends the lease so that long test runs don't lose it.
+func (c *Client) Heartbeat(leaseID string) error {
+	resp, err := c.post("/heartbeat", heartbeatRequest{LeaseID: leaseID})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrLeaseExpired
+	default:
+		return unexpectedStatus(resp)
+	}
+}
+
+// Report sends the outcome of a leased mutant to the coordinator.
+func (c *Client) Report(result Result) error {
+	result.Worker = c.worker
+
+	resp, err := c.post("/report", result)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrLeaseExpired
+	default:
+		return unexpectedStatus(resp)
+	}
+}
+
+// Status fetches the coordinator's queue summary.
+func (c *Client) Status() (Status, error) {
+	var status Status
+
+	resp, err := c.httpClient.Get(c.baseURL + "/status")
+	if err != nil {
+		return status, fmt.Errorf("failed to reach the coordinator at %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return status, unexpectedStatus(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return status, fmt.Errorf("failed to decode the status: %w", err)
+	}
+	return status, nil
+}
+
+func (c *Client) post(path string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the coordinator at %s: %w", c.baseURL, err)
+	}
+	return resp, nil
+}
+
+func unexpectedStatus(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("coordinator responded with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
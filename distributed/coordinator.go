@@ -0,0 +1,328 @@
+package distributed
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// maxAttempts is how many times a mutant is handed out after workers reported
+// errors (not test results) for it, before the coordinator gives up on it.
+const maxAttempts = 3
+
+// Task is a single mutant waiting in the coordinator's queue.
+type Task struct {
+	MutantID     string `json:"mutantId"`     // Mutant identifier used in the state, e.g. "gambit_out/mutants/1/src/Vault.sol"
+	OriginalFile string `json:"originalFile"` // Path of the file to overwrite with the mutant, e.g. "src/Vault.sol"
+}
+
+// Lease grants a worker the exclusive right to test a mutant until the deadline.
+type Lease struct {
+	ID       string        `json:"id"`
+	Task     Task          `json:"task"`
+	Source   string        `json:"source"` // Content of the mutated file, so workers don't need the mutants directory.
+	Deadline time.Time     `json:"deadline"`
+	Timeout  time.Duration `json:"timeout"` // Lease duration, workers renew it with heartbeats well before it runs out.
+}
+
+// Result is what a worker reports back after testing a leased mutant.
+type Result struct {
+	LeaseID  string `json:"leaseId"`
+	MutantID string `json:"mutantId"`
+	Worker   string `json:"worker"`
+	Slain    bool   `json:"slain"`
+	Error    string `json:"error,omitempty"` // Set if the worker couldn't test the mutant. The mutant is queued again.
+	// KillingTests are the tests that failed against the mutant, only reported by workers run with --kill-matrix.
+	KillingTests []string `json:"killingTests,omitempty"`
+	// How the worker ran the tests, kept in the record of the mutant.
+	TestCommand string        `json:"testCommand,omitempty"`
+	ExitCode    int           `json:"exitCode"`
+	Started     time.Time     `json:"started"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Status summarizes the queue.
+type Status struct {
+	Pending   int  `json:"pending"`
+	Leased    int  `json:"leased"`
+	Completed int  `json:"completed"`
+	Failed    int  `json:"failed"`
+	Finished  bool `json:"finished"`
+}
+
+type leaseRequest struct {
+	Worker string `json:"worker"`
+}
+
+type heartbeatRequest struct {
+	LeaseID string `json:"leaseId"`
+}
+
+type activeLease struct {
+	lease  Lease
+	worker string
+}
+
+// Coordinator holds the mutant queue and hands out leases to workers over a
+// small HTTP+JSON API:
+//
+//	POST /lease      {"worker": "..."}  -> 200 Lease, 204 nothing to lease right now, 410 queue finished
+//	POST /heartbeat  {"leaseId": "..."} -> 200 lease extended, 409 lease expired
+//	POST /report     Result             -> 200 result accepted, 409 result ignored
+//	GET  /status                        -> 200 Status
+//
+// Leases that are not renewed or reported before their deadline go back to
+// the queue, so mutants of crashed workers are handed to someone else.
+type Coordinator struct {
+	mu           sync.Mutex
+	pending      []Task
+	leases       map[string]*activeLease // Keyed by lease ID.
+	completed    map[string]bool         // Keyed by mutant ID.
+	attempts     map[string]int          // Failed attempts keyed by mutant ID.
+	failed       int
+	leaseTimeout time.Duration
+	onResult     func(Task, Result)
+	done         chan struct{}
+
+	now        func() time.Time           // Replaceable clock for tests.
+	readSource func(Task) ([]byte, error) // Reads the mutant's source, applying its diff if it's stored as one.
+}
+
+// NewCoordinator creates a coordinator for the given tasks. onResult is called
+// once per mutant with its final result, one call at a time.
+func NewCoordinator(tasks []Task, leaseTimeout time.Duration, onResult func(Task, Result)) *Coordinator {
+	c := &Coordinator{
+		pending:      append([]Task{}, tasks...),
+		leases:       make(map[string]*activeLease),
+		completed:    make(map[string]bool),
+		attempts:     make(map[string]int),
+		leaseTimeout: leaseTimeout,
+		onResult:     onResult,
+		done:         make(chan struct{}),
+		now:          time.Now,
+		readSource: func(task Task) ([]byte, error) {
+			return mutator.ReadMutant(task.MutantID, task.OriginalFile)
+		},
+	}
+	if len(tasks) == 0 {
+		close(c.done)
+	}
+	return c
+}
+
+// Done is closed once every mutant has a final result.
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// Status returns the current queue summary.
+func (c *Coordinator) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireLeases()
+	return c.status()
+}
+
+// Handler returns the HTTP handler serving the coordinator API.
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /lease", c.handleLease)
+	mux.HandleFunc("POST /heartbeat", c.handleHeartbeat)
+	mux.HandleFunc("POST /report", c.handleReport)
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Status())
+	})
+	return mux
+}
+
+func (c *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid lease request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireLeases()
+
+	for len(c.pending) > 0 {
+		task := c.pending[0]
+		c.pending = c.pending[1:]
+
+		source, err := c.readSource(task)
+		if err != nil {
+			c.fail(task, fmt.Sprintf("coordinator couldn't read the mutant: %v", err))
+			continue
+		}
+
+		lease := Lease{
+			ID:       newLeaseID(),
+			Task:     task,
+			Source:   string(source),
+			Deadline: c.now().Add(c.leaseTimeout),
+			Timeout:  c.leaseTimeout,
+		}
+		c.leases[lease.ID] = &activeLease{lease: lease, worker: req.Worker}
+		writeJSON(w, http.StatusOK, lease)
+		return
+	}
+
+	if c.finished() {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	// Everything is leased out, the worker should ask again later in case
+	// some lease expires.
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Coordinator) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var req heartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid heartbeat: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireLeases()
+
+	active, ok := c.leases[req.LeaseID]
+	if !ok {
+		http.Error(w, "lease expired", http.StatusConflict)
+		return
+	}
+	active.lease.Deadline = c.now().Add(c.leaseTimeout)
+	writeJSON(w, http.StatusOK, active.lease.Deadline)
+}
+
+func (c *Coordinator) handleReport(w http.ResponseWriter, r *http.Request) {
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, fmt.Sprintf("invalid result: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.leases[result.LeaseID]
+	if !ok || active.lease.Task.MutantID != result.MutantID {
+		// The lease expired and the mutant went to someone else. The late
+		// result is still valid if nobody reported this mutant yet.
+		if result.Error != "" {
+			http.Error(w, "unknown or expired lease, result ignored", http.StatusConflict)
+			return
+		}
+		task, ok := c.takePending(result.MutantID)
+		if !ok {
+			http.Error(w, "unknown or expired lease, result ignored", http.StatusConflict)
+			return
+		}
+		c.complete(task, result)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	delete(c.leases, result.LeaseID)
+	task := active.lease.Task
+
+	if result.Error != "" {
+		c.attempts[task.MutantID]++
+		if c.attempts[task.MutantID] >= maxAttempts {
+			c.fail(task, result.Error)
+		} else {
+			c.pending = append(c.pending, task)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	c.complete(task, result)
+	w.WriteHeader(http.StatusOK)
+}
+
+// expireLeases puts the mutants of expired leases back at the front of the queue.
+// Must be called with the mutex held.
+func (c *Coordinator) expireLeases() {
+	now := c.now()
+	for id, active := range c.leases {
+		if now.After(active.lease.Deadline) {
+			fmt.Printf("\033[33m[Warning] Lease of mutant %s held by worker '%s' expired. Handing it to another worker.\033[0m\n",
+				active.lease.Task.MutantID, active.worker)
+			delete(c.leases, id)
+			c.pending = append([]Task{active.lease.Task}, c.pending...)
+		}
+	}
+}
+
+func (c *Coordinator) complete(task Task, result Result) {
+	c.completed[task.MutantID] = true
+	if c.onResult != nil {
+		c.onResult(task, result)
+	}
+	c.closeIfFinished()
+}
+
+func (c *Coordinator) fail(task Task, reason string) {
+	fmt.Printf("\033[31m[Error] Giving up on mutant %s: %s\033[0m\n", task.MutantID, reason)
+	c.failed++
+	c.closeIfFinished()
+}
+
+// takePending removes the mutant from the queue if it's waiting there.
+func (c *Coordinator) takePending(mutantID string) (Task, bool) {
+	for i, task := range c.pending {
+		if task.MutantID == mutantID {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+func (c *Coordinator) finished() bool {
+	return len(c.pending) == 0 && len(c.leases) == 0
+}
+
+func (c *Coordinator) closeIfFinished() {
+	if c.finished() {
+		select {
+		case <-c.done:
+		default:
+			close(c.done)
+		}
+	}
+}
+
+func (c *Coordinator) status() Status {
+	return Status{
+		Pending:   len(c.pending),
+		Leased:    len(c.leases),
+		Completed: len(c.completed),
+		Failed:    c.failed,
+		Finished:  c.finished(),
+	}
+}
+
+func newLeaseID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
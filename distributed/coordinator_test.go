@@ -0,0 +1,188 @@
+package distributed
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func newTestCoordinator(t *testing.T, tasks []Task, timeout time.Duration) (*Coordinator, *fakeClock, *[]Result, string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var results []Result
+	coordinator := NewCoordinator(tasks, timeout, func(task Task, result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+	})
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	coordinator.now = clock.Now
+	coordinator.readSource = func(task Task) ([]byte, error) {
+		return []byte("contract Mutant_" + task.MutantID + " {}"), nil
+	}
+
+	server := httptest.NewServer(coordinator.Handler())
+	t.Cleanup(server.Close)
+
+	return coordinator, clock, &results, server.URL
+}
+
+func TestWorkersDrainTheQueue(t *testing.T) {
+	tasks := []Task{
+		{MutantID: "1", OriginalFile: "src/A.sol"},
+		{MutantID: "2", OriginalFile: "src/A.sol"},
+		{MutantID: "3", OriginalFile: "src/B.sol"},
+	}
+	coordinator, _, results, url := newTestCoordinator(t, tasks, time.Minute)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"worker-a", "worker-b"} {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			for {
+				lease, err := client.Lease()
+				if errors.Is(err, ErrQueueFinished) {
+					return
+				}
+				if err != nil {
+					t.Errorf("lease failed: %v", err)
+					return
+				}
+				if lease == nil {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+				if err := client.Report(Result{LeaseID: lease.ID, MutantID: lease.Task.MutantID, Slain: true}); err != nil {
+					t.Errorf("report failed: %v", err)
+				}
+			}
+		}(NewClient(url, name))
+	}
+	wg.Wait()
+
+	select {
+	case <-coordinator.Done():
+	default:
+		t.Fatal("coordinator is not done after all mutants were reported")
+	}
+	if len(*results) != len(tasks) {
+		t.Fatalf("expected %d results, got %d", len(tasks), len(*results))
+	}
+}
+
+func TestExpiredLeaseIsHandedToAnotherWorker(t *testing.T) {
+	coordinator, clock, results, url := newTestCoordinator(t, []Task{{MutantID: "1", OriginalFile: "src/A.sol"}}, time.Minute)
+
+	crashed := NewClient(url, "crashed")
+	healthy := NewClient(url, "healthy")
+
+	lease, err := crashed.Lease()
+	if err != nil || lease == nil {
+		t.Fatalf("expected a lease, got %v, %v", lease, err)
+	}
+	if lease.Source != "contract Mutant_1 {}" {
+		t.Fatalf("unexpected mutant source: %q", lease.Source)
+	}
+
+	// While the lease is active nobody else gets the mutant.
+	if other, err := healthy.Lease(); err != nil || other != nil {
+		t.Fatalf("expected no lease while the mutant is leased, got %v, %v", other, err)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if err := crashed.Heartbeat(lease.ID); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("expected the expired lease to be rejected, got %v", err)
+	}
+
+	reassigned, err := healthy.Lease()
+	if err != nil || reassigned == nil {
+		t.Fatalf("expected the expired mutant to be leased again, got %v, %v", reassigned, err)
+	}
+	if reassigned.Task.MutantID != "1" {
+		t.Fatalf("expected mutant 1 to be reassigned, got %s", reassigned.Task.MutantID)
+	}
+
+	if err := healthy.Report(Result{LeaseID: reassigned.ID, MutantID: "1", Slain: false}); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	// The crashed worker coming back late must not produce a second result.
+	if err := crashed.Report(Result{LeaseID: lease.ID, MutantID: "1", Slain: true}); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("expected the late report to be ignored, got %v", err)
+	}
+
+	if _, err := healthy.Lease(); !errors.Is(err, ErrQueueFinished) {
+		t.Fatalf("expected the queue to be finished, got %v", err)
+	}
+	if len(*results) != 1 || (*results)[0].Worker != "healthy" || (*results)[0].Slain {
+		t.Fatalf("unexpected results: %+v", *results)
+	}
+	if status := coordinator.Status(); !status.Finished || status.Completed != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestHeartbeatKeepsTheLease(t *testing.T) {
+	_, clock, _, url := newTestCoordinator(t, []Task{{MutantID: "1"}}, time.Minute)
+	client := NewClient(url, "slow")
+
+	lease, err := client.Lease()
+	if err != nil || lease == nil {
+		t.Fatalf("expected a lease, got %v, %v", lease, err)
+	}
+
+	for range 3 {
+		clock.Advance(40 * time.Second)
+		if err := client.Heartbeat(lease.ID); err != nil {
+			t.Fatalf("heartbeat failed: %v", err)
+		}
+	}
+
+	if err := client.Report(Result{LeaseID: lease.ID, MutantID: "1", Slain: true}); err != nil {
+		t.Fatalf("report after heartbeats failed: %v", err)
+	}
+}
+
+func TestWorkerErrorsRequeueTheMutant(t *testing.T) {
+	coordinator, _, results, url := newTestCoordinator(t, []Task{{MutantID: "1"}}, time.Minute)
+	client := NewClient(url, "flaky")
+
+	for range maxAttempts {
+		lease, err := client.Lease()
+		if err != nil || lease == nil {
+			t.Fatalf("expected a lease, got %v, %v", lease, err)
+		}
+		if err := client.Report(Result{LeaseID: lease.ID, MutantID: "1", Error: "disk full"}); err != nil {
+			t.Fatalf("report failed: %v", err)
+		}
+	}
+
+	if _, err := client.Lease(); !errors.Is(err, ErrQueueFinished) {
+		t.Fatalf("expected the coordinator to give up on the mutant, got %v", err)
+	}
+	if status := coordinator.Status(); status.Failed != 1 || len(*results) != 0 {
+		t.Fatalf("unexpected status %+v with results %+v", status, *results)
+	}
+}
@@ -0,0 +1,98 @@
+package framework
+
+import (
+	"bufio"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Foundry supports projects built with forge (https://getfoundry.sh).
+type Foundry struct{}
+
+var (
+	ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// e.g. "[FAIL: assertion failed: 1 != 2] test_Increment() (gas: 31303)"
+	forgeTestLineRegex = regexp.MustCompile(`^\[(PASS|FAIL)[^\]]*\]\s+([A-Za-z0-9_$]+\([^)]*\))`)
+	// e.g. "Ran 2 tests for test/Counter.t.sol:CounterTest"
+	forgeSuiteLineRegex = regexp.MustCompile(`^(?:Ran \d+ tests? for|Encountered \d+ failing tests? in) (\S+)`)
+)
+
+func (Foundry) Name() string { return "Foundry" }
+
+func (Foundry) Detect(dir string) bool {
+	return fileExists(filepath.Join(dir, "foundry.toml"))
+}
+
+// BuildSettings reads 'foundry.toml' and 'remappings.txt' directly, so forge
+// doesn't have to be installed. Without 'foundry.toml' forge's defaults are used.
+func (Foundry) BuildSettings(dir string) (BuildSettings, error) {
+	if !fileExists(filepath.Join(dir, "foundry.toml")) {
+		return BuildSettings{
+			Profile:    foundryProfile(),
+			SourceDir:  "src",
+			Libs:       []string{"lib"},
+			Remappings: autodetectLibRemappings(dir, []string{"lib"}),
+		}, nil
+	}
+	return loadFoundrySettings(dir)
+}
+
+// Remappings returns the remappings that forge would use, without running
+// 'forge remappings'. They come from the config files, whatever the sources.
+func (f Foundry) Remappings(string) (string, error) {
+	settings, err := f.BuildSettings(".")
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(settings.Remappings, "\n"), nil
+}
+
+func (Foundry) BaselineTestCommand() string { return "forge test" }
+
+func (Foundry) MutantTestCommand() string { return "forge test --fail-fast" }
+
+func (Foundry) CoverageCommand() string { return "forge coverage --report lcov" }
+
+// ParseResults reads forge's test output. Failing tests are repeated in the
+// summary at the end of the output, so results are keyed by the test name.
+func (Foundry) ParseResults(output string) TestResults {
+	results := make(map[string]bool) // Test name -> passed
+	var failingOrder []string
+	suite := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(ansiEscapeRegex.ReplaceAllString(output, "")))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := forgeSuiteLineRegex.FindStringSubmatch(line); match != nil {
+			suite = match[1]
+			continue
+		}
+
+		match := forgeTestLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		name := match[2]
+		if suite != "" {
+			name = suite + "::" + name
+		}
+		if _, seen := results[name]; seen {
+			continue
+		}
+
+		passed := match[1] == "PASS"
+		results[name] = passed
+		if !passed {
+			failingOrder = append(failingOrder, name)
+		}
+	}
+
+	return TestResults{
+		Passed:       len(results) - len(failingOrder),
+		Failed:       len(failingOrder),
+		FailingTests: failingOrder,
+	}
+}
@@ -0,0 +1,258 @@
+package framework
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuildSettings describes the source layout and compiler settings of a project.
+type BuildSettings struct {
+	Profile       string   // Selected configuration profile e.g. "default". Empty if the framework has no profiles.
+	SourceDir     string   // Folder with the project's contracts e.g. "src".
+	Libs          []string // Folders with dependencies e.g. ["lib"].
+	Remappings    []string // Import remappings in the "prefix/=target/" form, in order of precedence.
+	SolcVersion   string   // Requested compiler version e.g. "0.8.23", or a path to a solc binary.
+	Optimizer     bool     // Whether the optimizer is enabled.
+	OptimizerRuns int      // Number of optimizer runs, 0 if not configured.
+	ViaIR         bool     // Whether to compile via the IR pipeline.
+	// Warnings describe the problems with the config files that were worked
+	// around with the defaults, for the user to fix.
+	Warnings []string
+}
+
+// foundryProfile returns the profile selected with FOUNDRY_PROFILE, the same
+// way forge selects it.
+func foundryProfile() string {
+	if profile := os.Getenv("FOUNDRY_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// loadFoundrySettings reads 'foundry.toml' and 'remappings.txt' in dir without
+// invoking forge. Keys missing in the selected profile fall back to the
+// default profile and then to forge's defaults. A 'foundry.toml' that can't be
+// parsed, e.g. with TOML this parser doesn't support, and an undefined profile
+// give a warning and forge's defaults instead of an error.
+func loadFoundrySettings(dir string) (BuildSettings, error) {
+	profile := foundryProfile()
+	settings := BuildSettings{
+		Profile:   profile,
+		SourceDir: "src",
+		Libs:      []string{"lib"},
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "foundry.toml"))
+	if err != nil {
+		return settings, fmt.Errorf("Failed to read foundry.toml: %w", err)
+	}
+	values, err := parseTOML(string(content))
+	if err != nil {
+		settings.Warnings = append(settings.Warnings, fmt.Sprintf("Failed to parse foundry.toml, using forge's defaults instead: %v.", err))
+		values = map[string]any{}
+	} else if profile != "default" && !hasTable(values, "profile."+profile) {
+		settings.Warnings = append(settings.Warnings, fmt.Sprintf("The profile '%s' selected with FOUNDRY_PROFILE is not defined in foundry.toml, using the default profile.", profile))
+		profile = "default"
+		settings.Profile = profile
+	}
+
+	lookup := func(key string) (any, bool) {
+		for _, p := range []string{profile, "default"} {
+			if value, ok := values["profile."+p+"."+key]; ok {
+				return value, true
+			}
+		}
+		return nil, false
+	}
+
+	if value, ok := lookup("src"); ok {
+		if src, ok := value.(string); ok && src != "" {
+			settings.SourceDir = filepath.Clean(src)
+		}
+	}
+	if value, ok := lookup("libs"); ok {
+		settings.Libs = toStrings(value)
+	}
+	for _, key := range []string{"solc_version", "solc"} {
+		if value, ok := lookup(key); ok {
+			if version, ok := value.(string); ok {
+				settings.SolcVersion = version
+				break
+			}
+		}
+	}
+	if value, ok := lookup("optimizer"); ok {
+		settings.Optimizer, _ = value.(bool)
+	}
+	if value, ok := lookup("optimizer_runs"); ok {
+		if runs, ok := value.(int64); ok {
+			settings.OptimizerRuns = int(runs)
+		}
+	}
+	if value, ok := lookup("via_ir"); ok {
+		settings.ViaIR, _ = value.(bool)
+	}
+
+	// Remappings in order of precedence: foundry.toml, remappings.txt and
+	// finally the ones forge detects automatically from the libs folders.
+	var remappings []string
+	if value, ok := lookup("remappings"); ok {
+		remappings = append(remappings, toStrings(value)...)
+	}
+	fileRemappings, err := readRemappingsFile(filepath.Join(dir, "remappings.txt"), "")
+	if err != nil {
+		return settings, err
+	}
+	remappings = append(remappings, fileRemappings...)
+	remappings = append(remappings, autodetectLibRemappings(dir, settings.Libs)...)
+	settings.Remappings = dedupeRemappings(remappings)
+
+	return settings, nil
+}
+
+// hasTable reports whether the table is defined, with a header of its own or
+// as the prefix of a key.
+func hasTable(values map[string]any, table string) bool {
+	if _, ok := values[table]; ok {
+		return true
+	}
+	for key := range values {
+		if strings.HasPrefix(key, table+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func toStrings(value any) []string {
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// readRemappingsFile reads a remappings.txt file. Targets are prefixed with
+// targetPrefix, which is used for the remappings of nested dependencies. A
+// missing file results in no remappings.
+func readRemappingsFile(path, targetPrefix string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var remappings []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if targetPrefix != "" {
+			line = prefixRemappingPaths(line, targetPrefix)
+		}
+		remappings = append(remappings, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+	}
+
+	return remappings, nil
+}
+
+// prefixRemappingPaths makes the context and target of a dependency's
+// remapping relative to the project root, e.g. "@oz/=contracts/" from
+// 'lib/oz/remappings.txt' becomes "@oz/=lib/oz/contracts/".
+func prefixRemappingPaths(line, pathPrefix string) string {
+	from, to, found := strings.Cut(line, "=")
+	if !found {
+		return line
+	}
+
+	join := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		joined := filepath.ToSlash(filepath.Join(pathPrefix, path))
+		if strings.HasSuffix(path, "/") {
+			joined += "/"
+		}
+		return joined
+	}
+
+	if context, prefix, hasContext := strings.Cut(from, ":"); hasContext {
+		from = join(context) + ":" + prefix
+	}
+	return from + "=" + join(to)
+}
+
+// autodetectLibRemappings mimics forge's automatic remappings: each dependency
+// 'lib/<name>' is remapped as '<name>/' to its 'src' or 'contracts' folder if
+// there is one. The dependency's own remappings.txt is included as well.
+func autodetectLibRemappings(dir string, libs []string) []string {
+	var remappings []string
+
+	for _, lib := range libs {
+		entries, err := os.ReadDir(filepath.Join(dir, lib))
+		if err != nil {
+			continue
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			depPath := filepath.ToSlash(filepath.Join(lib, entry.Name()))
+			target := depPath + "/"
+			for _, sourceDir := range []string{"src", "contracts"} {
+				if info, err := os.Stat(filepath.Join(dir, depPath, sourceDir)); err == nil && info.IsDir() {
+					target = depPath + "/" + sourceDir + "/"
+					break
+				}
+			}
+			remappings = append(remappings, fmt.Sprintf("%s/=%s", entry.Name(), target))
+
+			nested, err := readRemappingsFile(filepath.Join(dir, depPath, "remappings.txt"), depPath)
+			if err == nil {
+				remappings = append(remappings, nested...)
+			}
+		}
+	}
+
+	return remappings
+}
+
+// dedupeRemappings keeps the first remapping for each (context, prefix) pair.
+func dedupeRemappings(remappings []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, line := range remappings {
+		remapping, err := ParseRemapping(line)
+		if err != nil {
+			// Keep malformed lines, they are reported when translated.
+			result = append(result, line)
+			continue
+		}
+		if seen[remapping.Key()] {
+			continue
+		}
+		seen[remapping.Key()] = true
+		result = append(result, line)
+	}
+	return result
+}
@@ -0,0 +1,182 @@
+package framework
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testFoundryToml = `# Project config
+[profile.default]
+src = "contracts"
+out = "out"
+libs = ["lib", "node_modules"] # dependencies
+remappings = [
+    "@oz/=lib/openzeppelin-contracts/contracts/",
+    'solmate/=lib/solmate/src/',
+]
+solc_version = "0.8.23"
+optimizer = true
+optimizer_runs = 10_000
+
+[profile.ci]
+via_ir = true
+optimizer = false
+
+[fmt]
+line_length = 120
+
+[[profile.default.fs_permissions]]
+access = "read"
+path = "./"
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFoundryBuildSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"foundry.toml":                              testFoundryToml,
+		"remappings.txt":                            "forge-std/=lib/forge-std/src/\n@oz/=somewhere/else/\n",
+		"lib/forge-std/src/Test.sol":                "",
+		"lib/openzeppelin-contracts/contracts/a":    "",
+		"lib/openzeppelin-contracts/remappings.txt": "@openzeppelin/contracts/=contracts/\n",
+		"lib/solmate/src/a":                         "",
+		"node_modules/@uniswap/v3-core/a":           "",
+	})
+
+	t.Setenv("FOUNDRY_PROFILE", "")
+	settings, err := Foundry{}.BuildSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := BuildSettings{
+		Profile:       "default",
+		SourceDir:     "contracts",
+		Libs:          []string{"lib", "node_modules"},
+		SolcVersion:   "0.8.23",
+		Optimizer:     true,
+		OptimizerRuns: 10000,
+		Remappings: []string{
+			"@oz/=lib/openzeppelin-contracts/contracts/",
+			"solmate/=lib/solmate/src/",
+			"forge-std/=lib/forge-std/src/",
+			"openzeppelin-contracts/=lib/openzeppelin-contracts/contracts/",
+			"@openzeppelin/contracts/=lib/openzeppelin-contracts/contracts/",
+			"@uniswap/=node_modules/@uniswap/",
+		},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Fatalf("got  %+v\nwant %+v", settings, want)
+	}
+}
+
+func TestFoundryBuildSettingsProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"foundry.toml": testFoundryToml})
+
+	t.Setenv("FOUNDRY_PROFILE", "ci")
+	settings, err := Foundry{}.BuildSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys missing in the ci profile come from the default profile.
+	if settings.Profile != "ci" || !settings.ViaIR || settings.Optimizer || settings.SourceDir != "contracts" || settings.SolcVersion != "0.8.23" {
+		t.Fatalf("unexpected settings for the ci profile: %+v", settings)
+	}
+
+	// An undefined profile falls back to the default one.
+	t.Setenv("FOUNDRY_PROFILE", "missing")
+	settings, err = Foundry{}.BuildSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Profile != "default" || settings.SourceDir != "contracts" || len(settings.Warnings) != 1 {
+		t.Fatalf("unexpected settings for an undefined profile: %+v", settings)
+	}
+
+	// An empty profile is defined.
+	writeFiles(t, dir, map[string]string{"foundry.toml": testFoundryToml + "\n[profile.empty]\n"})
+	t.Setenv("FOUNDRY_PROFILE", "empty")
+	settings, err = Foundry{}.BuildSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Profile != "empty" || settings.SourceDir != "contracts" || len(settings.Warnings) > 0 {
+		t.Fatalf("unexpected settings for an empty profile: %+v", settings)
+	}
+}
+
+func TestFoundryBuildSettingsUnparsableConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"foundry.toml":   "[profile.default]\nsrc = \"contracts\"\nlibs = [\"lib\"\n",
+		"remappings.txt": "forge-std/=lib/forge-std/src/\n",
+	})
+
+	t.Setenv("FOUNDRY_PROFILE", "")
+	settings, err := Foundry{}.BuildSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// forge's defaults, with the remappings of remappings.txt.
+	if settings.SourceDir != "src" || !reflect.DeepEqual(settings.Remappings, []string{"forge-std/=lib/forge-std/src/"}) || len(settings.Warnings) != 1 {
+		t.Fatalf("expected forge's defaults and a warning, got %+v", settings)
+	}
+}
+
+func TestFoundryBuildSettingsWithoutConfig(t *testing.T) {
+	settings, err := Foundry{}.BuildSettings(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.SourceDir != "src" || !reflect.DeepEqual(settings.Libs, []string{"lib"}) {
+		t.Fatalf("expected forge's defaults, got %+v", settings)
+	}
+}
+
+func TestParseTOMLDates(t *testing.T) {
+	values, err := parseTOML(`released = 1979-05-27
+odt = 1979-05-27T07:32:00Z
+spaced = 1979-05-27 07:32:00.999-07:00
+time = [07:32:00, 00:32:00.5]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"released": "1979-05-27",
+		"odt":      "1979-05-27T07:32:00Z",
+		"spaced":   "1979-05-27 07:32:00.999-07:00",
+		"time":     []any{"07:32:00", "00:32:00.5"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("got  %v\nwant %v", values, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, content := range []string{
+		"src = \"unterminated",
+		"[profile.default\nsrc = \"src\"",
+		"libs = [\"lib\"",
+		"src \"src\"",
+	} {
+		if _, err := parseTOML(content); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
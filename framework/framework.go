@@ -0,0 +1,88 @@
+// Package framework abstracts the Solidity development frameworks (Foundry,
+// Hardhat) that checkmate runs the test suite and resolves imports with.
+package framework
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Framework describes how checkmate interacts with a Solidity development framework.
+type Framework interface {
+	// Name is the human readable name of the framework e.g. "Foundry".
+	Name() string
+
+	// Detect returns true if the project in dir uses this framework.
+	Detect(dir string) bool
+
+	// BuildSettings reads the source layout and compiler settings of the
+	// project in dir from the framework's config files.
+	BuildSettings(dir string) (BuildSettings, error)
+
+	// Remappings returns the import remappings in the "prefix/=target/" form
+	// used by 'forge remappings'. contractsDir is the folder with the sources
+	// that the remappings must resolve, for frameworks that derive them from
+	// the imports of the sources.
+	Remappings(contractsDir string) (string, error)
+
+	// BaselineTestCommand runs the whole test suite on the unmutated code.
+	BaselineTestCommand() string
+
+	// MutantTestCommand runs the test suite against a mutant. It should stop
+	// at the first failing test as this is enough to slay the mutant.
+	MutantTestCommand() string
+
+	// ParseResults extracts the test results from the output of a test command.
+	ParseResults(output string) TestResults
+
+	// CoverageCommand produces a coverage report of the test suite.
+	CoverageCommand() string
+}
+
+// TestResults summarizes the output of a test run.
+type TestResults struct {
+	Passed       int      // Number of passing tests.
+	Failed       int      // Number of failing tests.
+	FailingTests []string // Names of the failing tests, as printed by the framework.
+}
+
+// supported lists the frameworks in the order of detection. Foundry goes first
+// because projects using both frameworks run their tests faster with forge.
+var supported = []Framework{
+	Foundry{},
+	Hardhat{},
+}
+
+// Detect returns the framework used by the project in dir.
+func Detect(dir string) (Framework, bool) {
+	for _, fw := range supported {
+		if fw.Detect(dir) {
+			return fw, true
+		}
+	}
+	return nil, false
+}
+
+// ByName returns the framework with the given (case insensitive) name.
+func ByName(name string) (Framework, error) {
+	var names []string
+	for _, fw := range supported {
+		if strings.EqualFold(fw.Name(), name) {
+			return fw, nil
+		}
+		names = append(names, strings.ToLower(fw.Name()))
+	}
+	return nil, fmt.Errorf("unsupported framework '%s', supported frameworks are: %s", name, strings.Join(names, ", "))
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func anyFileExists(dir string, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	return err == nil && len(matches) > 0
+}
@@ -0,0 +1,158 @@
+package framework
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"foundry", []string{"foundry.toml"}, "Foundry"},
+		{"hardhat js", []string{"hardhat.config.js"}, "Hardhat"},
+		{"hardhat ts", []string{"hardhat.config.ts"}, "Hardhat"},
+		{"both prefers foundry", []string{"hardhat.config.ts", "foundry.toml"}, "Foundry"},
+		{"none", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, file), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fw, ok := Detect(dir)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("expected no framework, got %s", fw.Name())
+				}
+				return
+			}
+			if !ok || fw.Name() != tt.want {
+				t.Fatalf("expected %s, got %v (detected: %v)", tt.want, fw, ok)
+			}
+		})
+	}
+}
+
+func TestByName(t *testing.T) {
+	if fw, err := ByName("hardhat"); err != nil || fw.Name() != "Hardhat" {
+		t.Fatalf("expected Hardhat, got %v, %v", fw, err)
+	}
+	if _, err := ByName("truffle"); err == nil {
+		t.Fatal("expected an error for an unsupported framework")
+	}
+}
+
+func TestCoverageCommand(t *testing.T) {
+	tests := []struct {
+		fw   Framework
+		want string
+	}{
+		{Foundry{}, "forge coverage --report lcov"},
+		{Hardhat{}, "npx hardhat coverage"},
+	}
+	for _, tt := range tests {
+		if got := tt.fw.CoverageCommand(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.fw.Name(), tt.want, got)
+		}
+	}
+}
+
+func TestFoundryParseResults(t *testing.T) {
+	output := "Ran 3 tests for test/Counter.t.sol:CounterTest\n" +
+		"\x1b[32m[PASS]\x1b[0m testFuzz_SetNumber(uint256) (runs: 256, μ: 30977, ~: 31288)\n" +
+		"[PASS] test_Decrement() (gas: 28000)\n" +
+		"\x1b[31m[FAIL: assertion failed: 1 != 2]\x1b[0m test_Increment() (gas: 31303)\n" +
+		"Suite result: FAILED. 2 passed; 1 failed; 0 skipped; finished in 7.14ms\n" +
+		"\n" +
+		"Failing tests:\n" +
+		"Encountered 1 failing test in test/Counter.t.sol:CounterTest\n" +
+		"[FAIL: assertion failed: 1 != 2] test_Increment() (gas: 31303)\n"
+
+	got := Foundry{}.ParseResults(output)
+	want := TestResults{
+		Passed:       2,
+		Failed:       1,
+		FailingTests: []string{"test/Counter.t.sol:CounterTest::test_Increment()"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestHardhatParseResults(t *testing.T) {
+	output := `
+  Lock
+    Deployment
+      ✔ Should set the right unlockTime (1021ms)
+      1) Should set the right owner
+
+  1 passing (1s)
+  1 failing
+
+  1) Lock
+       Deployment
+         Should set the right owner:
+     AssertionError: expected '0x1' to equal '0x2'
+`
+
+	got := Hardhat{}.ParseResults(output)
+	want := TestResults{
+		Passed:       1,
+		Failed:       1,
+		FailingTests: []string{"Should set the right owner"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestHardhatRemappings(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	for _, path := range []string{"contracts", "node_modules/@openzeppelin/contracts", "node_modules/solmate"} {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := `import "@openzeppelin/contracts/token/ERC20/ERC20.sol";
+import {Owned} from "solmate/auth/Owned.sol";
+import "./Local.sol";
+import "missing/Lib.sol";`
+	if err := os.WriteFile("contracts/Token.sol", []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Hardhat{}.Remappings("contracts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "@openzeppelin/=node_modules/@openzeppelin/\nsolmate/=node_modules/solmate/"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// chdir switches to dir for the duration of the test. Remappings are
+// resolved relative to the project root, which is the working directory.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
@@ -0,0 +1,128 @@
+package framework
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hardhat supports projects built with Hardhat (https://hardhat.org).
+type Hardhat struct{}
+
+var (
+	// e.g. "  12 passing (3s)" and "  1 failing"
+	mochaSummaryRegex = regexp.MustCompile(`^\s*(\d+) (passing|failing)`)
+	// e.g. "      1) Should set the right owner"
+	mochaFailingTestRegex = regexp.MustCompile(`^\s+\d+\) (.+)$`)
+)
+
+func (Hardhat) Name() string { return "Hardhat" }
+
+func (Hardhat) Detect(dir string) bool {
+	return anyFileExists(dir, "hardhat.config.*")
+}
+
+// BuildSettings returns Hardhat's default layout. The compiler settings live
+// in the JavaScript config, which isn't evaluated.
+func (Hardhat) BuildSettings(dir string) (BuildSettings, error) {
+	return BuildSettings{
+		SourceDir: "contracts",
+		Libs:      []string{"node_modules"},
+	}, nil
+}
+
+// Remappings maps the npm packages imported by the contracts to node_modules,
+// the same way Hardhat resolves them e.g. "@openzeppelin/=node_modules/@openzeppelin/".
+func (Hardhat) Remappings(contractsDir string) (string, error) {
+	packages := make(map[string]bool)
+
+	err := filepath.Walk(contractsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".sol") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, importPath := range Imports(string(content)) {
+			if pkg := npmPackageOf(importPath); pkg != "" {
+				packages[pkg] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to scan '%s' for imports: %v", contractsDir, err)
+	}
+
+	var lines []string
+	for pkg := range packages {
+		target := filepath.ToSlash(filepath.Join("node_modules", pkg))
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			lines = append(lines, fmt.Sprintf("%s/=%s/", pkg, target))
+		}
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// npmPackageOf returns the package part of a non-relative import path e.g.
+// "@openzeppelin" for "@openzeppelin/contracts/token/ERC20/ERC20.sol" and
+// "solmate" for "solmate/src/tokens/ERC20.sol".
+func npmPackageOf(importPath string) string {
+	if strings.HasPrefix(importPath, ".") || strings.HasPrefix(importPath, "/") {
+		return ""
+	}
+	pkg, _, found := strings.Cut(importPath, "/")
+	if !found {
+		return ""
+	}
+	return pkg
+}
+
+func (Hardhat) BaselineTestCommand() string { return "npx hardhat test" }
+
+func (Hardhat) MutantTestCommand() string { return "npx hardhat test --bail" }
+
+func (Hardhat) CoverageCommand() string { return "npx hardhat coverage" }
+
+// ParseResults reads mocha's output. Failing tests are numbered inline and
+// repeated with their errors after the summary, only the inline ones are used.
+func (Hardhat) ParseResults(output string) TestResults {
+	var results TestResults
+	summaryReached := false
+
+	scanner := bufio.NewScanner(strings.NewReader(ansiEscapeRegex.ReplaceAllString(output, "")))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := mochaSummaryRegex.FindStringSubmatch(line); match != nil {
+			summaryReached = true
+			count, _ := strconv.Atoi(match[1])
+			if match[2] == "passing" {
+				results.Passed = count
+			} else {
+				results.Failed = count
+			}
+			continue
+		}
+
+		if !summaryReached {
+			if match := mochaFailingTestRegex.FindStringSubmatch(line); match != nil {
+				results.FailingTests = append(results.FailingTests, strings.TrimSpace(match[1]))
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,77 @@
+package framework
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matches all forms of Solidity imports and captures the imported path e.g.
+// `import "./A.sol";`, `import {A} from "../src/A.sol";`, `import * as A from "src/A.sol";`
+var importPathRegex = regexp.MustCompile(`import\s+(?:[^;"']*?\s+from\s+)?["']([^"']+)["']`)
+
+// Imports returns the paths imported by the Solidity source, as written in
+// its import statements.
+func Imports(content string) []string {
+	var paths []string
+	for _, match := range importPathRegex.FindAllStringSubmatch(content, -1) {
+		paths = append(paths, match[1])
+	}
+	return paths
+}
+
+// Remapping is a solc import remapping in the "[context:]prefix=target" form.
+type Remapping struct {
+	Context string // Only imports from files under this path are remapped. Empty applies to all files.
+	Prefix  string // Import path prefix to replace e.g. "@openzeppelin/".
+	Target  string // Replacement of the prefix e.g. "lib/openzeppelin-contracts/contracts/".
+}
+
+// ParseRemapping parses a single remapping line as printed by 'forge
+// remappings' or written in remappings.txt and foundry.toml.
+func ParseRemapping(line string) (Remapping, error) {
+	var r Remapping
+
+	line = strings.TrimSpace(line)
+	source, target, found := strings.Cut(line, "=")
+	if !found {
+		return r, fmt.Errorf("'%s' has no '='", line)
+	}
+
+	if context, prefix, hasContext := strings.Cut(source, ":"); hasContext {
+		r.Context = strings.TrimSpace(context)
+		source = prefix
+	}
+	r.Prefix = strings.TrimSpace(source)
+	r.Target = strings.TrimSpace(target)
+
+	if r.Prefix == "" {
+		return r, fmt.Errorf("'%s' has an empty prefix", line)
+	}
+	if r.Target == "" {
+		return r, fmt.Errorf("'%s' has an empty target", line)
+	}
+
+	return r, nil
+}
+
+// Key identifies remappings that compete for the same imports. Trailing
+// slashes don't matter, "@oz" and "@oz/" remap the same imports.
+func (r Remapping) Key() string {
+	return r.Context + ":" + strings.TrimSuffix(r.Prefix, "/")
+}
+
+// String formats the remapping the way solc and Gambit accept it. A trailing
+// slash is dropped from the prefix, keeping the "prefix=target" style of
+// Gambit's config e.g. "@oz=lib/oz/contracts/" or "lib/foo:@oz=lib/oz/contracts/".
+func (r Remapping) String() string {
+	prefix := r.Prefix
+	if len(prefix) > 1 {
+		prefix = strings.TrimSuffix(prefix, "/")
+	}
+
+	if r.Context != "" {
+		return fmt.Sprintf("%s:%s=%s", r.Context, prefix, r.Target)
+	}
+	return fmt.Sprintf("%s=%s", prefix, r.Target)
+}
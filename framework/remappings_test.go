@@ -0,0 +1,91 @@
+package framework
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRemapping(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Remapping
+		gambit  string
+		wantErr bool
+	}{
+		{line: "forge-std/=lib/forge-std/src/", want: Remapping{Prefix: "forge-std/", Target: "lib/forge-std/src/"}, gambit: "forge-std=lib/forge-std/src/"},
+		{line: "ds-test/=lib/ds-test/src", want: Remapping{Prefix: "ds-test/", Target: "lib/ds-test/src"}, gambit: "ds-test=lib/ds-test/src"},
+		{line: "@oz=lib/oz/contracts", want: Remapping{Prefix: "@oz", Target: "lib/oz/contracts"}, gambit: "@oz=lib/oz/contracts"},
+		{line: "lib/foo:@oz/=lib/oz/contracts/", want: Remapping{Context: "lib/foo", Prefix: "@oz/", Target: "lib/oz/contracts/"}, gambit: "lib/foo:@oz=lib/oz/contracts/"},
+		{line: "hardhat/console.sol=lib/forge-std/src/console.sol", want: Remapping{Prefix: "hardhat/console.sol", Target: "lib/forge-std/src/console.sol"}, gambit: "hardhat/console.sol=lib/forge-std/src/console.sol"},
+		{line: "  solmate/ = lib/solmate/src/ ", want: Remapping{Prefix: "solmate/", Target: "lib/solmate/src/"}, gambit: "solmate=lib/solmate/src/"},
+		{line: "no-equals-sign", wantErr: true},
+		{line: "=lib/oz/", wantErr: true},
+		{line: "@oz/=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRemapping(tt.line)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRemapping(%q): expected an error, got %+v", tt.line, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemapping(%q): unexpected error: %v", tt.line, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRemapping(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+		if got.String() != tt.gambit {
+			t.Errorf("ParseRemapping(%q).String() = %q, want %q", tt.line, got.String(), tt.gambit)
+		}
+	}
+}
+
+func TestRemappingKeyIgnoresTrailingSlash(t *testing.T) {
+	a, _ := ParseRemapping("@oz/=lib/a/")
+	b, _ := ParseRemapping("@oz=lib/b")
+	c, _ := ParseRemapping("lib/x:@oz/=lib/c/")
+	if a.Key() != b.Key() {
+		t.Fatalf("expected %q and %q to share a key", a, b)
+	}
+	if a.Key() == c.Key() {
+		t.Fatalf("expected context-scoped %q to have its own key", c)
+	}
+}
+
+func TestPrefixRemappingPaths(t *testing.T) {
+	tests := map[string]string{
+		"@oz/=contracts/":         "@oz/=lib/oz/contracts/",
+		"src:@oz/=contracts/":     "lib/oz/src:@oz/=lib/oz/contracts/",
+		"@abs/=/usr/share/solid/": "@abs/=/usr/share/solid/",
+	}
+	for line, want := range tests {
+		if got := prefixRemappingPaths(line, "lib/oz"); got != want {
+			t.Errorf("prefixRemappingPaths(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestImports(t *testing.T) {
+	content := `// SPDX-License-Identifier: MIT
+import "./A.sol";
+import {B, C} from "../src/B.sol";
+import * as D from 'src/D.sol';
+import "@openzeppelin/contracts/token/ERC20/ERC20.sol" as OZ;
+import {
+    E
+} from "lib/E.sol";
+
+contract Vault {}
+`
+	want := []string{"./A.sol", "../src/B.sol", "src/D.sol", "@openzeppelin/contracts/token/ERC20/ERC20.sol", "lib/E.sol"}
+	if got := Imports(content); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := Imports("contract Vault {}"); len(got) != 0 {
+		t.Fatalf("expected no imports, got %v", got)
+	}
+}
@@ -0,0 +1,365 @@
+package framework
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by 'foundry.toml' files into a flat
+// map keyed by the full dotted path of each key, e.g. "profile.default.src".
+// Supported values are strings, booleans, numbers, arrays and inline tables.
+// Dates and times are kept as strings. Every table header gets a key, with an
+// empty table as its value unless the table is set otherwise, so that empty
+// tables are defined too. Arrays of tables ('[[...]]') are skipped as
+// foundry's build settings don't use them.
+func parseTOML(content string) (map[string]any, error) {
+	p := &tomlParser{src: []rune(content), line: 1}
+	values := make(map[string]any)
+	table := ""
+	skipTable := false
+
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return values, nil
+		}
+
+		if p.peek() == '[' {
+			header, isArrayTable, err := p.parseTableHeader()
+			if err != nil {
+				return nil, err
+			}
+			table, skipTable = header, isArrayTable
+			if _, ok := values[table]; !ok && !skipTable {
+				values[table] = map[string]any{}
+			}
+			continue
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWhitespaceAndComments(false)
+		if !p.consume('=') {
+			return nil, p.errorf("expected '=' after key '%s'", key)
+		}
+		p.skipWhitespaceAndComments(false)
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if !skipTable {
+			fullKey := key
+			if table != "" {
+				fullKey = table + "." + key
+			}
+			values[fullKey] = value
+		}
+
+		p.skipWhitespaceAndComments(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("unexpected characters after the value of '%s'", key)
+		}
+	}
+}
+
+type tomlParser struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) peek() rune { return p.src[p.pos] }
+
+func (p *tomlParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *tomlParser) consume(r rune) bool {
+	if !p.eof() && p.peek() == r {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:min(p.pos+len(prefix), len(p.src))]), prefix)
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("toml line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipWhitespaceAndComments skips spaces, tabs, comments and, if newlines is
+// true, line breaks.
+func (p *tomlParser) skipWhitespaceAndComments(newlines bool) {
+	for !p.eof() {
+		switch r := p.peek(); {
+		case r == ' ' || r == '\t' || r == '\r':
+			p.next()
+		case r == '\n' && newlines:
+			p.next()
+		case r == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) parseTableHeader() (string, bool, error) {
+	p.next() // '['
+	isArrayTable := p.consume('[')
+
+	p.skipWhitespaceAndComments(false)
+	key, err := p.parseKey()
+	if err != nil {
+		return "", false, err
+	}
+	p.skipWhitespaceAndComments(false)
+
+	if !p.consume(']') || (isArrayTable && !p.consume(']')) {
+		return "", false, p.errorf("unterminated table header '%s'", key)
+	}
+	return key, isArrayTable, nil
+}
+
+// parseKey parses bare, quoted and dotted keys and returns them joined with dots.
+func (p *tomlParser) parseKey() (string, error) {
+	var parts []string
+	for {
+		p.skipWhitespaceAndComments(false)
+		if p.eof() {
+			return "", p.errorf("expected a key")
+		}
+
+		var part string
+		switch p.peek() {
+		case '"', '\'':
+			s, err := p.parseString()
+			if err != nil {
+				return "", err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.next()
+			}
+			if start == p.pos {
+				return "", p.errorf("invalid character '%c' in key", p.peek())
+			}
+			part = string(p.src[start:p.pos])
+		}
+		parts = append(parts, part)
+
+		p.skipWhitespaceAndComments(false)
+		if !p.consume('.') {
+			return strings.Join(parts, "."), nil
+		}
+	}
+}
+
+func isBareKeyChar(r rune) bool {
+	return r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '[':
+		return p.parseArray()
+	case r == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true"):
+		p.pos += len("true")
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += len("false")
+		return false, nil
+	default:
+		return p.parseNumber()
+	}
+}
+
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.next()
+
+	// Multi-line strings: """...""" and '''...'''
+	if p.hasPrefix(string([]rune{quote, quote})) {
+		p.pos += 2
+		closing := string([]rune{quote, quote, quote})
+		// A newline right after the opening delimiter is trimmed.
+		p.consume('\n')
+		var sb strings.Builder
+		for !p.eof() {
+			if p.hasPrefix(closing) {
+				p.pos += 3
+				return sb.String(), nil
+			}
+			sb.WriteRune(p.next())
+		}
+		return "", p.errorf("unterminated multi-line string")
+	}
+
+	var sb strings.Builder
+	for !p.eof() {
+		r := p.next()
+		switch {
+		case r == quote:
+			return sb.String(), nil
+		case r == '\n':
+			return "", p.errorf("newline in string")
+		case r == '\\' && quote == '"':
+			if p.eof() {
+				return "", p.errorf("unterminated escape sequence")
+			}
+			switch e := p.next(); e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '"', '\\':
+				sb.WriteRune(e)
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(e)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.next() // '['
+	values := []any{}
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.consume(']') {
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipWhitespaceAndComments(true)
+		if p.consume(',') {
+			continue
+		}
+		if !p.consume(']') {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+		return values, nil
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.next() // '{'
+	table := make(map[string]any)
+	for {
+		p.skipWhitespaceAndComments(false)
+		if p.consume('}') {
+			return table, nil
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWhitespaceAndComments(false)
+		if !p.consume('=') {
+			return nil, p.errorf("expected '=' after key '%s'", key)
+		}
+		p.skipWhitespaceAndComments(false)
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		table[key] = value
+
+		p.skipWhitespaceAndComments(false)
+		if p.consume(',') {
+			continue
+		}
+		if !p.consume('}') {
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+		return table, nil
+	}
+}
+
+// parseNumber parses integers and floats, and the dates and times which are
+// returned as written.
+func (p *tomlParser) parseNumber() (any, error) {
+	start := p.pos
+	p.skipBareValue()
+	// The date and time of an offset date-time may be separated by a space.
+	if localDateRegex.MatchString(string(p.src[start:p.pos])) && p.hasPrefix(" ") &&
+		p.pos+3 < len(p.src) && isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		p.next()
+		p.skipBareValue()
+	}
+
+	written := string(p.src[start:p.pos])
+	if written == "" {
+		return nil, p.errorf("expected a value")
+	}
+	if dateTimeRegex.MatchString(written) {
+		return written, nil
+	}
+
+	raw := strings.ReplaceAll(written, "_", "")
+	if i, err := strconv.ParseInt(raw, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f, nil
+	}
+	return nil, p.errorf("unsupported value '%s'", raw)
+}
+
+var (
+	// e.g. "1979-05-27"
+	localDateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	// e.g. "1979-05-27T07:32:00Z", "1979-05-27 07:32:00.5-07:00" and "07:32:00"
+	dateTimeRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}:\d{2}(\.\d+)?)$`)
+)
+
+// skipBareValue skips to the end of an unquoted value.
+func (p *tomlParser) skipBareValue() {
+	for !p.eof() {
+		r := p.peek()
+		if r == ',' || r == ']' || r == '}' || r == '#' || r == '\n' || r == ' ' || r == '\t' || r == '\r' {
+			return
+		}
+		p.next()
+	}
+}
+
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
@@ -0,0 +1,166 @@
+// Package importer converts the output of other Solidity mutation tools into
+// Gambit's output layout: '<outdir>/gambit_results.json' and
+// '<outdir>/mutants/<id>/<path>', with Gambit's marker comment above every
+// mutated line. Everything downstream of the mutant generation (slaying, LLM
+// analysis and reports) then works unchanged.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// Mutant is a mutant read from another tool's output.
+type Mutant struct {
+	ID       string // ID or file name in the tool's output, used in messages.
+	Operator string // Operator name, Gambit's where there is an equivalent e.g. "BinaryOpMutation".
+	File     string // Original file, absolute or relative to the project root.
+	Content  string // Mutated source of the file.
+}
+
+// Options configure an import.
+type Options struct {
+	// SourceDir is searched for the original files of tools that only record
+	// the file name, e.g. './src'.
+	SourceDir string
+	// Log receives the progress messages. Defaults to os.Stdout.
+	Log io.Writer
+}
+
+// Importer reads the mutants of a single tool.
+type Importer interface {
+	// Name is the human readable name of the tool e.g. "slither-mutate".
+	Name() string
+
+	// Load reads the mutants from the tool's output at path.
+	Load(path string, opts Options) ([]Mutant, error)
+}
+
+// importers by the name used on the command line.
+var importers = map[string]Importer{
+	"slither": slitherImporter{},
+	"sumo":    sumoImporter{},
+	"vertigo": vertigoImporter{},
+}
+
+// Names returns the supported tool names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Import reads the mutants of the tool from path and writes them to outdir,
+// replacing its previous mutants. Mutants that don't change their file or
+// duplicate another mutant are skipped.
+func Import(tool, path, outdir string, opts Options) ([]mutator.Result, error) {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.SourceDir == "" {
+		opts.SourceDir = "."
+	}
+	importer, ok := importers[strings.ToLower(tool)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown tool '%s', supported tools are: %s.", tool, strings.Join(Names(), ", "))
+	}
+
+	loaded, err := importer.Load(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("No mutants found in the %s output at '%s'.", importer.Name(), path)
+	}
+
+	originals := make(map[string]string)
+	seen := make(map[string]bool)
+	var mutants []mutator.Mutant
+	for _, m := range loaded {
+		file, err := projectPath(m.File)
+		if err != nil {
+			return nil, err
+		}
+
+		original, ok := originals[file]
+		if !ok {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read the original file of mutant %s: %w", m.ID, err)
+			}
+			original = string(content)
+			originals[file] = original
+		}
+
+		mutation, changed := mutator.Compare(original, m.Content)
+		if !changed {
+			fmt.Fprintf(opts.Log, "\033[33m[Warning] Mutant %s doesn't change %s, it's skipped.\033[0m\n", m.ID, file)
+			continue
+		}
+		if seen[file+"\x00"+m.Content] {
+			continue
+		}
+		seen[file+"\x00"+m.Content] = true
+
+		mutation.Operator = m.Operator
+		mutants = append(mutants, mutator.Apply(file, original, mutation))
+	}
+
+	sort.SliceStable(mutants, func(i, j int) bool {
+		if mutants[i].File != mutants[j].File {
+			return mutants[i].File < mutants[j].File
+		}
+		return mutants[i].Start < mutants[j].Start
+	})
+
+	fmt.Fprintf(opts.Log, "[Info] Imported %d of %d %s mutants across %d files.\n", len(mutants), len(loaded), importer.Name(), len(originals))
+	return mutator.Write(outdir, mutants)
+}
+
+// projectPath returns the path of a file relative to the project root, which
+// is the working directory.
+func projectPath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path)), nil
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	// Compare the real paths, the root might be behind a symlink e.g. /tmp on macOS.
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("The mutated file '%s' is outside of the project at '%s', run the import from the project root.", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// operatorName maps a tool's operator code to Gambit's operator name, or to
+// '<code>Mutation' if Gambit has no equivalent. The 'Mutation' suffix keeps
+// the marker comment recognizable.
+func operatorName(equivalents map[string]string, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if name, ok := equivalents[code]; ok {
+		return name
+	}
+	if code == "" {
+		return "UnknownMutation"
+	}
+	return code + "Mutation"
+}
@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+const vaultSource = `pragma solidity ^0.8.0;
+
+contract Vault {
+    uint256 public total;
+
+    function deposit(uint256 amount) external {
+        require(amount > 0);
+        total += amount;
+    }
+}
+`
+
+// The same regex as the LLM context extraction.
+var mutationCommentRegex = regexp.MustCompile(`^\s*///.*Mutation\((.*?)\).*$`)
+
+// setupProject creates a project with src/Vault.sol in a temporary directory
+// and makes it the working directory.
+func setupProject(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	writeFile(t, "src/Vault.sol", vaultSource)
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkResults verifies the imported layout against the expected
+// descriptions and mutated lines, in order.
+func checkResults(t *testing.T, results []mutator.Result, descriptions, lines []string) {
+	t.Helper()
+
+	if len(results) != len(descriptions) {
+		t.Fatalf("expected %d mutants, got %d: %+v", len(descriptions), len(results), results)
+	}
+
+	content, err := os.ReadFile(filepath.Join("gambit_out", "gambit_results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written []mutator.Result
+	if err := json.Unmarshal(content, &written); err != nil || len(written) != len(results) {
+		t.Fatalf("unexpected gambit_results.json (%v): %s", err, content)
+	}
+
+	for i, result := range results {
+		if result.Description != descriptions[i] || result.Original != "src/Vault.sol" || result.Name != "mutants/"+result.ID+"/src/Vault.sol" {
+			t.Errorf("unexpected result %+v", result)
+		}
+
+		mutant, err := os.ReadFile(filepath.Join("gambit_out", result.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutantLines := strings.Split(string(mutant), "\n")
+		marker := -1
+		for j, line := range mutantLines {
+			if mutationCommentRegex.MatchString(line) {
+				marker = j
+				break
+			}
+		}
+		if marker < 0 || marker+1 >= len(mutantLines) {
+			t.Fatalf("mutant %s has no marker:\n%s", result.ID, mutant)
+		}
+		if !strings.Contains(mutantLines[marker], result.Description+"(") {
+			t.Errorf("marker of mutant %s doesn't name %s: %s", result.ID, result.Description, mutantLines[marker])
+		}
+		if got := strings.TrimSpace(mutantLines[marker+1]); got != lines[i] {
+			t.Errorf("mutant %s: got mutated line %q, want %q", result.ID, got, lines[i])
+		}
+	}
+}
+
+func TestImportSlither(t *testing.T) {
+	setupProject(t)
+	writeFile(t, "mutation_campaign/Vault/Vault_ROR_0.sol", strings.Replace(vaultSource, "amount > 0", "amount >= 0", 1))
+	writeFile(t, "mutation_campaign/Vault/Vault_RR_1.sol", strings.Replace(vaultSource, "total += amount;", "revert();", 1))
+	writeFile(t, "mutation_campaign/Vault/Vault_AOR_2.sol", vaultSource)
+
+	var log strings.Builder
+	results, err := Import("slither", "mutation_campaign", "gambit_out", Options{SourceDir: "src", Log: &log})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkResults(t, results,
+		[]string{mutator.BinaryOp, "RRMutation"},
+		[]string{"require(amount >= 0);", "revert();"})
+	if !strings.Contains(log.String(), "Vault_AOR_2.sol doesn't change") {
+		t.Errorf("expected a warning about the unchanged mutant, got:\n%s", log.String())
+	}
+}
+
+func TestImportSlitherMissingOriginal(t *testing.T) {
+	setupProject(t)
+	writeFile(t, "mutation_campaign/Token/Token_ROR_0.sol", vaultSource)
+
+	_, err := Import("slither", "mutation_campaign", "gambit_out", Options{SourceDir: "src", Log: &strings.Builder{}})
+	if err == nil || !strings.Contains(err.Error(), "Token.sol") {
+		t.Fatalf("expected an error about the missing original, got %v", err)
+	}
+}
+
+func TestImportSumo(t *testing.T) {
+	dir := setupProject(t)
+	start := strings.Index(vaultSource, "+=")
+	report := map[string][]map[string]any{
+		filepath.Join(dir, "src", "Vault.sol"): {
+			{"id": "m1", "file": filepath.Join(dir, "src", "Vault.sol"), "start": start, "end": start + 2, "replace": "-=", "operator": "AOR", "status": "live"},
+			{"id": "m2", "file": filepath.Join(dir, "src", "Vault.sol"), "start": start, "end": start + 2, "replace": "*=", "operator": "AOR", "status": "stillborn"},
+			{"id": "m3", "file": "src/Vault.sol", "start": strings.Index(vaultSource, "external"), "end": strings.Index(vaultSource, "external") + 8, "replace": "public", "operator": "FVR", "status": "killed"},
+		},
+	}
+	content, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "sumo/results/mutations.json", string(content))
+
+	var log strings.Builder
+	results, err := Import("sumo", ".", "gambit_out", Options{Log: &log})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkResults(t, results,
+		[]string{"FVRMutation", mutator.BinaryOp},
+		[]string{"function deposit(uint256 amount) public {", "total -= amount;"})
+	if !strings.Contains(log.String(), "Skipped 1 stillborn") {
+		t.Errorf("expected the stillborn mutant to be skipped, got:\n%s", log.String())
+	}
+}
+
+func TestImportVertigo(t *testing.T) {
+	dir := setupProject(t)
+	file := filepath.Join(dir, "src", "Vault.sol")
+	writeFile(t, "report.txt", `Mutation testing report:
+Number of mutations:    3
+Killed:                 1 / 3
+
+Mutations:
+Mutation:
+    File: `+file+`
+    Line nr: 8
+    Result: Lived
+    Original line:
+             total += amount;
+
+    Mutated line:
+             total -= amount;
+
+Mutation:
+    File: `+file+`
+    Line nr: 7
+    Result: Killed
+    Original line:
+             require(amount > 0);
+
+    Mutated line:
+             require(amount < 0);
+
+Mutation:
+    File: `+file+`
+    Line nr: 7
+    Result: Error
+    Original line:
+             require(amount > 0);
+
+    Mutated line:
+             require(amount => 0);
+`)
+
+	results, err := Import("vertigo", "report.txt", "gambit_out", Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkResults(t, results,
+		[]string{mutator.BinaryOp, mutator.BinaryOp},
+		[]string{"require(amount < 0);", "total -= amount;"})
+}
+
+func TestVertigoOperator(t *testing.T) {
+	for _, tt := range []struct {
+		original, mutated, want string
+	}{
+		{"a = b + c;", "a = b - c;", mutator.BinaryOp},
+		{"i++;", "i--;", mutator.UnaryOperator},
+		{"function f() onlyOwner {", "function f() {", "VertigoMutation"},
+	} {
+		if got := vertigoOperator(tt.original, tt.mutated); got != tt.want {
+			t.Errorf("vertigoOperator(%q, %q) = %s, want %s", tt.original, tt.mutated, got, tt.want)
+		}
+	}
+}
+
+func TestImportUnknownTool(t *testing.T) {
+	_, err := Import("mutest", ".", "gambit_out", Options{Log: &strings.Builder{}})
+	if err == nil || !strings.Contains(err.Error(), "slither, sumo, vertigo") {
+		t.Fatalf("expected an error listing the tools, got %v", err)
+	}
+}
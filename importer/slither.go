@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// slitherImporter reads the mutant files that slither-mutate writes to its
+// output directory ('mutation_campaign' by default), named
+// '<file stem>_<operator>_<n>.sol'. They are complete copies of the mutated
+// file, so the original is looked up by its stem in the source directory.
+type slitherImporter struct{}
+
+func (slitherImporter) Name() string { return "slither-mutate" }
+
+var slitherMutantName = regexp.MustCompile(`^(.+)_([A-Z]+)_(\d+)\.sol$`)
+
+// slitherEquivalents maps slither-mutate's operators to Gambit's.
+var slitherEquivalents = map[string]string{
+	"AOR":  mutator.BinaryOp, // Arithmetic operator replacement.
+	"BOR":  mutator.BinaryOp, // Bitwise operator replacement.
+	"ROR":  mutator.BinaryOp, // Relational operator replacement.
+	"LOR":  mutator.BinaryOp, // Logical operator replacement.
+	"ASOR": mutator.BinaryOp, // Assignment operator replacement e.g. '+=' to '-='.
+	"UOR":  mutator.UnaryOperator,
+	"MIA":  mutator.IfStatement, // 'if' conditions replaced with true or false.
+	"MVIV": mutator.Assignment,  // Variable initialization replaced with a value.
+	"MVIE": mutator.Assignment,  // Variable initialization replaced with an expression.
+	"LIR":  mutator.Assignment,  // Literal integer replacement.
+	"CR":   mutator.DeleteExpression,
+}
+
+func (slitherImporter) Load(path string, opts Options) ([]Mutant, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the slither-mutate output: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Expected the slither-mutate output directory (e.g. 'mutation_campaign'), got the file '%s'.", path)
+	}
+
+	originals, err := solidityFilesByStem(opts.SourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var mutants []Mutant
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		match := slitherMutantName.FindStringSubmatch(d.Name())
+		if d.IsDir() || match == nil {
+			return nil
+		}
+
+		stem, operator := match[1], match[2]
+		candidates := originals[stem]
+		switch len(candidates) {
+		case 0:
+			return fmt.Errorf("Couldn't find '%s.sol', the original of mutant '%s', in '%s'. Use --contracts-path to point at the mutated sources.", stem, d.Name(), opts.SourceDir)
+		case 1:
+		default:
+			return fmt.Errorf("The original of mutant '%s' is ambiguous, it could be any of: %s.", d.Name(), strings.Join(candidates, ", "))
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Failed to read mutant '%s': %w", file, err)
+		}
+		mutants = append(mutants, Mutant{
+			ID:       d.Name(),
+			Operator: operatorName(slitherEquivalents, operator),
+			File:     candidates[0],
+			Content:  string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mutants, nil
+}
+
+// solidityFilesByStem maps the stems of the Solidity files in dir, e.g.
+// 'Vault' for 'src/Vault.sol', to their paths.
+func solidityFilesByStem(dir string) (map[string][]string, error) {
+	files := make(map[string][]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".sol") {
+			return nil
+		}
+		// slither-mutate names the mutants after the part of the name before the first dot.
+		stem, _, _ := strings.Cut(d.Name(), ".")
+		files[stem] = append(files[stem], path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the sources in '%s': %w", dir, err)
+	}
+	for _, paths := range files {
+		sort.Strings(paths)
+	}
+	return files, nil
+}
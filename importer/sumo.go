@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// sumoImporter reads SuMo's mutations report ('sumo/results/mutations.json').
+// Every mutation records the byte range of the original file it replaces, so
+// the mutants are rebuilt from the current sources.
+type sumoImporter struct{}
+
+func (sumoImporter) Name() string { return "SuMo" }
+
+// sumoMutation is a mutation in SuMo's report.
+type sumoMutation struct {
+	ID       string `json:"id"`
+	Hash     string `json:"hash"`
+	File     string `json:"file"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Replace  string `json:"replace"`
+	Operator string `json:"operator"`
+	Status   string `json:"status"`
+}
+
+// sumoEquivalents maps SuMo's operators to Gambit's.
+var sumoEquivalents = map[string]string{
+	"BOR":  mutator.BinaryOp, // Binary operator replacement.
+	"AOR":  mutator.BinaryOp, // Assignment operator replacement e.g. '+=' to '-='.
+	"UORD": mutator.UnaryOperator,
+	"CSC":  mutator.IfStatement, // Conditional statement change.
+	"RSD":  mutator.DeleteExpression,
+	"EED":  mutator.DeleteExpression, // Event emission deletion.
+}
+
+// sumoSkipped are the statuses of mutants that can't be slain: stillborn
+// mutants don't compile, equivalent and redundant ones were ruled out by SuMo.
+var sumoSkipped = map[string]bool{
+	"stillborn":  true,
+	"equivalent": true,
+	"redundant":  true,
+}
+
+func (sumoImporter) Load(path string, opts Options) ([]Mutant, error) {
+	report, err := sumoReportPath(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(report)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the SuMo report: %w", err)
+	}
+
+	// The report is either a list of mutations or the mutations grouped by file.
+	var mutations []sumoMutation
+	if err := json.Unmarshal(content, &mutations); err != nil {
+		var byFile map[string][]sumoMutation
+		if err := json.Unmarshal(content, &byFile); err != nil {
+			return nil, fmt.Errorf("Failed to parse the SuMo report '%s': %w", report, err)
+		}
+		files := make([]string, 0, len(byFile))
+		for file := range byFile {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			mutations = append(mutations, byFile[file]...)
+		}
+	}
+
+	originals := make(map[string]string)
+	var mutants []Mutant
+	skipped := 0
+	for i, m := range mutations {
+		id := m.ID
+		if id == "" {
+			id = m.Hash
+		}
+		if id == "" {
+			id = fmt.Sprintf("#%d", i+1)
+		}
+		if sumoSkipped[strings.ToLower(m.Status)] {
+			skipped++
+			continue
+		}
+		if m.File == "" {
+			return nil, fmt.Errorf("SuMo mutation %s has no 'file'.", id)
+		}
+
+		original, ok := originals[m.File]
+		if !ok {
+			source, err := os.ReadFile(m.File)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read the original file of SuMo mutation %s: %w", id, err)
+			}
+			original = string(source)
+			originals[m.File] = original
+		}
+		if m.Start < 0 || m.End < m.Start || m.End > len(original) {
+			return nil, fmt.Errorf("SuMo mutation %s replaces %d:%d which is outside of '%s', was the file changed after the mutants were generated?", id, m.Start, m.End, m.File)
+		}
+
+		mutants = append(mutants, Mutant{
+			ID:       id,
+			Operator: operatorName(sumoEquivalents, m.Operator),
+			File:     m.File,
+			Content:  original[:m.Start] + m.Replace + original[m.End:],
+		})
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(opts.Log, "[Info] Skipped %d stillborn, equivalent or redundant SuMo mutants.\n", skipped)
+	}
+	return mutants, nil
+}
+
+// sumoReportPath accepts the report itself or a directory that contains it,
+// such as the project root, 'sumo' or 'sumo/results'.
+func sumoReportPath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read the SuMo output: %w", err)
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	for _, candidate := range []string{
+		"mutations.json",
+		filepath.Join("results", "mutations.json"),
+		filepath.Join("sumo", "results", "mutations.json"),
+	} {
+		report := filepath.Join(path, candidate)
+		if _, err := os.Stat(report); err == nil {
+			return report, nil
+		}
+	}
+	return "", fmt.Errorf("Couldn't find SuMo's 'mutations.json' in '%s'.", path)
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// vertigoImporter reads the report of vertigo-rs ('vertigo run --output'),
+// which lists every mutation as:
+//
+//	Mutation:
+//	    File: /project/contracts/Vault.sol
+//	    Line nr: 42
+//	    Result: Lived
+//	    Original line:
+//	             balance -= amount;
+//
+//	    Mutated line:
+//	             balance += amount;
+//
+// The report has no operator names, they are inferred from the change.
+type vertigoImporter struct{}
+
+func (vertigoImporter) Name() string { return "vertigo-rs" }
+
+// vertigoMutation is a mutation block of the report.
+type vertigoMutation struct {
+	file     string
+	line     int
+	result   string
+	original string
+	mutated  string
+}
+
+func (vertigoImporter) Load(path string, opts Options) ([]Mutant, error) {
+	report, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the vertigo-rs report: %w", err)
+	}
+	defer report.Close()
+
+	var mutations []vertigoMutation
+	var current *vertigoMutation
+	var field *string // The code field whose line comes next.
+	scanner := bufio.NewScanner(report)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "Mutation:":
+			mutations = append(mutations, vertigoMutation{})
+			current = &mutations[len(mutations)-1]
+			field = nil
+		case current == nil:
+			// The summary before the first mutation.
+		case strings.HasPrefix(line, "File:"):
+			current.file = strings.TrimSpace(strings.TrimPrefix(line, "File:"))
+		case strings.HasPrefix(line, "Line nr:"):
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Line nr:")))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid line number on line %d of the vertigo-rs report: %w", lineNumber, err)
+			}
+			current.line = n
+		case strings.HasPrefix(line, "Result:"):
+			current.result = strings.TrimSpace(strings.TrimPrefix(line, "Result:"))
+		case line == "Original line:":
+			field = &current.original
+		case line == "Mutated line:":
+			field = &current.mutated
+		case field != nil && line != "":
+			*field = line
+			field = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read the vertigo-rs report: %w", err)
+	}
+
+	originals := make(map[string][]string)
+	var mutants []Mutant
+	skipped := 0
+	for i, m := range mutations {
+		id := fmt.Sprintf("#%d (%s:%d)", i+1, m.file, m.line)
+		// Errored mutants didn't compile or broke the test run.
+		if strings.EqualFold(m.result, "error") {
+			skipped++
+			continue
+		}
+		if m.file == "" || m.line == 0 {
+			return nil, fmt.Errorf("vertigo-rs mutation #%d has no file or line number.", i+1)
+		}
+
+		lines, ok := originals[m.file]
+		if !ok {
+			source, err := os.ReadFile(m.file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read the original file of vertigo-rs mutation %s: %w", id, err)
+			}
+			lines = strings.Split(string(source), "\n")
+			originals[m.file] = lines
+		}
+		if m.line > len(lines) || strings.TrimSpace(lines[m.line-1]) != m.original {
+			return nil, fmt.Errorf("Line %d of '%s' doesn't match the vertigo-rs report, was the file changed after the mutants were generated?", m.line, m.file)
+		}
+
+		original := lines[m.line-1]
+		indent := original[:len(original)-len(strings.TrimLeft(original, " \t"))]
+		mutated := make([]string, len(lines))
+		copy(mutated, lines)
+		mutated[m.line-1] = indent + m.mutated
+
+		mutants = append(mutants, Mutant{
+			ID:       id,
+			Operator: vertigoOperator(m.original, m.mutated),
+			File:     m.file,
+			Content:  strings.Join(mutated, "\n"),
+		})
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(opts.Log, "[Info] Skipped %d vertigo-rs mutants that errored.\n", skipped)
+	}
+	return mutants, nil
+}
+
+var binaryOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "**": true,
+	"<<": true, ">>": true, "&": true, "|": true, "^": true,
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"&&": true, "||": true,
+	"+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+}
+
+// vertigoOperator infers the operator of a line mutation: vertigo-rs swaps
+// binary operators, increments and decrements, and removes modifiers.
+func vertigoOperator(original, mutated string) string {
+	mutation, ok := mutator.Compare(original, mutated)
+	if !ok {
+		return "VertigoMutation"
+	}
+	from, to := strings.TrimSpace(mutation.Original), strings.TrimSpace(mutation.Replacement)
+	switch {
+	case binaryOperators[from] && binaryOperators[to]:
+		return mutator.BinaryOp
+	case withoutUnaryOperators(from) == withoutUnaryOperators(to):
+		return mutator.UnaryOperator
+	}
+	return "VertigoMutation"
+}
+
+func withoutUnaryOperators(code string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("+-!~", r) {
+			return -1
+		}
+		return r
+	}, code)
+}
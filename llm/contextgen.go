@@ -2,6 +2,7 @@ package llm
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/ChmielewskiKamil/checkmate/db"
+	"github.com/ChmielewskiKamil/checkmate/mutator"
 )
 
 // MutantJSONInfo represents the structure of an object in gambit_results.json
@@ -80,14 +82,15 @@ func generateMutationAnalysisContext(
 
 	ctx.MutatedFilePathUsed = filepath.Join(mutantsBaseDir, mutantId, gambitResultsJSON.Original)
 
-	file, err := os.Open(ctx.MutatedFilePathUsed)
+	// The mutant might be stored as its diff against the original, see
+	// --store-diffs.
+	content, err := mutator.ReadMutant(ctx.MutatedFilePathUsed, gambitResultsJSON.Original)
 	if err != nil {
 		return ctx, fmt.Errorf("[Error] Failed to open mutated file '%s': %w", ctx.MutatedFilePathUsed, err)
 	}
-	defer file.Close()
 
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
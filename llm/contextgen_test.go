@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+func TestGenerateContextOfDiffMutant(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	original := "contract Vault {\n    function f(uint a) external {\n        require(a > 1);\n    }\n}\n"
+	diff := "--- original\n+++ mutant\n@@ -1,5 +1,6 @@\n contract Vault {\n     function f(uint a) external {\n-        require(a > 1);\n+        /// BinaryOpMutation(`>` |==> `>=`) of: `require(a > 1);`\n+        require(a >= 1);\n     }\n }\n"
+	mutant := filepath.Join("gambit_out", "mutants", "1", "src", "Vault.sol")
+	for path, content := range map[string]string{
+		filepath.Join("src", "Vault.sol"): original,
+		mutant + mutator.DiffSuffix:       diff,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info := MutantJSONInfo{
+		Description: "BinaryOpMutation",
+		Diff:        diff,
+		ID:          "1",
+		Name:        "mutants/1/src/Vault.sol",
+		Original:    "src/Vault.sol",
+	}
+	ctx, err := generateMutationAnalysisContext("1", filepath.Join("gambit_out", "mutants"), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.MutatedFilePathUsed != mutant {
+		t.Errorf("expected the mutant path %s, got %s", mutant, ctx.MutatedFilePathUsed)
+	}
+	if ctx.MutationMarkerLine != 3 {
+		t.Errorf("expected the marker on line 3, got %d", ctx.MutationMarkerLine)
+	}
+	if !strings.Contains(ctx.MutationContext, "require(a >= 1);") {
+		t.Errorf("expected the mutated line in the context, got:\n%s", ctx.MutationContext)
+	}
+}
@@ -0,0 +1,89 @@
+package mutator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DiffSuffix is appended to the path of a mutant stored as its diff, e.g.
+// 'mutants/3/src/Vault.sol.diff' instead of 'mutants/3/src/Vault.sol'.
+const DiffSuffix = ".diff"
+
+// CompactMutants replaces the mutant files in outdir by their diffs from
+// gambit_results.json, which are a fraction of their size. A mutant is only
+// replaced if its diff applied to the original gives back the mutant file,
+// so the others stay full copies. It returns the number of replaced mutants.
+func CompactMutants(outdir string) (int, error) {
+	results, err := ReadResults(outdir)
+	if err != nil {
+		return 0, err
+	}
+
+	compacted := 0
+	originals := make(map[string]string)
+	for _, result := range results {
+		path := filepath.Join(outdir, result.Name)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue // Slain, excluded or already a diff.
+		} else if err != nil {
+			return compacted, fmt.Errorf("Failed to read mutant %s: %w", result.ID, err)
+		}
+
+		original, ok := originals[result.Original]
+		if !ok {
+			source, err := os.ReadFile(result.Original)
+			if err != nil {
+				return compacted, fmt.Errorf("Failed to read '%s': %w", result.Original, err)
+			}
+			original = string(source)
+			originals[result.Original] = original
+		}
+		if mutated, err := ApplyDiff(original, result.Diff); err != nil || mutated != string(content) {
+			continue
+		}
+
+		if err := os.WriteFile(path+DiffSuffix, []byte(result.Diff), 0o644); err != nil {
+			return compacted, fmt.Errorf("Failed to write the diff of mutant %s: %w", result.ID, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return compacted, fmt.Errorf("Failed to remove mutant %s: %w", result.ID, err)
+		}
+		compacted++
+	}
+	return compacted, nil
+}
+
+// ReadMutant returns the source of the mutant at path. A mutant stored as its
+// diff is applied to the original file in memory.
+func ReadMutant(path, original string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if !os.IsNotExist(err) {
+		return content, err
+	}
+
+	diff, err := os.ReadFile(path + DiffSuffix)
+	if err != nil {
+		return nil, err
+	}
+	source, err := os.ReadFile(original)
+	if err != nil {
+		return nil, err
+	}
+	mutated, err := ApplyDiff(string(source), string(diff))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply the diff of %s: %w", path, err)
+	}
+	return []byte(mutated), nil
+}
+
+// MutantExists reports whether the mutant at path is stored, either as a file
+// or as its diff.
+func MutantExists(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	_, err := os.Stat(path + DiffSuffix)
+	return err == nil
+}
@@ -0,0 +1,61 @@
+package mutator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompactMutants(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("Vault.sol", []byte(vaultSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := Generate([]Entry{{Filename: "Vault.sol", Mutations: []string{"require-mutation"}, SkipValidate: true}}, Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("gambit_out", results[0].Name)
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second mutant doesn't match its diff, so it stays a full copy.
+	other := filepath.Join("gambit_out", results[1].Name)
+	if err := os.WriteFile(other, []byte("contract Edited {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	compacted, err := CompactMutants("gambit_out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compacted != len(results)-1 {
+		t.Fatalf("compacted %d mutants, want %d", compacted, len(results)-1)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected the mutant file to be replaced by its diff")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("expected the edited mutant to be kept: %v", err)
+	}
+	if !MutantExists(path) || !MutantExists(other) || MutantExists(path+".missing") {
+		t.Fatal("unexpected MutantExists result")
+	}
+
+	got, err := ReadMutant(path, "Vault.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("got mutant:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Compacting again changes nothing.
+	if compacted, err := CompactMutants("gambit_out"); err != nil || compacted != 0 {
+		t.Fatalf("compacted %d mutants again, err: %v", compacted, err)
+	}
+}
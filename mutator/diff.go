@@ -0,0 +1,160 @@
+package mutator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the change.
+const diffContext = 3
+
+// unifiedDiff returns a single hunk unified diff between the original and
+// the mutated source, in the format of gambit_results.json. Mutations change
+// one contiguous region, so a single hunk is always enough.
+func unifiedDiff(original, mutated string) string {
+	a := strings.Split(original, "\n")
+	b := strings.Split(mutated, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	start := max(prefix-diffContext, 0)
+	endA := min(len(a)-suffix+diffContext, len(a))
+	endB := min(len(b)-suffix+diffContext, len(b))
+
+	var diff strings.Builder
+	diff.WriteString("--- original\n+++ mutant\n")
+	fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start+1, endA-start, start+1, endB-start)
+	for _, line := range a[start:prefix] {
+		diff.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		diff.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : endA] {
+		diff.WriteString(" " + line + "\n")
+	}
+
+	return diff.String()
+}
+
+// Compare returns the mutation that turns original into mutated, widened to
+// whitespace so that the marker shows whole tokens e.g. '>=' instead of '='.
+// It reports false if both are the same.
+func Compare(original, mutated string) (Mutation, bool) {
+	if original == mutated {
+		return Mutation{}, false
+	}
+
+	prefix := 0
+	for prefix < len(original) && prefix < len(mutated) && original[prefix] == mutated[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(original)-prefix && suffix < len(mutated)-prefix &&
+		original[len(original)-1-suffix] == mutated[len(mutated)-1-suffix] {
+		suffix++
+	}
+
+	for prefix > 0 && !isSpace(original[prefix-1]) {
+		prefix--
+	}
+	for suffix > 0 && !isSpace(original[len(original)-suffix]) {
+		suffix--
+	}
+
+	return Mutation{
+		Start:       prefix,
+		End:         len(original) - suffix,
+		Original:    original[prefix : len(original)-suffix],
+		Replacement: mutated[prefix : len(mutated)-suffix],
+	}, true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// ApplyDiff applies a unified diff in the format of gambit_results.json to
+// the original source and returns the mutated source.
+func ApplyDiff(original, diff string) (string, error) {
+	return applyDiff(original, diff, nil)
+}
+
+// Recover returns the mutation of a mutant from its diff against the original
+// source, without the marker comments. It reports false if the diff doesn't
+// change the code.
+func Recover(original string, result Result) (Mutation, bool, error) {
+	mutated, err := applyDiff(original, result.Diff, markerRegex.MatchString)
+	if err != nil {
+		return Mutation{}, false, fmt.Errorf("Failed to recover mutant %s: %w", result.ID, err)
+	}
+	mutation, ok := Compare(original, mutated)
+	mutation.Operator = result.Description
+	return mutation, ok, nil
+}
+
+// applyDiff applies the diff, leaving out the added lines for which skip
+// returns true.
+func applyDiff(original, diff string, skip func(line string) bool) (string, error) {
+	source := strings.Split(original, "\n")
+	var mutated []string
+	next := 0 // Index of the next line of the original to copy.
+	inHunk := false
+	for n, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if strings.HasPrefix(line, "@@") {
+			var start, count int
+			if _, err := fmt.Sscanf(line, "@@ -%d,%d", &start, &count); err != nil {
+				if _, err := fmt.Sscanf(line, "@@ -%d", &start); err != nil {
+					return "", fmt.Errorf("invalid hunk header on line %d of the diff: %q", n+1, line)
+				}
+			}
+			// An empty hunk starts after the given line.
+			if count == 0 && strings.HasPrefix(line, fmt.Sprintf("@@ -%d,0", start)) {
+				start++
+			}
+			if start-1 < next || start-1 > len(source) {
+				return "", fmt.Errorf("hunk on line %d of the diff is out of order or beyond the end of the file", n+1)
+			}
+			mutated = append(mutated, source[next:start-1]...)
+			next = start - 1
+			inHunk = true
+			continue
+		}
+		if !inHunk || line == "" || line[0] == '\\' {
+			continue // The '---'/'+++' headers and '\ No newline at end of file'.
+		}
+
+		text := line[1:]
+		switch line[0] {
+		case ' ', '-':
+			if next >= len(source) || source[next] != text {
+				return "", fmt.Errorf("line %d of the diff doesn't match the original, was the file changed after the mutants were generated?", n+1)
+			}
+			if line[0] == ' ' {
+				mutated = append(mutated, text)
+			}
+			next++
+		case '+':
+			if skip == nil || !skip(text) {
+				mutated = append(mutated, text)
+			}
+		default:
+			return "", fmt.Errorf("invalid line %d of the diff: %q", n+1, line)
+		}
+	}
+	if !inHunk {
+		return "", fmt.Errorf("the diff has no hunks")
+	}
+	mutated = append(mutated, source[next:]...)
+	return strings.Join(mutated, "\n"), nil
+}
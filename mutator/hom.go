@@ -0,0 +1,349 @@
+package mutator
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// HigherOrder is the operator of the mutants combining several first-order
+// mutants. Every mutated line keeps the marker of its own operator.
+const HigherOrder = "HigherOrderMutation"
+
+// markerRegex matches the marker comment above a mutated line.
+var markerRegex = regexp.MustCompile(`^\s*///.*Mutation\((.*?)\).*$`)
+
+// HigherOrderOptions configure the generation of higher-order mutants.
+type HigherOrderOptions struct {
+	Options
+
+	// Order is the number of first-order mutants combined into each mutant.
+	Order int
+	// Max is the maximum number of mutants generated.
+	Max int
+	// Survivors are the IDs of the first-order mutants that survived. The
+	// combinations with the most survivors come first.
+	Survivors map[string]bool
+	// Exclude are the IDs of the first-order mutants that must not be
+	// combined e.g. equivalent ones.
+	Exclude map[string]bool
+}
+
+// firstOrder is a first-order mutant with the mutation recovered from its diff.
+type firstOrder struct {
+	id       string
+	mutation Mutation
+	line     int // First and last line of the mutation, 0-indexed.
+	endLine  int
+	survived bool
+}
+
+// candidate is a combination of first-order mutants of the same function.
+type candidate struct {
+	file    string
+	members []firstOrder
+}
+
+// id is the composite ID of the mutant e.g. "3+7".
+func (c candidate) id() string {
+	ids := make([]string, len(c.members))
+	for i, m := range c.members {
+		ids[i] = m.id
+	}
+	return strings.Join(ids, "+")
+}
+
+// GenerateHigherOrder combines first-order mutants of the same function into
+// higher-order mutants and adds them to outdir, next to the first-order ones.
+// The mutations are recovered from the diffs of gambit_results.json, so slain
+// mutants whose directory was removed are combined too. A mutant gets the
+// IDs of its first-order mutants as its ID e.g. "3+7". Mutants are validated
+// with the solc of their entry unless skip_validate is set.
+func GenerateHigherOrder(outdir string, entries []Entry, opts HigherOrderOptions) ([]Result, error) {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.Compile == nil {
+		opts.Compile = solc.Compile
+	}
+	if opts.Order < 2 {
+		return nil, fmt.Errorf("Higher-order mutants combine at least 2 first-order mutants, got an order of %d.", opts.Order)
+	}
+
+	existing, err := ReadResults(outdir)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("No mutants found in '%s'. Generate the first-order mutants first.", filepath.Join(outdir, "gambit_results.json"))
+	}
+
+	// First-order mutants grouped by file, contract and function.
+	groups := make(map[string][]firstOrder)
+	var groupKeys []string
+	files := make(map[string]string) // Keyed by group.
+	sources := make(map[string]string)
+	units := make(map[string]*solidity.SourceUnit)
+	taken := make(map[string]bool)
+	for _, result := range existing {
+		taken[result.ID] = true
+		if result.Description == HigherOrder || opts.Exclude[result.ID] {
+			continue
+		}
+
+		source, ok := sources[result.Original]
+		if !ok {
+			content, err := os.ReadFile(result.Original)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read '%s': %w", result.Original, err)
+			}
+			source = string(content)
+			unit, err := solidity.Parse(source)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse '%s': %w", result.Original, err)
+			}
+			sources[result.Original] = source
+			units[result.Original] = unit
+		}
+		unit := units[result.Original]
+
+		mutation, ok, err := Recover(source, result)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		token := sort.Search(len(unit.Tokens), func(i int) bool { return unit.Tokens[i].End() > mutation.Start })
+		contract, function, inFunction := unit.FunctionAt(token)
+		if !inFunction {
+			continue
+		}
+
+		key := result.Original + ":" + function.Name
+		if contract != nil { // nil for free functions.
+			key = result.Original + ":" + contract.Name + "." + function.Name
+		}
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+			files[key] = result.Original
+		}
+		groups[key] = append(groups[key], firstOrder{
+			id:       result.ID,
+			mutation: mutation,
+			line:     strings.Count(source[:mutation.Start], "\n"),
+			endLine:  strings.Count(source[:mutation.End], "\n"),
+			survived: opts.Survivors[result.ID],
+		})
+	}
+
+	for _, key := range groupKeys {
+		members := groups[key]
+		sort.SliceStable(members, func(i, j int) bool { return members[i].mutation.Start < members[j].mutation.Start })
+	}
+
+	entryFor := func(file string) (Entry, bool) {
+		for _, entry := range entries {
+			if filepath.ToSlash(filepath.Clean(entry.Filename)) == file {
+				return entry, true
+			}
+		}
+		return Entry{}, false
+	}
+
+	var added []Result
+	var log strings.Builder
+	// Validate in batches per file, like the first-order mutants.
+	var batch []candidate
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		file := batch[0].file
+		source := sources[file]
+		var mutants []Mutant
+		members := make(map[string][]firstOrder) // Keyed by the mutant's content.
+		for _, c := range batch {
+			m := combineMutants(file, source, c.members)
+			if _, ok := members[m.Content]; ok {
+				continue
+			}
+			members[m.Content] = c.members
+			mutants = append(mutants, m)
+		}
+		batch = nil
+
+		if entry, ok := entryFor(file); ok && !entry.SkipValidate {
+			mutants, _ = validate(entry, file, source, mutants, opts.Options)
+		}
+
+		results, err := writeHigherOrder(outdir, source, mutants, members, &log)
+		if err != nil {
+			return err
+		}
+		added = append(added, results...)
+		return nil
+	}
+
+	tried := 0
+	for c := range higherOrderCandidates(groupKeys, groups, files, opts.Order) {
+		if taken[c.id()] {
+			continue // Generated by a previous run.
+		}
+		tried++
+		if len(batch) > 0 && batch[0].file != c.file {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batch = append(batch, c)
+		// Only as many candidates as mutants are missing, some may not compile.
+		if opts.Max > 0 && len(added)+len(batch) >= opts.Max {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if len(added) >= opts.Max {
+				break
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if err := writeResults(outdir, append(existing, added...)); err != nil {
+		return nil, err
+	}
+	if err := appendFile(filepath.Join(outdir, "mutants.log"), log.String()); err != nil {
+		return nil, fmt.Errorf("Failed to write mutants.log: %w", err)
+	}
+	fmt.Fprintf(opts.Log, "[Info] %d combinations of %d first-order mutants tried, %d higher-order mutants kept.\n", tried, opts.Order, len(added))
+	return added, nil
+}
+
+// higherOrderCandidates yields the combinations of k first-order mutants of
+// every group. Combinations of survivors come first, they are the most likely
+// to reveal test gaps. They are built as they are consumed, so that a limited
+// number of mutants doesn't need every combination of a large function.
+func higherOrderCandidates(keys []string, groups map[string][]firstOrder, files map[string]string, k int) iter.Seq[candidate] {
+	return func(yield func(candidate) bool) {
+		for survivors := k; survivors >= 0; survivors-- {
+			for _, key := range keys {
+				more := combine(groups[key], k, survivors, func(combination []firstOrder) bool {
+					return yield(candidate{file: files[key], members: append([]firstOrder{}, combination...)})
+				})
+				if !more {
+					return
+				}
+			}
+		}
+	}
+}
+
+// combine calls fn with every combination of k mutants, with exactly the
+// given number of survivors, that don't share a line. Mutations on the same
+// line would share a marker comment. It stops and returns false as soon as
+// fn returns false.
+func combine(mutants []firstOrder, k, survivors int, fn func([]firstOrder) bool) bool {
+	// left[i] is the number of survivors in mutants[i:].
+	left := make([]int, len(mutants)+1)
+	for i := len(mutants) - 1; i >= 0; i-- {
+		left[i] = left[i+1]
+		if mutants[i].survived {
+			left[i]++
+		}
+	}
+
+	combination := make([]firstOrder, 0, k)
+	var visit func(from, picked int) bool
+	visit = func(from, picked int) bool {
+		if len(combination) == k {
+			return fn(combination)
+		}
+		if left[from] < survivors-picked {
+			return true // Not enough survivors left.
+		}
+		for i := from; i < len(mutants); i++ {
+			if len(combination) > 0 && combination[len(combination)-1].endLine >= mutants[i].line {
+				continue
+			}
+			next := picked
+			if mutants[i].survived {
+				next++
+			}
+			// Too many survivors, or too few slots left for the missing ones.
+			if next > survivors || k-len(combination)-1 < survivors-next {
+				continue
+			}
+			combination = append(combination, mutants[i])
+			more := visit(i+1, next)
+			combination = combination[:len(combination)-1]
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+	return visit(0, 0)
+}
+
+// combineMutants applies the mutations, ordered by their position, each with
+// its own marker comment.
+func combineMutants(file, source string, members []firstOrder) Mutant {
+	content := source
+	// From the bottom up, so that the offsets of the mutations above stay valid.
+	for i := len(members) - 1; i >= 0; i-- {
+		content = Apply(file, content, members[i].mutation).Content
+	}
+	first := Apply(file, source, members[0].mutation)
+	first.Operator = HigherOrder
+	first.Content = content
+	first.Diff = unifiedDiff(source, content)
+	return first
+}
+
+// writeHigherOrder writes the mutant files under the composite IDs of their
+// first-order mutants. Every first-order mutation gets its own line in
+// mutants.log.
+func writeHigherOrder(outdir, source string, mutants []Mutant, members map[string][]firstOrder, log *strings.Builder) ([]Result, error) {
+	sourceRoot, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, m := range mutants {
+		id := candidate{members: members[m.Content]}.id()
+
+		name := filepath.ToSlash(filepath.Join("mutants", id, m.File))
+		path := filepath.Join(outdir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("Failed to create the mutant directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(m.Content), 0o644); err != nil {
+			return nil, fmt.Errorf("Failed to write mutant %s: %w", id, err)
+		}
+
+		results = append(results, Result{
+			Description: HigherOrder,
+			Diff:        m.Diff,
+			ID:          id,
+			Name:        name,
+			Original:    m.File,
+			SourceRoot:  sourceRoot,
+		})
+		for _, member := range members[m.Content] {
+			first := Apply(m.File, source, member.mutation)
+			fmt.Fprintf(log, "%s,%s,%s,%d:%d,%s,%s\n", id, first.Operator, m.File, first.Line, first.Col, singleLine(first.Original), singleLine(first.Replacement))
+		}
+	}
+	return results, nil
+}
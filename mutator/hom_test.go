@@ -0,0 +1,151 @@
+package mutator
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHigherOrder(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("Vault.sol", []byte(vaultSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{{Filename: "Vault.sol", Mutations: []string{"require-mutation", "if-cond-mutation", "unary-operator-mutation"}, SkipValidate: true}}
+	first, err := Generate(entries, Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The slain mutant's directory is gone, it's recovered from its diff.
+	if err := os.RemoveAll(filepath.Join("gambit_out", "mutants", "1")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := HigherOrderOptions{
+		Options:   Options{Log: &strings.Builder{}},
+		Order:     2,
+		Max:       2,
+		Survivors: map[string]bool{"3": true, "5": true},
+	}
+	results, err := GenerateHigherOrder("gambit_out", entries, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 mutants, got %d", len(results))
+	}
+
+	// The combination of both survivors comes first.
+	want := "3+5"
+	if results[0].ID != want || results[0].Description != HigherOrder {
+		t.Fatalf("got mutant %s (%s), want %s", results[0].ID, results[0].Description, want)
+	}
+	if results[1].ID != "1+3" {
+		t.Fatalf("got mutant %s, want 1+3", results[1].ID)
+	}
+	mutant, err := os.ReadFile(filepath.Join("gambit_out", results[0].Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(mutant), "Mutation(") != 2 {
+		t.Fatalf("expected a marker per mutated line, got:\n%s", mutant)
+	}
+	if got, err := ApplyDiff(vaultSource, results[0].Diff); err != nil || got != string(mutant) {
+		t.Fatalf("applying the diff (%v) gives\n%s\nwant\n%s", err, got, mutant)
+	}
+
+	log, err := os.ReadFile(filepath.Join("gambit_out", "mutants.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(log), "\n"+want+",") != 2 {
+		t.Fatalf("expected a mutants.log line per first-order mutant, got:\n%s", log)
+	}
+
+	// Running again doesn't duplicate the mutants.
+	again, err := GenerateHigherOrder("gambit_out", entries, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range again {
+		if result.ID == results[0].ID || result.ID == results[1].ID {
+			t.Fatalf("mutant %s generated twice", result.ID)
+		}
+	}
+	all, err := ReadResults("gambit_out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(first)+len(results)+len(again) {
+		t.Fatalf("expected %d results, got %d", len(first)+len(results)+len(again), len(all))
+	}
+}
+
+func TestGenerateHigherOrderFreeFunction(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	source := `pragma solidity ^0.8.0;
+
+function clamp(uint256 a, uint256 b) pure returns (uint256) {
+    require(a > 0);
+    if (a > b) {
+        return b;
+    }
+    return a;
+}
+`
+	if err := os.WriteFile("Math.sol", []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{{Filename: "Math.sol", Mutations: []string{"require-mutation", "if-cond-mutation"}, SkipValidate: true}}
+	if _, err := Generate(entries, Options{Log: &strings.Builder{}}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := GenerateHigherOrder("gambit_out", entries, HigherOrderOptions{Options: Options{Log: &strings.Builder{}}, Order: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected the mutants of the free function to be combined")
+	}
+}
+
+func TestGenerateHigherOrderOrder(t *testing.T) {
+	if _, err := GenerateHigherOrder(t.TempDir(), nil, HigherOrderOptions{Order: 1}); err == nil {
+		t.Fatal("expected an error for an order below 2")
+	}
+}
+
+func TestHigherOrderCandidatesStopEarly(t *testing.T) {
+	// 40 mutants, one per line, of which the last two survived.
+	var members []firstOrder
+	for i := range 40 {
+		members = append(members, firstOrder{id: strconv.Itoa(i), line: i, endLine: i, survived: i >= 38})
+	}
+	groups := map[string][]firstOrder{"A.sol:f": members}
+	files := map[string]string{"A.sol:f": "A.sol"}
+
+	var got []string
+	for c := range higherOrderCandidates([]string{"A.sol:f"}, groups, files, 3) {
+		got = append(got, c.id())
+		if len(got) == 3 {
+			break
+		}
+	}
+	// Both survivors first, then the others.
+	want := []string{"0+38+39", "1+38+39", "2+38+39"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("got candidates %v, want %v", got, want)
+	}
+
+	count := 0
+	combine(members, 3, 1, func([]firstOrder) bool { count++; return true })
+	if want := 2 * (38 * 37 / 2); count != want {
+		t.Fatalf("got %d combinations with a single survivor, want %d", count, want)
+	}
+}
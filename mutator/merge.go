@@ -0,0 +1,141 @@
+package mutator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RemoveMutants removes the mutants of the given files, keyed by their slash
+// separated path, from outdir: their directories, results and mutants.log
+// lines. It returns the removed results.
+func RemoveMutants(outdir string, files map[string]bool) ([]Result, error) {
+	results, err := ReadResults(outdir)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept, removed []Result
+	for _, result := range results {
+		if !files[filepath.ToSlash(filepath.Clean(result.Original))] {
+			kept = append(kept, result)
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(outdir, "mutants", result.ID)); err != nil {
+			return nil, fmt.Errorf("Failed to remove mutant %s: %w", result.ID, err)
+		}
+		removed = append(removed, result)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := writeResults(outdir, kept); err != nil {
+		return nil, err
+	}
+	err = rewriteLog(filepath.Join(outdir, "mutants.log"), func(fields []string) []string {
+		if len(fields) > 2 && files[filepath.ToSlash(filepath.Clean(fields[2]))] {
+			return nil
+		}
+		return fields
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// MergeMutants moves the mutants generated in from into outdir, numbered
+// after the mutants already there, and removes from. It returns the results
+// of the moved mutants.
+func MergeMutants(outdir, from string) ([]Result, error) {
+	incoming, err := ReadResults(from)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ReadResults(outdir)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	for _, result := range existing {
+		if id, err := strconv.Atoi(result.ID); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+
+	ids := make(map[string]string) // Incoming ID to its new ID.
+	var added []Result
+	for _, result := range incoming {
+		id := strconv.Itoa(next)
+		next++
+		ids[result.ID] = id
+
+		source := filepath.Join(from, "mutants", result.ID)
+		target := filepath.Join(outdir, "mutants", id)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("Failed to create the mutants directory: %w", err)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return nil, fmt.Errorf("Failed to replace mutant %s: %w", id, err)
+		}
+		if err := os.Rename(source, target); err != nil {
+			return nil, fmt.Errorf("Failed to move mutant %s: %w", result.ID, err)
+		}
+
+		result.Name = filepath.ToSlash(filepath.Join("mutants", id, strings.TrimPrefix(result.Name, "mutants/"+result.ID+"/")))
+		result.ID = id
+		added = append(added, result)
+	}
+
+	if err := writeResults(outdir, append(existing, added...)); err != nil {
+		return nil, err
+	}
+	var log strings.Builder
+	content, err := os.ReadFile(filepath.Join(from, "mutants.log"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Failed to read mutants.log: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, ",")
+		if id, ok := ids[fields[0]]; ok {
+			fields[0] = id
+			log.WriteString(strings.Join(fields, ",") + "\n")
+		}
+	}
+	if err := appendFile(filepath.Join(outdir, "mutants.log"), log.String()); err != nil {
+		return nil, fmt.Errorf("Failed to write mutants.log: %w", err)
+	}
+
+	if err := os.RemoveAll(from); err != nil {
+		return nil, fmt.Errorf("Failed to remove '%s': %w", from, err)
+	}
+	return added, nil
+}
+
+// rewriteLog rewrites every line of mutants.log with fn, dropping the lines
+// for which it returns nil.
+func rewriteLog(path string, fn func(fields []string) []string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to read mutants.log: %w", err)
+	}
+
+	var log strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if fields := fn(strings.Split(line, ",")); fields != nil {
+			log.WriteString(strings.Join(fields, ",") + "\n")
+		}
+	}
+	if err := os.WriteFile(path, []byte(log.String()), 0o644); err != nil {
+		return fmt.Errorf("Failed to write mutants.log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package mutator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemoveAndMergeMutants(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	token := "pragma solidity ^0.8.0;\n\ncontract Token {\n    function f(uint a) external pure returns (uint) {\n        require(a > 1);\n        return a;\n    }\n}\n"
+	for file, content := range map[string]string{"Vault.sol": vaultSource, "Token.sol": token} {
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := []Entry{
+		{Filename: "Vault.sol", Mutations: []string{"require-mutation"}, SkipValidate: true},
+		{Filename: "Token.sol", Mutations: []string{"require-mutation"}, SkipValidate: true},
+	}
+	if _, err := Generate(entries, Options{Log: &strings.Builder{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := RemoveMutants("gambit_out", map[string]bool{"Vault.sol": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].ID != "1" || removed[1].ID != "2" {
+		t.Fatalf("unexpected removed mutants %+v", removed)
+	}
+	if _, err := os.Stat(filepath.Join("gambit_out", "mutants", "1")); !os.IsNotExist(err) {
+		t.Fatal("expected the directory of mutant 1 to be removed")
+	}
+
+	// Regenerate the mutants of Vault.sol next to the others.
+	entries[0].Outdir = "tmp"
+	if _, err := Generate(entries[:1], Options{Log: &strings.Builder{}}); err != nil {
+		t.Fatal(err)
+	}
+	added, err := MergeMutants("gambit_out", "tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 || added[0].ID != "5" || added[0].Name != "mutants/5/Vault.sol" || added[0].Original != "Vault.sol" {
+		t.Fatalf("unexpected merged mutants %+v", added)
+	}
+	if _, err := os.Stat(filepath.Join("gambit_out", "mutants", "5", "Vault.sol")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("tmp"); !os.IsNotExist(err) {
+		t.Fatal("expected the merged directory to be removed")
+	}
+
+	results, err := ReadResults("gambit_out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	if got := strings.Join(ids, ","); got != "3,4,5,6" {
+		t.Fatalf("got mutants %s, want 3,4,5,6", got)
+	}
+
+	log, err := os.ReadFile(filepath.Join("gambit_out", "mutants.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(log)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "3,RequireMutation,Token.sol,") || !strings.HasPrefix(lines[2], "5,RequireMutation,Vault.sol,") {
+		t.Fatalf("unexpected mutants.log:\n%s", log)
+	}
+}
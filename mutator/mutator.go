@@ -0,0 +1,360 @@
+// Package mutator is a pure Go mutant generator. It reads Gambit's config,
+// applies the same operator families as Gambit and writes its output layout:
+// '<outdir>/gambit_results.json', '<outdir>/mutants.log' and
+// '<outdir>/mutants/<id>/<path>', so everything downstream works with either.
+package mutator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// DefaultOutdir is Gambit's default output directory.
+const DefaultOutdir = "gambit_out"
+
+// Entry is an entry of Gambit's config, limited to the keys the native
+// mutator supports.
+type Entry struct {
+	Filename       string   `json:"filename"`
+	Mutations      []string `json:"mutations"`
+	Functions      []string `json:"functions"`
+	Contract       string   `json:"contract"`
+	NumMutants     int      `json:"num_mutants"`
+	RandomSeed     bool     `json:"random_seed"`
+	Seed           uint64   `json:"seed"`
+	Solc           string   `json:"solc"`
+	SolcRemappings []string `json:"solc_remappings"`
+	SolcBasePath   string   `json:"solc_base_path"`
+	SolcAllowPaths []string `json:"solc_allow_paths"`
+	SolcViaIR      bool     `json:"solc_via_ir"`
+	Outdir         string   `json:"outdir"`
+	SkipValidate   bool     `json:"skip_validate"`
+}
+
+// Result is an entry of gambit_results.json.
+type Result struct {
+	Description string `json:"description"`
+	Diff        string `json:"diff"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`     // e.g. "mutants/1/src/Vault.sol"
+	Original    string `json:"original"` // e.g. "src/Vault.sol"
+	SourceRoot  string `json:"sourceroot"`
+}
+
+// Options configure a run of the generator.
+type Options struct {
+	// Log receives the progress messages. Defaults to os.Stdout.
+	Log io.Writer
+	// Compile validates mutants. Defaults to solc.Compile.
+	Compile func(binary string, input solc.Input, opts solc.Options) (*solc.Output, error)
+}
+
+// LoadConfig reads the entries of a Gambit config file.
+func LoadConfig(path string) ([]Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the gambit config: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("Failed to parse the gambit config '%s': %w", path, err)
+	}
+	return entries, nil
+}
+
+// Mutant is a mutated copy of a source file before it gets its ID.
+type Mutant struct {
+	Mutation
+	File    string // e.g. "src/Vault.sol"
+	Line    int    // Line of the mutation in the original file.
+	Col     int
+	Content string // Mutated source, including the marker comment.
+	Diff    string
+}
+
+// Generate creates the mutants of all entries and writes them to the output
+// directory, replacing its previous content. Mutants are numbered from 1 in
+// the order of the entries.
+func Generate(entries []Entry, opts Options) ([]Result, error) {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.Compile == nil {
+		opts.Compile = solc.Compile
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("The gambit config has no entries, there is nothing to mutate.")
+	}
+
+	outdir := entries[0].Outdir
+	if outdir == "" {
+		outdir = DefaultOutdir
+	}
+	for _, entry := range entries[1:] {
+		if entry.Outdir != "" && filepath.Clean(entry.Outdir) != filepath.Clean(outdir) {
+			return nil, fmt.Errorf("All entries of the gambit config must use the same 'outdir', found '%s' and '%s'.", outdir, entry.Outdir)
+		}
+	}
+
+	var all []Mutant
+	for _, entry := range entries {
+		mutants, err := mutateEntry(entry, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, mutants...)
+	}
+
+	return Write(outdir, all)
+}
+
+// Write stores the mutants in Gambit's output layout under outdir, replacing
+// the previous mutants. Mutants are numbered from 1 in the given order.
+func Write(outdir string, mutants []Mutant) ([]Result, error) {
+	if err := os.RemoveAll(filepath.Join(outdir, "mutants")); err != nil {
+		return nil, fmt.Errorf("Failed to remove the previous mutants: %w", err)
+	}
+
+	results, log, err := writeMutants(outdir, mutants, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeResults(outdir, results); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(outdir, "mutants.log"), []byte(log), 0o644); err != nil {
+		return nil, fmt.Errorf("Failed to write mutants.log: %w", err)
+	}
+
+	return results, nil
+}
+
+// writeMutants writes the mutant files numbered from firstID and returns
+// their results and mutants.log lines.
+func writeMutants(outdir string, mutants []Mutant, firstID int) ([]Result, string, error) {
+	sourceRoot, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+
+	results := make([]Result, 0, len(mutants))
+	var log strings.Builder
+	for i, m := range mutants {
+		id := strconv.Itoa(firstID + i)
+		name := filepath.ToSlash(filepath.Join("mutants", id, m.File))
+
+		path := filepath.Join(outdir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, "", fmt.Errorf("Failed to create the mutant directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(m.Content), 0o644); err != nil {
+			return nil, "", fmt.Errorf("Failed to write mutant %s: %w", id, err)
+		}
+
+		results = append(results, Result{
+			Description: m.Operator,
+			Diff:        m.Diff,
+			ID:          id,
+			Name:        name,
+			Original:    m.File,
+			SourceRoot:  sourceRoot,
+		})
+		fmt.Fprintf(&log, "%s,%s,%s,%d:%d,%s,%s\n", id, m.Operator, m.File, m.Line, m.Col, singleLine(m.Original), singleLine(m.Replacement))
+	}
+
+	return results, log.String(), nil
+}
+
+// writeResults writes gambit_results.json to outdir.
+func writeResults(outdir string, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	// Like Gambit, keep '<', '>' and '&' of the code readable.
+	var resultsJSON bytes.Buffer
+	encoder := json.NewEncoder(&resultsJSON)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return fmt.Errorf("Failed to create '%s': %w", outdir, err)
+	}
+	if err := os.WriteFile(filepath.Join(outdir, "gambit_results.json"), resultsJSON.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("Failed to write gambit_results.json: %w", err)
+	}
+	return nil
+}
+
+// mutateEntry generates, validates and samples the mutants of a single file.
+func mutateEntry(entry Entry, opts Options) ([]Mutant, error) {
+	file := filepath.ToSlash(filepath.Clean(entry.Filename))
+	content, err := os.ReadFile(entry.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read '%s': %w", entry.Filename, err)
+	}
+	source := string(content)
+
+	unit, err := solidity.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse '%s': %w", entry.Filename, err)
+	}
+
+	filter := Filter{Contract: entry.Contract}
+	for _, name := range entry.Mutations {
+		operator, ok := configNames[name]
+		if !ok {
+			fmt.Fprintf(opts.Log, "\033[33m[Warning] The native mutator doesn't support '%s', it's skipped for %s.\033[0m\n", name, file)
+			continue
+		}
+		if filter.Operators == nil {
+			filter.Operators = make(map[string]bool)
+		}
+		filter.Operators[operator] = true
+	}
+	if len(entry.Mutations) > 0 && len(filter.Operators) == 0 {
+		return nil, nil
+	}
+	for _, function := range entry.Functions {
+		if filter.Functions == nil {
+			filter.Functions = make(map[string]bool)
+		}
+		filter.Functions[function] = true
+	}
+
+	mutations := FindMutations(unit, source, filter)
+	sort.SliceStable(mutations, func(i, j int) bool { return mutations[i].Start < mutations[j].Start })
+
+	var mutants []Mutant
+	seen := map[string]bool{source: true}
+	for _, mutation := range mutations {
+		m := Apply(file, source, mutation)
+		// Different mutations can produce the same code e.g. 'true' for 'a != b' in an if and a require.
+		if seen[m.Content] {
+			continue
+		}
+		seen[m.Content] = true
+		mutants = append(mutants, m)
+	}
+
+	generated := len(mutants)
+	invalid := 0
+	if !entry.SkipValidate {
+		mutants, invalid = validate(entry, file, source, mutants, opts)
+	}
+
+	if entry.NumMutants > 0 && len(mutants) > entry.NumMutants {
+		seed := entry.Seed
+		if entry.RandomSeed {
+			seed = uint64(time.Now().UnixNano())
+		}
+		rng := rand.New(rand.NewPCG(seed, seed))
+		rng.Shuffle(len(mutants), func(i, j int) { mutants[i], mutants[j] = mutants[j], mutants[i] })
+		mutants = mutants[:entry.NumMutants]
+		sort.SliceStable(mutants, func(i, j int) bool { return mutants[i].Start < mutants[j].Start })
+	}
+
+	fmt.Fprintf(opts.Log, "[Info] %s: %d mutants generated, %d invalid, %d kept.\n", file, generated, invalid, len(mutants))
+	return mutants, nil
+}
+
+// Apply creates the mutated source of file with Gambit's marker comment on the
+// line before the mutated line e.g.
+// '/// BinaryOpMutation(`+` |==> `-`) of: `return a + b;`'.
+func Apply(file, source string, mutation Mutation) Mutant {
+	lineStart := strings.LastIndexByte(source[:mutation.Start], '\n') + 1
+	lineEnd := strings.IndexByte(source[lineStart:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(source)
+	} else {
+		lineEnd += lineStart
+	}
+	line := source[lineStart:lineEnd]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+	marker := fmt.Sprintf("%s/// %s(`%s` |==> `%s`) of: `%s`\n",
+		indent, mutation.Operator, singleLine(mutation.Original), singleLine(mutation.Replacement), strings.TrimSpace(line))
+
+	mutated := source[:lineStart] + marker + source[lineStart:mutation.Start] + mutation.Replacement + source[mutation.End:]
+
+	return Mutant{
+		Mutation: mutation,
+		File:     file,
+		Line:     strings.Count(source[:lineStart], "\n") + 1,
+		Col:      mutation.Start - lineStart + 1,
+		Content:  mutated,
+		Diff:     unifiedDiff(source, mutated),
+	}
+}
+
+// validate keeps the mutants that compile. Validation is skipped with a
+// warning if there is no compiler or the original file doesn't compile.
+func validate(entry Entry, file, source string, mutants []Mutant, opts Options) ([]Mutant, int) {
+	if len(mutants) == 0 {
+		return mutants, 0
+	}
+
+	binary := entry.Solc
+	if binary == "" {
+		binary = "solc"
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		fmt.Fprintf(opts.Log, "\033[33m[Warning] Couldn't find solc ('%s') to validate the mutants of %s, they are kept unvalidated.\033[0m\n", binary, file)
+		return mutants, 0
+	}
+
+	basePath := entry.SolcBasePath
+	if basePath == "" {
+		basePath = "."
+	}
+	compile := func(content string) (solc.Diagnostic, bool, error) {
+		output, err := opts.Compile(binary, solc.Input{
+			Sources:  map[string]solc.Source{file: {Content: content}},
+			Settings: solc.Settings{Remappings: entry.SolcRemappings},
+		}, solc.Options{BasePath: basePath, AllowPaths: entry.SolcAllowPaths})
+		if err != nil {
+			return solc.Diagnostic{}, false, err
+		}
+		diagnostic, failed := output.FirstError()
+		return diagnostic, failed, nil
+	}
+
+	if diagnostic, failed, err := compile(source); err != nil || failed {
+		reason := diagnostic.Message
+		if err != nil {
+			reason = err.Error()
+		}
+		fmt.Fprintf(opts.Log, "\033[33m[Warning] %s doesn't compile with %s (%s), its mutants are kept unvalidated.\033[0m\n", file, binary, reason)
+		return mutants, 0
+	}
+
+	valid := mutants[:0]
+	invalid := 0
+	for _, m := range mutants {
+		if _, failed, err := compile(m.Content); err == nil && !failed {
+			valid = append(valid, m)
+		} else {
+			invalid++
+		}
+	}
+	return valid, invalid
+}
+
+// singleLine collapses whitespace so that code fits the one line marker.
+func singleLine(code string) string {
+	return strings.Join(strings.Fields(code), " ")
+}
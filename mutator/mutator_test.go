@@ -0,0 +1,238 @@
+package mutator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+const vaultSource = `pragma solidity ^0.8.0;
+
+contract Vault {
+    mapping(address => uint256) public balances;
+    bool public paused;
+
+    function withdraw(uint256 amount) external {
+        require(balances[msg.sender] >= amount);
+        if (paused) revert();
+        balances[msg.sender] = balances[msg.sender] - amount;
+        paused = !paused;
+    }
+
+    function count() external returns (uint256 i) {
+        i++;
+    }
+}
+`
+
+// chdir switches to dir for the duration of the test. The mutator resolves
+// the files of the config relative to the working directory.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+func findMutations(t *testing.T, filter Filter) []Mutation {
+	t.Helper()
+	unit, err := solidity.Parse(vaultSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FindMutations(unit, vaultSource, filter)
+}
+
+func TestFindMutations(t *testing.T) {
+	var got []string
+	for _, m := range findMutations(t, Filter{}) {
+		if m.Operator == BinaryOp {
+			continue // Covered below, there are many alternatives.
+		}
+		got = append(got, m.Operator+": "+m.Original+" => "+m.Replacement)
+	}
+
+	for _, want := range []string{
+		"DeleteExpressionMutation: require(balances[msg.sender] >= amount) => assert(true)",
+		"RequireMutation: balances[msg.sender] >= amount => false",
+		"SwapArgumentsOperatorMutation: balances[msg.sender] >= amount => amount >= balances[msg.sender]",
+		"IfStatementMutation: paused => true",
+		"AssignmentMutation: balances[msg.sender] - amount => 0",
+		"SwapArgumentsOperatorMutation: balances[msg.sender] - amount => amount - balances[msg.sender]",
+		"AssignmentMutation: !paused => true",
+		"UnaryOperatorMutation: !paused => paused",
+		"UnaryOperatorMutation: i++ => i--",
+	} {
+		found := false
+		for _, mutation := range got {
+			if mutation == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing mutation %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestFindMutationsFilter(t *testing.T) {
+	mutations := findMutations(t, Filter{
+		Operators: map[string]bool{BinaryOp: true},
+		Functions: map[string]bool{"withdraw": true},
+	})
+
+	// '>=' and '-' in withdraw, five alternatives each.
+	if len(mutations) != 10 {
+		t.Fatalf("expected 10 mutations, got %d: %+v", len(mutations), mutations)
+	}
+	for _, m := range mutations {
+		if m.Operator != BinaryOp || m.Function != "withdraw" || m.Contract != "Vault" {
+			t.Fatalf("unexpected mutation %+v", m)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.MkdirAll("src", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("src/Vault.sol", []byte(vaultSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Validation only needs an executable, the compilation is faked.
+	if err := os.WriteFile("solc", []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	compile := func(binary string, input solc.Input, opts solc.Options) (*solc.Output, error) {
+		// Pretend that swapping the operands never compiles.
+		if strings.Contains(input.Sources["src/Vault.sol"].Content, SwapArgumentsOperator+"(") {
+			return &solc.Output{Errors: []solc.Diagnostic{{Severity: "error", Message: "TypeError"}}}, nil
+		}
+		return &solc.Output{}, nil
+	}
+
+	entries := []Entry{{
+		Filename:  "src/Vault.sol",
+		Mutations: []string{"swap-arguments-operator-mutation", "if-cond-mutation", "elim-delegate-mutation"},
+		Solc:      "./solc",
+	}}
+	var log strings.Builder
+	results, err := Generate(entries, Options{Log: &log, Compile: compile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the two if mutations are valid.
+	if len(results) != 2 {
+		t.Fatalf("expected 2 mutants, got %d\n%s", len(results), log.String())
+	}
+	if !strings.Contains(log.String(), "doesn't support 'elim-delegate-mutation'") {
+		t.Errorf("expected a warning about the unsupported mutation, got:\n%s", log.String())
+	}
+
+	first := results[0]
+	if first.ID != "1" || first.Name != "mutants/1/src/Vault.sol" || first.Original != "src/Vault.sol" || first.Description != IfStatement {
+		t.Fatalf("unexpected result %+v", first)
+	}
+	wantDiff := "--- original\n+++ mutant\n@@ -6,7 +6,8 @@\n" +
+		" \n" +
+		"     function withdraw(uint256 amount) external {\n" +
+		"         require(balances[msg.sender] >= amount);\n" +
+		"-        if (paused) revert();\n" +
+		"+        /// IfStatementMutation(`paused` |==> `true`) of: `if (paused) revert();`\n" +
+		"+        if (true) revert();\n" +
+		"         balances[msg.sender] = balances[msg.sender] - amount;\n" +
+		"         paused = !paused;\n" +
+		"     }\n"
+	if first.Diff != wantDiff {
+		t.Fatalf("got diff\n%s\nwant\n%s", first.Diff, wantDiff)
+	}
+
+	mutant, err := os.ReadFile(filepath.Join("gambit_out", first.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(mutant), "        if (true) revert();") {
+		t.Fatalf("unexpected mutant:\n%s", mutant)
+	}
+
+	var written []Result
+	content, err := os.ReadFile(filepath.Join("gambit_out", "gambit_results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &written); err != nil || len(written) != 2 {
+		t.Fatalf("unexpected gambit_results.json (%v): %s", err, content)
+	}
+}
+
+func TestGenerateSampling(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("Vault.sol", []byte(vaultSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := Entry{Filename: "Vault.sol", NumMutants: 5, SkipValidate: true, Outdir: "out"}
+	first, err := Generate([]Entry{entry}, Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate([]Entry{entry}, Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 5 || len(second) != 5 {
+		t.Fatalf("expected 5 mutants, got %d and %d", len(first), len(second))
+	}
+	// The same seed samples the same mutants.
+	for i := range first {
+		if first[i].Diff != second[i].Diff {
+			t.Fatalf("mutant %d differs between runs with the same seed", i+1)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("out", "mutants", "5", "Vault.sol")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("out", "mutants", "6")); !os.IsNotExist(err) {
+		t.Fatal("expected only 5 mutant directories")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, tt := range []struct {
+		original, mutated, from, to string
+	}{
+		{"if (a >= b) {", "if (a > b) {", ">=", ">"},
+		{"x = a + b;", "x = a - b;", "+", "-"},
+		{"require(ok);\nx = 1;", "x = 1;", "require(ok);\nx", "x"},
+	} {
+		mutation, ok := Compare(tt.original, tt.mutated)
+		if !ok || mutation.Original != tt.from || mutation.Replacement != tt.to {
+			t.Errorf("Compare(%q, %q) = %q => %q, want %q => %q", tt.original, tt.mutated, mutation.Original, mutation.Replacement, tt.from, tt.to)
+		}
+		if got := tt.original[:mutation.Start] + mutation.Replacement + tt.original[mutation.End:]; got != tt.mutated {
+			t.Errorf("applying the mutation gives %q, want %q", got, tt.mutated)
+		}
+	}
+
+	if _, ok := Compare("a", "a"); ok {
+		t.Error("expected no mutation for identical sources")
+	}
+}
@@ -0,0 +1,256 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// Rule is a user-defined mutation: every occurrence of Pattern in its scope
+// is replaced with Replacement, one mutant per occurrence.
+type Rule struct {
+	// Name is the operator name of the mutants e.g. "TxOriginMutation".
+	Name string `json:"name"`
+	// Pattern is the code to replace e.g. "msg.sender". It's matched token by
+	// token, so whitespace doesn't matter and 'transfer' doesn't match
+	// 'safeTransfer'. Comments and strings are never matched.
+	Pattern string `json:"pattern"`
+	// Replacement is the code put in place of the match e.g. "tx.origin".
+	// Empty removes the match.
+	Replacement string `json:"replacement"`
+	// Regex makes Pattern a regular expression, and Replacement may refer to
+	// its groups e.g. "$1".
+	Regex bool `json:"regex"`
+	// Contract and Functions limit the rule to a contract or functions.
+	Contract  string   `json:"contract"`
+	Functions []string `json:"functions"`
+
+	pattern []solidity.Token
+	regex   *regexp.Regexp
+}
+
+// Compile validates the rule and prepares its pattern. The name gets the
+// 'Mutation' suffix if it's missing, the marker comment is only recognized
+// with it.
+func (r *Rule) Compile() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("every rule needs a 'name'")
+	}
+	if !strings.HasSuffix(r.Name, "Mutation") {
+		r.Name += "Mutation"
+	}
+	if strings.TrimSpace(r.Pattern) == "" {
+		return fmt.Errorf("rule '%s' has an empty 'pattern'", r.Name)
+	}
+
+	if r.Regex {
+		regex, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule '%s' has an invalid regular expression: %w", r.Name, err)
+		}
+		r.regex = regex
+		return nil
+	}
+
+	tokens, err := solidity.Tokenize(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule '%s' has an invalid pattern: %w", r.Name, err)
+	}
+	r.pattern = tokens
+	return nil
+}
+
+// FindRuleMutations returns a mutation for every occurrence of the rule's
+// pattern in the source. The rule must be compiled.
+func FindRuleMutations(unit *solidity.SourceUnit, source string, rule Rule) []Mutation {
+	var mutations []Mutation
+	add := func(token, start, end int, replacement string) {
+		contract, function, inFunction := unit.FunctionAt(token)
+		m := Mutation{
+			Operator:    rule.Name,
+			Start:       start,
+			End:         end,
+			Original:    source[start:end],
+			Replacement: replacement,
+		}
+		if contract != nil {
+			m.Contract = contract.Name
+		}
+		if inFunction {
+			m.Function = function.Name
+		}
+
+		if rule.Contract != "" && m.Contract != rule.Contract {
+			return
+		}
+		if len(rule.Functions) > 0 && !contains(rule.Functions, m.Function) {
+			return
+		}
+		mutations = append(mutations, m)
+	}
+
+	tokens := unit.Tokens
+	if rule.regex != nil {
+		for _, match := range rule.regex.FindAllStringSubmatchIndex(source, -1) {
+			start, end := match[0], match[1]
+			// Matches must start at a token, never in a comment or within a string.
+			i := sort.Search(len(tokens), func(i int) bool { return tokens[i].Offset >= start })
+			if i == len(tokens) || tokens[i].Offset != start || start == end {
+				continue
+			}
+			replacement := string(rule.regex.ExpandString(nil, rule.Replacement, source, match))
+			add(i, start, end, replacement)
+		}
+		return mutations
+	}
+
+	for i := 0; i+len(rule.pattern) <= len(tokens); i++ {
+		matched := true
+		for j, want := range rule.pattern {
+			if tokens[i+j].Text != want.Text {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			last := tokens[i+len(rule.pattern)-1]
+			add(i, tokens[i].Offset, last.End(), rule.Replacement)
+		}
+	}
+	return mutations
+}
+
+// Finder returns the mutations of a file of the gambit config.
+type Finder func(entry Entry, unit *solidity.SourceUnit, source string) []Mutation
+
+// RuleFinder applies the rules given per file, keyed by the entry's filename.
+func RuleFinder(rules map[string][]Rule) Finder {
+	return func(entry Entry, unit *solidity.SourceUnit, source string) []Mutation {
+		var mutations []Mutation
+		for _, rule := range rules[entry.Filename] {
+			mutations = append(mutations, FindRuleMutations(unit, source, rule)...)
+		}
+		return mutations
+	}
+}
+
+// AppendMutants adds the mutants found in the files of the entries to the
+// ones already in outdir, numbered after them. kind describes the mutants in
+// the log e.g. "rule". Like the generated mutants, they are validated with
+// the entry's solc unless skip_validate is set.
+func AppendMutants(outdir string, entries []Entry, kind string, find Finder, opts Options) ([]Result, error) {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.Compile == nil {
+		opts.Compile = solc.Compile
+	}
+
+	var all []Mutant
+	for _, entry := range entries {
+		file := filepath.ToSlash(filepath.Clean(entry.Filename))
+		content, err := os.ReadFile(entry.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read '%s': %w", entry.Filename, err)
+		}
+		source := string(content)
+		unit, err := solidity.Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse '%s': %w", entry.Filename, err)
+		}
+
+		mutations := find(entry, unit, source)
+		if len(mutations) == 0 {
+			continue
+		}
+		sort.SliceStable(mutations, func(i, j int) bool { return mutations[i].Start < mutations[j].Start })
+
+		var mutants []Mutant
+		seen := map[string]bool{source: true}
+		for _, mutation := range mutations {
+			m := Apply(file, source, mutation)
+			if seen[m.Content] {
+				continue
+			}
+			seen[m.Content] = true
+			mutants = append(mutants, m)
+		}
+
+		generated := len(mutants)
+		invalid := 0
+		if !entry.SkipValidate {
+			mutants, invalid = validate(entry, file, source, mutants, opts)
+		}
+		fmt.Fprintf(opts.Log, "[Info] %s: %d %s mutants generated, %d invalid, %d kept.\n", file, generated, kind, invalid, len(mutants))
+		all = append(all, mutants...)
+	}
+
+	existing, err := ReadResults(outdir)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	for _, result := range existing {
+		if id, err := strconv.Atoi(result.ID); err == nil && id >= next {
+			next = id + 1
+		}
+	}
+
+	added, log, err := writeMutants(outdir, all, next)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeResults(outdir, append(existing, added...)); err != nil {
+		return nil, err
+	}
+	if err := appendFile(filepath.Join(outdir, "mutants.log"), log); err != nil {
+		return nil, fmt.Errorf("Failed to write mutants.log: %w", err)
+	}
+	return added, nil
+}
+
+// ReadResults reads the gambit_results.json in outdir, if there is one.
+func ReadResults(outdir string) ([]Result, error) {
+	content, err := os.ReadFile(filepath.Join(outdir, "gambit_results.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read gambit_results.json: %w", err)
+	}
+
+	var results []Result
+	if err := json.Unmarshal(content, &results); err != nil {
+		return nil, fmt.Errorf("Failed to parse gambit_results.json: %w", err)
+	}
+	return results, nil
+}
+
+func appendFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
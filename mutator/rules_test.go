@@ -0,0 +1,132 @@
+package mutator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+const tokenSource = `pragma solidity ^0.8.0;
+
+contract Vault {
+    // Only msg.sender can withdraw.
+    function withdraw(uint256 amount) external nonReentrant {
+        token.safeTransfer(msg.sender, amount);
+        owner.transfer(amount);
+    }
+
+    function sweep() external onlyOwner nonReentrant {
+        token.safeTransfer(msg.sender, token.balanceOf(address(this)));
+    }
+}
+`
+
+func ruleMutations(t *testing.T, rule Rule) []Mutation {
+	t.Helper()
+	if err := rule.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	unit, err := solidity.Parse(tokenSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FindRuleMutations(unit, tokenSource, rule)
+}
+
+func TestFindRuleMutations(t *testing.T) {
+	// The comment isn't mutated.
+	mutations := ruleMutations(t, Rule{Name: "TxOrigin", Pattern: "msg . sender", Replacement: "tx.origin"})
+	if len(mutations) != 2 {
+		t.Fatalf("expected 2 mutations, got %+v", mutations)
+	}
+	for _, m := range mutations {
+		if m.Operator != "TxOriginMutation" || m.Original != "msg.sender" || m.Replacement != "tx.origin" || m.Contract != "Vault" {
+			t.Fatalf("unexpected mutation %+v", m)
+		}
+	}
+	if mutations[0].Function != "withdraw" || mutations[1].Function != "sweep" {
+		t.Fatalf("unexpected functions %s and %s", mutations[0].Function, mutations[1].Function)
+	}
+
+	// Patterns match whole tokens, 'transfer' isn't found in 'safeTransfer'.
+	mutations = ruleMutations(t, Rule{Name: "UnsafeTransferMutation", Pattern: "transfer", Replacement: "send"})
+	if len(mutations) != 1 || !strings.HasPrefix(tokenSource[mutations[0].Start:], "transfer(amount)") {
+		t.Fatalf("expected a single mutation of 'transfer', got %+v", mutations)
+	}
+
+	// Scoped to a function.
+	mutations = ruleMutations(t, Rule{Name: "DropNonReentrantMutation", Pattern: "nonReentrant", Functions: []string{"sweep"}})
+	if len(mutations) != 1 || mutations[0].Function != "sweep" || mutations[0].Replacement != "" {
+		t.Fatalf("expected the modifier of sweep to be removed, got %+v", mutations)
+	}
+}
+
+func TestFindRuleMutationsRegex(t *testing.T) {
+	mutations := ruleMutations(t, Rule{
+		Name:        "UnsafeTransferMutation",
+		Pattern:     `(\w+)\.safeTransfer\(`,
+		Replacement: "$1.transfer(",
+		Regex:       true,
+		Contract:    "Vault",
+	})
+	if len(mutations) != 2 {
+		t.Fatalf("expected 2 mutations, got %+v", mutations)
+	}
+	if mutations[0].Original != "token.safeTransfer(" || mutations[0].Replacement != "token.transfer(" {
+		t.Fatalf("unexpected mutation %+v", mutations[0])
+	}
+
+	if mutations := ruleMutations(t, Rule{Name: "X", Pattern: "msg", Regex: true, Contract: "Token"}); len(mutations) != 0 {
+		t.Fatalf("expected no mutations outside of the contract, got %+v", mutations)
+	}
+}
+
+func TestAppendMutants(t *testing.T) {
+	chdir(t, t.TempDir())
+	if err := os.WriteFile("Vault.sol", []byte(tokenSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := Entry{Filename: "Vault.sol", SkipValidate: true, NumMutants: 3, Outdir: "out"}
+	if _, err := Generate([]Entry{entry}, Options{Log: &strings.Builder{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := Rule{Name: "TxOriginMutation", Pattern: "msg.sender", Replacement: "tx.origin"}
+	if err := rule.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	added, err := AppendMutants("out", []Entry{entry}, "rule", RuleFinder(map[string][]Rule{"Vault.sol": {rule}}), Options{Log: &strings.Builder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(added) != 2 || added[0].ID != "4" || added[1].ID != "5" {
+		t.Fatalf("expected mutants 4 and 5, got %+v", added)
+	}
+	results, err := ReadResults("out")
+	if err != nil || len(results) != 5 {
+		t.Fatalf("expected 5 results (%v), got %+v", err, results)
+	}
+
+	mutant, err := os.ReadFile(filepath.Join("out", "mutants", "4", "Vault.sol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker := "        /// TxOriginMutation(`msg.sender` |==> `tx.origin`) of: `token.safeTransfer(msg.sender, amount);`\n" +
+		"        token.safeTransfer(tx.origin, amount);\n"
+	if !strings.Contains(string(mutant), marker) {
+		t.Fatalf("mutant 4 lacks the marker:\n%s", mutant)
+	}
+
+	log, err := os.ReadFile(filepath.Join("out", "mutants.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(log)), "\n"); len(lines) != 5 || !strings.HasPrefix(lines[4], "5,TxOriginMutation,Vault.sol,") {
+		t.Fatalf("unexpected mutants.log:\n%s", log)
+	}
+}
@@ -0,0 +1,322 @@
+package mutator
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// Operators of the security pack. They target access control, accounting and
+// reentrancy bugs and are only applied when selected explicitly.
+const (
+	ModifierRemoval    = "ModifierRemovalMutation"
+	VisibilityWidening = "VisibilityWideningMutation"
+	UncheckedRemoval   = "UncheckedRemovalMutation"
+	BalanceCheck       = "BalanceCheckMutation"
+	EmitRemoval        = "EmitRemovalMutation"
+	SkipStateUpdate    = "SkipStateUpdateMutation"
+)
+
+// SecurityOperators maps the config names of the security pack's operators
+// to the operators.
+var SecurityOperators = map[string]string{
+	"modifier-removal-mutation":    ModifierRemoval,
+	"visibility-widening-mutation": VisibilityWidening,
+	"unchecked-removal-mutation":   UncheckedRemoval,
+	"balance-check-mutation":       BalanceCheck,
+	"emit-removal-mutation":        EmitRemoval,
+	"skip-state-update-mutation":   SkipStateUpdate,
+}
+
+// SecurityFinder applies the selected operators of the security pack, within
+// the contract and functions of each entry.
+func SecurityFinder(operators map[string]bool) Finder {
+	return func(entry Entry, unit *solidity.SourceUnit, source string) []Mutation {
+		if len(operators) == 0 {
+			return nil
+		}
+		filter := Filter{Operators: operators, Contract: entry.Contract}
+		for _, function := range entry.Functions {
+			if filter.Functions == nil {
+				filter.Functions = make(map[string]bool)
+			}
+			filter.Functions[function] = true
+		}
+		return FindMutations(unit, source, filter)
+	}
+}
+
+func isSecurityOperator(operator string) bool {
+	for _, security := range SecurityOperators {
+		if security == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// headerKeywords are the parts of a function header that are not modifiers.
+var headerKeywords = map[string]bool{
+	"external": true, "public": true, "internal": true, "private": true,
+	"pure": true, "view": true, "payable": true, "virtual": true,
+}
+
+// boundaryFlips turns strict balance checks into inclusive ones and back,
+// the classic off-by-one of accounting code.
+var boundaryFlips = map[string]string{">=": ">", ">": ">=", "<=": "<", "<": "<="}
+
+// lowLevelCalls are members whose call always reaches another contract or
+// transfers ether, whatever the type of their base.
+var lowLevelCalls = map[string]bool{
+	"call": true, "delegatecall": true, "staticcall": true, "send": true, "transfer": true,
+	"transferFrom": true, "sendValue": true, "functionCall": true, "functionCallWithValue": true,
+	"safeTransfer": true, "safeTransferFrom": true, "safeApprove": true, "forceApprove": true,
+	"safeIncreaseAllowance": true, "safeDecreaseAllowance": true,
+}
+
+// header mutates the modifiers and the visibility of a function.
+func (f *finder) header(contract solidity.Contract, function solidity.Function) {
+	if function.Kind == "modifier" {
+		return
+	}
+
+	open := function.Start
+	for open < function.BodyStart && f.tokens[open].Text != "(" {
+		open++
+	}
+	for i := f.matching(open, "(", ")") + 1; i < function.BodyStart; i++ {
+		tok := f.tokens[i]
+		switch {
+		case tok.Text == "returns":
+			return
+		case tok.Text == "override":
+			if f.tokens[i+1].Text == "(" {
+				i = f.matching(i+1, "(", ")")
+			}
+		case tok.Text == "internal" || tok.Text == "private":
+			// Library functions and free functions can't be made public.
+			if f.filter.allows(VisibilityWidening) && contract.Kind == solidity.KindContract {
+				f.add(VisibilityWidening, i, i+1, "public")
+			}
+		case headerKeywords[tok.Text]:
+		case tok.Kind == solidity.Identifier:
+			end := i + 1
+			if f.tokens[end].Text == "(" {
+				end = f.matching(end, "(", ")") + 1
+			}
+			// The arguments of a constructor's header call the base constructors.
+			if f.filter.allows(ModifierRemoval) && function.Kind != "constructor" {
+				// Remove the space before the modifier too, 'external  {' looks like a typo.
+				f.addMutation(Mutation{
+					Operator:    ModifierRemoval,
+					Start:       f.tokens[i-1].End(),
+					End:         f.tokens[end-1].End(),
+					Original:    f.text(i, end),
+					Replacement: "",
+				})
+			}
+			i = end - 1
+		}
+	}
+}
+
+// removeUnchecked turns the 'unchecked' block at i into a checked one.
+func (f *finder) removeUnchecked(i int) {
+	if !f.filter.allows(UncheckedRemoval) || i+1 >= len(f.tokens) || f.tokens[i+1].Text != "{" {
+		return
+	}
+	f.addMutation(Mutation{
+		Operator:    UncheckedRemoval,
+		Start:       f.tokens[i].Offset,
+		End:         f.tokens[i+1].Offset,
+		Original:    "unchecked",
+		Replacement: "",
+	})
+}
+
+// balanceCheck flips the boundary of a comparison involving a balance.
+func (f *finder) balanceCheck(node *solidity.Node) {
+	flipped, ok := boundaryFlips[f.tokens[node.Op].Text]
+	if !ok || !f.filter.allows(BalanceCheck) {
+		return
+	}
+	for i := node.Start; i < node.End; i++ {
+		tok := f.tokens[i]
+		if tok.Kind == solidity.Identifier && strings.Contains(strings.ToLower(tok.Text), "balance") {
+			f.addMutation(Mutation{
+				Operator:    BalanceCheck,
+				Start:       f.tokens[node.Op].Offset,
+				End:         f.tokens[node.Op].End(),
+				Original:    f.tokens[node.Op].Text,
+				Replacement: flipped,
+			})
+			return
+		}
+	}
+}
+
+// skipStateUpdate removes the statement in [start, end) if it writes a state
+// variable and an external call follows it in the function.
+func (f *finder) skipStateUpdate(start, end int) {
+	if !f.filter.allows(SkipStateUpdate) || !f.writesState(start, end) {
+		return
+	}
+	for _, call := range f.externalCalls {
+		if call > end {
+			f.add(SkipStateUpdate, start, end, "assert(true)")
+			return
+		}
+	}
+}
+
+// writesState reports whether the expression statement in [start, end)
+// assigns, deletes, increments or pushes to a state variable.
+func (f *finder) writesState(start, end int) bool {
+	root, err := solidity.ParseExpression(f.tokens, start, end)
+	if err != nil {
+		return false
+	}
+
+	switch root.Kind {
+	case solidity.Assignment:
+		return f.isStateVariable(root.Children[0])
+	case solidity.Unary, solidity.Postfix:
+		switch f.tokens[root.Op].Text {
+		case "++", "--", "delete":
+			return f.isStateVariable(root.Children[0])
+		}
+	case solidity.Call:
+		callee := root.Children[0]
+		if callee.Kind == solidity.Member {
+			member := f.tokens[callee.End-1].Text
+			return (member == "push" || member == "pop") && f.isStateVariable(callee.Children[0])
+		}
+	}
+	return false
+}
+
+// isStateVariable reports whether the expression is a state variable or a
+// part of one e.g. 'balances[user]' or 'config.fee'. Shadowing is ignored.
+func (f *finder) isStateVariable(node *solidity.Node) bool {
+	for {
+		switch node.Kind {
+		case solidity.Index, solidity.Member:
+			node = node.Children[0]
+		case solidity.Primary:
+			return f.stateVars[f.text(node.Start, node.End)]
+		default:
+			return false
+		}
+	}
+}
+
+// findExternalCalls returns the indexes of the '.' tokens of calls in
+// [start, end) that leave the contract: low-level calls, value transfers and
+// calls on variables or conversions of contract types e.g. 'token.mint(...)'
+// or 'IERC20(token).transfer(...)'.
+func findExternalCalls(tokens []solidity.Token, start, end int, contractVariables map[string]bool) []int {
+	var calls []int
+	for i := start; i+2 < end; i++ {
+		if tokens[i].Text != "." || tokens[i+1].Kind != solidity.Identifier {
+			continue
+		}
+		if next := tokens[i+2].Text; next != "(" && next != "{" {
+			continue
+		}
+
+		external := lowLevelCalls[tokens[i+1].Text]
+		if i > start {
+			base := tokens[i-1]
+			switch {
+			case base.Kind == solidity.Identifier && contractVariables[base.Text]:
+				external = true
+			case base.Text == ")":
+				// A conversion e.g. 'IERC20(token)', found by walking back to its '('.
+				depth := 0
+				for j := i - 1; j > start; j-- {
+					switch tokens[j].Text {
+					case ")":
+						depth++
+					case "(":
+						depth--
+					}
+					if depth == 0 {
+						callee := tokens[j-1]
+						external = external || (callee.Kind == solidity.Identifier && isTypeName(callee.Text) && (j < 2 || tokens[j-2].Text != "."))
+						break
+					}
+				}
+			}
+		}
+		if external {
+			calls = append(calls, i)
+		}
+	}
+	return calls
+}
+
+// contractVariables finds the variables declared in [start, end] with a
+// contract or interface type, recognized by the capitalized type name.
+func contractVariables(tokens []solidity.Token, start, end int) map[string]bool {
+	variables := make(map[string]bool)
+	for i := start; i <= end && i+2 < len(tokens); i++ {
+		if tokens[i].Kind != solidity.Identifier || !isTypeName(tokens[i].Text) || (i > 0 && tokens[i-1].Text == ".") {
+			continue
+		}
+		next := i + 1
+		for next < len(tokens) && declarationKeywords[tokens[next].Text] {
+			next++
+		}
+		if next+1 >= len(tokens) || tokens[next].Kind != solidity.Identifier || declarationKeywords[tokens[next].Text] {
+			continue
+		}
+		switch tokens[next+1].Text {
+		case ";", "=", ",", ")":
+			variables[tokens[next].Text] = true
+		}
+	}
+	return variables
+}
+
+// stateVariableNames returns the names of the state variables declared in
+// the contract. Inherited ones are not known.
+func (f *finder) stateVariableNames(contract solidity.Contract) map[string]bool {
+	names := make(map[string]bool)
+	open := contract.Start
+	for open < contract.End && f.tokens[open].Text != "{" {
+		open++
+	}
+
+	statementStart := open + 1
+	for i := open + 1; i < contract.End; i++ {
+		if fn := f.functionStartingAt(contract, i); fn != nil {
+			i = fn.End
+			statementStart = i + 1
+			continue
+		}
+		switch f.tokens[i].Text {
+		case "{":
+			i = f.matching(i, "{", "}")
+			statementStart = i + 1
+		case ";":
+			switch f.tokens[statementStart].Text {
+			case "event", "error", "using", "type":
+			default:
+				name := i - 1
+				if eq := f.topLevel(statementStart, i, "="); eq >= 0 {
+					name = eq - 1
+				}
+				if name > statementStart && f.tokens[name].Kind == solidity.Identifier {
+					names[f.tokens[name].Text] = true
+				}
+			}
+			statementStart = i + 1
+		}
+	}
+	return names
+}
+
+func isTypeName(name string) bool {
+	return name != "" && unicode.IsUpper(rune(name[0]))
+}
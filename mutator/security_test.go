@@ -0,0 +1,116 @@
+package mutator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+const bankSource = `pragma solidity ^0.8.0;
+
+contract Bank is Ownable {
+    mapping(address => uint256) public balances;
+    uint256 public totalDeposits;
+    IERC20 public token;
+
+    event Withdrawn(address indexed user, uint256 amount);
+
+    constructor(IERC20 _token) Ownable(msg.sender) {
+        token = _token;
+    }
+
+    function withdraw(uint256 amount) external nonReentrant whenNotPaused() {
+        require(balances[msg.sender] >= amount);
+        balances[msg.sender] -= amount;
+        totalDeposits = totalDeposits - amount;
+        (bool ok, ) = msg.sender.call{value: amount}("");
+        require(ok);
+        emit Withdrawn(msg.sender, amount);
+    }
+
+    function sweep(address to) external onlyOwner {
+        uint256 amount = token.balanceOf(address(this));
+        IERC20(token).transfer(to, amount);
+        totalDeposits = 0;
+    }
+
+    function _fee(uint256 amount) internal pure virtual returns (uint256) {
+        unchecked {
+            return amount / 100;
+        }
+    }
+}
+`
+
+func securityMutations(t *testing.T, operator string) []string {
+	t.Helper()
+	unit, err := solidity.Parse(bankSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := SecurityFinder(map[string]bool{operator: true})
+
+	var mutations []string
+	for _, m := range find(Entry{}, unit, bankSource) {
+		if m.Operator != operator {
+			t.Fatalf("unexpected operator in %+v", m)
+		}
+		mutations = append(mutations, m.Function+": "+singleLine(bankSource[m.Start:m.End])+" => "+m.Replacement)
+	}
+	return mutations
+}
+
+func TestSecurityOperators(t *testing.T) {
+	for _, tt := range []struct {
+		operator string
+		want     []string
+	}{
+		{ModifierRemoval, []string{
+			"withdraw: nonReentrant => ",
+			"withdraw: whenNotPaused() => ",
+			"sweep: onlyOwner => ",
+		}},
+		{VisibilityWidening, []string{"_fee: internal => public"}},
+		{UncheckedRemoval, []string{"_fee: unchecked => "}},
+		{BalanceCheck, []string{"withdraw: >= => >"}},
+		{EmitRemoval, []string{"withdraw: emit Withdrawn(msg.sender, amount) => assert(true)"}},
+		// totalDeposits in sweep is updated after the transfer, that's fine.
+		{SkipStateUpdate, []string{
+			"withdraw: balances[msg.sender] -= amount => assert(true)",
+			"withdraw: totalDeposits = totalDeposits - amount => assert(true)",
+		}},
+	} {
+		got := securityMutations(t, tt.operator)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s:\ngot\n  %s\nwant\n  %s", tt.operator, strings.Join(got, "\n  "), strings.Join(tt.want, "\n  "))
+		}
+	}
+}
+
+func TestSecurityOperatorsAreOptIn(t *testing.T) {
+	unit, err := solidity.Parse(bankSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range FindMutations(unit, bankSource, Filter{}) {
+		if isSecurityOperator(m.Operator) {
+			t.Fatalf("security operator applied without being selected: %+v", m)
+		}
+	}
+	if mutations := SecurityFinder(nil)(Entry{}, unit, bankSource); len(mutations) != 0 {
+		t.Fatalf("expected no mutations without operators, got %d", len(mutations))
+	}
+}
+
+func TestSecurityFinderScope(t *testing.T) {
+	unit, err := solidity.Parse(bankSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := SecurityFinder(map[string]bool{ModifierRemoval: true})
+	mutations := find(Entry{Functions: []string{"sweep"}}, unit, bankSource)
+	if len(mutations) != 1 || mutations[0].Function != "sweep" {
+		t.Fatalf("expected only the modifier of sweep, got %+v", mutations)
+	}
+}
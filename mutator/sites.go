@@ -0,0 +1,605 @@
+package mutator
+
+import (
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solidity"
+)
+
+// Operator names, as printed by Gambit in gambit_results.json and in the
+// mutation marker comments.
+const (
+	BinaryOp              = "BinaryOpMutation"
+	Require               = "RequireMutation"
+	IfStatement           = "IfStatementMutation"
+	Assignment            = "AssignmentMutation"
+	DeleteExpression      = "DeleteExpressionMutation"
+	SwapArgumentsOperator = "SwapArgumentsOperatorMutation"
+	UnaryOperator         = "UnaryOperatorMutation"
+)
+
+// configNames maps the operator names of Gambit's config to the operators.
+var configNames = map[string]string{
+	"binary-op-mutation":               BinaryOp,
+	"require-mutation":                 Require,
+	"if-cond-mutation":                 IfStatement,
+	"assignment-mutation":              Assignment,
+	"delete-expression-mutation":       DeleteExpression,
+	"swap-arguments-operator-mutation": SwapArgumentsOperator,
+	"unary-operator-mutation":          UnaryOperator,
+}
+
+// Mutation is a single change of a source file.
+type Mutation struct {
+	Operator    string
+	Start, End  int    // Byte offsets of the replaced code.
+	Original    string // Replaced code, shown in the marker comment.
+	Replacement string
+	Function    string // Name of the mutated function, empty for state variables.
+	Contract    string // Name of the mutated contract, empty for free functions.
+}
+
+// binaryAlternatives lists the replacements of each binary operator.
+var binaryAlternatives = map[string][]string{
+	"+":  {"-", "*", "/", "%", "**"},
+	"-":  {"+", "*", "/", "%", "**"},
+	"*":  {"+", "-", "/", "%", "**"},
+	"/":  {"+", "-", "*", "%", "**"},
+	"%":  {"+", "-", "*", "/", "**"},
+	"**": {"+", "-", "*", "/", "%"},
+	"<<": {">>", "*", "/"},
+	">>": {"<<", "*", "/"},
+	"&":  {"|", "^"},
+	"|":  {"&", "^"},
+	"^":  {"&", "|"},
+	"<":  {"<=", ">", ">=", "==", "!="},
+	"<=": {"<", ">", ">=", "==", "!="},
+	">":  {"<", "<=", ">=", "==", "!="},
+	">=": {"<", "<=", ">", "==", "!="},
+	"==": {"!=", "<", "<=", ">", ">="},
+	"!=": {"==", "<", "<=", ">", ">="},
+	"&&": {"||"},
+	"||": {"&&"},
+}
+
+// nonCommutative operators change the result when their operands are swapped.
+var nonCommutative = map[string]bool{
+	"-": true, "/": true, "%": true, "**": true, "<<": true, ">>": true,
+	"<": true, "<=": true, ">": true, ">=": true,
+}
+
+// constants replace the value of assignments, by the assigned type.
+var constants = map[string][]string{
+	"uint": {"0", "1"},
+	"int":  {"0", "1", "-1"},
+	"bool": {"true", "false"},
+}
+
+// Filter restricts the mutations to some operators, contracts and functions.
+// Empty fields don't restrict anything, except that the operators of the
+// security pack are only applied when listed in Operators.
+type Filter struct {
+	Operators map[string]bool
+	Contract  string
+	Functions map[string]bool
+}
+
+func (f Filter) allows(operator string) bool {
+	if isSecurityOperator(operator) {
+		return f.Operators[operator]
+	}
+	return len(f.Operators) == 0 || f.Operators[operator]
+}
+
+// finder collects the mutations of a single source file.
+type finder struct {
+	unit     *solidity.SourceUnit
+	tokens   []solidity.Token
+	source   string
+	filter   Filter
+	types    map[string]varType
+	contract string
+	function string
+
+	stateVars     map[string]bool // State variables of the current contract.
+	externalCalls []int           // Token indexes of the external calls of the current function.
+
+	mutations []Mutation
+}
+
+// varType is the inferred type of a variable, enough to pick constants for
+// the assignment mutation. elem is set for arrays and mappings.
+type varType struct {
+	kind string // "uint", "int" or "bool".
+	elem bool   // Whether indexing the variable yields a value of kind.
+}
+
+// FindMutations returns all mutations of the source, in the order of their
+// position.
+func FindMutations(unit *solidity.SourceUnit, source string, filter Filter) []Mutation {
+	f := &finder{unit: unit, tokens: unit.Tokens, source: source, filter: filter}
+
+	for _, contract := range unit.Contracts {
+		if filter.Contract != "" && contract.Name != filter.Contract {
+			continue
+		}
+		if contract.Kind == solidity.KindInterface {
+			continue
+		}
+		f.contract = contract.Name
+		f.types = inferTypes(f.tokens, contract.Start, contract.End)
+		f.stateVars = f.stateVariableNames(contract)
+		contractVars := contractVariables(f.tokens, contract.Start, contract.End)
+
+		if len(filter.Functions) == 0 {
+			f.function = ""
+			f.stateVariables(contract)
+		}
+		for _, function := range contract.Functions {
+			if !function.HasBody || (len(filter.Functions) > 0 && !filter.Functions[function.Name]) {
+				continue
+			}
+			f.function = function.Name
+			f.externalCalls = findExternalCalls(f.tokens, function.BodyStart, function.End, contractVars)
+			f.header(contract, function)
+			f.block(function.BodyStart+1, function.End)
+		}
+	}
+
+	if filter.Contract == "" {
+		f.contract = ""
+		f.stateVars, f.externalCalls = nil, nil
+		for _, function := range unit.FreeFunctions {
+			if !function.HasBody || (len(filter.Functions) > 0 && !filter.Functions[function.Name]) {
+				continue
+			}
+			f.types = inferTypes(f.tokens, function.Start, function.End)
+			f.function = function.Name
+			f.block(function.BodyStart+1, function.End)
+		}
+	}
+
+	return f.mutations
+}
+
+// stateVariables mutates the initial values of state variables.
+func (f *finder) stateVariables(contract solidity.Contract) {
+	open := contract.Start
+	for open < contract.End && f.tokens[open].Text != "{" {
+		open++
+	}
+
+	statementStart := open + 1
+	for i := open + 1; i < contract.End; i++ {
+		if fn := f.functionStartingAt(contract, i); fn != nil {
+			i = fn.End
+			statementStart = i + 1
+			continue
+		}
+		switch f.tokens[i].Text {
+		case "{":
+			i = f.matching(i, "{", "}")
+			statementStart = i + 1
+		case ";":
+			first := f.tokens[statementStart].Text
+			if first != "event" && first != "error" && first != "using" {
+				if eq := f.topLevel(statementStart, i, "="); eq >= 0 {
+					f.expression(eq+1, i)
+				}
+			}
+			statementStart = i + 1
+		}
+	}
+}
+
+func (f *finder) functionStartingAt(contract solidity.Contract, i int) *solidity.Function {
+	for j := range contract.Functions {
+		if contract.Functions[j].Start == i {
+			return &contract.Functions[j]
+		}
+	}
+	return nil
+}
+
+// block walks the statements of a function body in [start, end).
+func (f *finder) block(start, end int) {
+	for i := start; i < end; {
+		tok := f.tokens[i]
+		switch tok.Text {
+		case "unchecked":
+			f.removeUnchecked(i)
+			i++
+		case "{", "}", ";", "else", "do":
+			i++
+		case "if", "while":
+			open := i + 1
+			if open >= end || f.tokens[open].Text != "(" {
+				i++
+				continue
+			}
+			closing := f.matching(open, "(", ")")
+			if tok.Text == "if" {
+				f.replaceWithConstants(IfStatement, open+1, closing, []string{"true", "false"})
+			}
+			f.expression(open+1, closing)
+			i = closing + 1
+		case "for":
+			open := i + 1
+			if open >= end || f.tokens[open].Text != "(" {
+				i++
+				continue
+			}
+			closing := f.matching(open, "(", ")")
+			firstSemi := f.topLevel(open+1, closing, ";")
+			if firstSemi < 0 {
+				i = closing + 1
+				continue
+			}
+			secondSemi := f.topLevel(firstSemi+1, closing, ";")
+			f.statement(open+1, firstSemi, false)
+			if secondSemi >= 0 {
+				f.expression(firstSemi+1, secondSemi)
+				f.expression(secondSemi+1, closing)
+			}
+			i = closing + 1
+		case "assembly":
+			for i < end && f.tokens[i].Text != "{" {
+				i++
+			}
+			i = f.matching(i, "{", "}") + 1
+		case "try", "catch":
+			// The body of try and catch clauses is walked as regular statements.
+			for i < end && f.tokens[i].Text != "{" {
+				if f.tokens[i].Text == "(" {
+					i = f.matching(i, "(", ")")
+				}
+				i++
+			}
+		case "return", "emit":
+			semi := f.statementEnd(i, end)
+			if tok.Text == "emit" && f.filter.allows(EmitRemoval) {
+				f.add(EmitRemoval, i, semi, "assert(true)")
+			}
+			f.expression(i+1, semi)
+			i = semi + 1
+		case "revert", "break", "continue", "_":
+			i = f.statementEnd(i, end) + 1
+		default:
+			semi := f.statementEnd(i, end)
+			f.statement(i, semi, true)
+			f.skipStateUpdate(i, semi)
+			i = semi + 1
+		}
+	}
+}
+
+// statement mutates a declaration or expression statement in [start, end).
+func (f *finder) statement(start, end int, deletable bool) {
+	if start >= end {
+		return
+	}
+
+	eq := f.topLevel(start, end, "=")
+	if f.isDeclaration(start, end, eq) {
+		if eq < 0 {
+			return
+		}
+		// The type is known for single variables of elementary types e.g. 'uint256 x = ...'.
+		if kind := elementaryKind(f.tokens[start].Text); kind != "" && eq-start >= 2 && f.tokens[eq-1].Kind == solidity.Identifier {
+			f.replaceWithConstants(Assignment, eq+1, end, constants[kind])
+		}
+		f.expression(eq+1, end)
+		return
+	}
+
+	if deletable && f.filter.allows(DeleteExpression) {
+		f.add(DeleteExpression, start, end, "assert(true)")
+	}
+	f.expression(start, end)
+}
+
+// isDeclaration reports whether the statement in [start, end) declares
+// variables. eq is the index of its top level '=', or -1.
+func (f *finder) isDeclaration(start, end, eq int) bool {
+	last := end - 1
+	if eq >= 0 {
+		last = eq - 1
+	}
+	if last-1 < start {
+		return false
+	}
+
+	// Tuple declarations e.g. '(uint a, , bool b) = f()'.
+	if f.tokens[start].Text == "(" && f.tokens[last].Text == ")" {
+		for i := start + 1; i < last; i++ {
+			if f.tokens[i].Kind == solidity.Identifier && f.tokens[i+1].Kind == solidity.Identifier {
+				return true
+			}
+		}
+		return false
+	}
+
+	name, before := f.tokens[last], f.tokens[last-1]
+	if name.Kind != solidity.Identifier {
+		return false
+	}
+	if before.Text == "delete" {
+		return false
+	}
+	return before.Kind == solidity.Identifier || before.Text == "]" || before.Text == ")"
+}
+
+// expression parses the tokens in [start, end) and mutates the operators in it.
+func (f *finder) expression(start, end int) {
+	if start >= end {
+		return
+	}
+	root, err := solidity.ParseExpression(f.tokens, start, end)
+	if err != nil {
+		// Unsupported syntax, the region is left unmutated.
+		return
+	}
+
+	root.Walk(func(node *solidity.Node) {
+		switch node.Kind {
+		case solidity.Binary:
+			op := f.tokens[node.Op].Text
+			if f.filter.allows(BinaryOp) {
+				for _, alternative := range binaryAlternatives[op] {
+					f.addMutation(Mutation{
+						Operator:    BinaryOp,
+						Start:       f.tokens[node.Op].Offset,
+						End:         f.tokens[node.Op].End(),
+						Original:    op,
+						Replacement: alternative,
+					})
+				}
+			}
+			if nonCommutative[op] && f.filter.allows(SwapArgumentsOperator) {
+				left, right := node.Children[0], node.Children[1]
+				f.add(SwapArgumentsOperator, node.Start, node.End, f.text(right.Start, right.End)+" "+op+" "+f.text(left.Start, left.End))
+			}
+			f.balanceCheck(node)
+		case solidity.Unary, solidity.Postfix:
+			f.unary(node)
+		case solidity.Assignment:
+			if f.tokens[node.Op].Text != "=" {
+				return
+			}
+			if kind := f.typeOf(node.Children[0]); kind != "" {
+				value := node.Children[1]
+				f.replaceWithConstants(Assignment, value.Start, value.End, constants[kind])
+			}
+		case solidity.Call:
+			callee := node.Children[0]
+			if callee.Kind == solidity.Primary && f.text(callee.Start, callee.End) == "require" && len(node.Children) > 1 && node.Children[1] != nil {
+				condition := node.Children[1]
+				f.replaceWithConstants(Require, condition.Start, condition.End, []string{"true", "false"})
+			}
+		}
+	})
+}
+
+func (f *finder) unary(node *solidity.Node) {
+	if !f.filter.allows(UnaryOperator) {
+		return
+	}
+	op := f.tokens[node.Op].Text
+	operand := f.text(node.Children[0].Start, node.Children[0].End)
+
+	var replacement string
+	switch op {
+	case "++", "--":
+		swapped := map[string]string{"++": "--", "--": "++"}[op]
+		if node.Kind == solidity.Unary {
+			replacement = swapped + operand
+		} else {
+			replacement = operand + swapped
+		}
+	case "!", "~", "-":
+		replacement = operand
+	default:
+		return
+	}
+	f.add(UnaryOperator, node.Start, node.End, replacement)
+}
+
+// typeOf returns the kind of an assigned variable if it is known.
+func (f *finder) typeOf(node *solidity.Node) string {
+	switch node.Kind {
+	case solidity.Primary:
+		if t, ok := f.types[f.text(node.Start, node.End)]; ok && !t.elem {
+			return t.kind
+		}
+	case solidity.Index:
+		base := node.Children[0]
+		for base.Kind == solidity.Index {
+			base = base.Children[0]
+		}
+		if base.Kind == solidity.Primary {
+			if t, ok := f.types[f.text(base.Start, base.End)]; ok && t.elem {
+				return t.kind
+			}
+		}
+	}
+	return ""
+}
+
+// replaceWithConstants replaces the code in [start, end) with each of the
+// values that differ from it.
+func (f *finder) replaceWithConstants(operator string, start, end int, values []string) {
+	if start >= end || !f.filter.allows(operator) {
+		return
+	}
+	original := f.text(start, end)
+	for _, value := range values {
+		if value != original {
+			f.add(operator, start, end, value)
+		}
+	}
+}
+
+// add records a mutation replacing the tokens in [start, end).
+func (f *finder) add(operator string, start, end int, replacement string) {
+	f.addMutation(Mutation{
+		Operator:    operator,
+		Start:       f.tokens[start].Offset,
+		End:         f.tokens[end-1].End(),
+		Original:    f.text(start, end),
+		Replacement: replacement,
+	})
+}
+
+func (f *finder) addMutation(m Mutation) {
+	m.Contract = f.contract
+	m.Function = f.function
+	f.mutations = append(f.mutations, m)
+}
+
+// text returns the source code of the tokens in [start, end).
+func (f *finder) text(start, end int) string {
+	return f.source[f.tokens[start].Offset:f.tokens[end-1].End()]
+}
+
+// matching returns the index of the token closing the one at open.
+func (f *finder) matching(open int, openText, closeText string) int {
+	depth := 0
+	for i := open; i < len(f.tokens); i++ {
+		switch f.tokens[i].Text {
+		case openText:
+			depth++
+		case closeText:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(f.tokens) - 1
+}
+
+// topLevel returns the index of the first token with the text in [start,
+// end) that is not nested in brackets, or -1.
+func (f *finder) topLevel(start, end int, text string) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		tok := f.tokens[i]
+		if tok.Kind != solidity.Punct {
+			continue
+		}
+		switch tok.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case text:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// statementEnd returns the index of the ';' ending the statement at start.
+func (f *finder) statementEnd(start, end int) int {
+	if semi := f.topLevel(start, end, ";"); semi >= 0 {
+		return semi
+	}
+	return end
+}
+
+// elementaryKind maps elementary type names to the kinds that have
+// replacement constants e.g. 'uint256' to "uint".
+func elementaryKind(typeName string) string {
+	switch {
+	case typeName == "bool":
+		return "bool"
+	case typeName == "uint" || (strings.HasPrefix(typeName, "uint") && isDigits(typeName[4:])):
+		return "uint"
+	case typeName == "int" || (strings.HasPrefix(typeName, "int") && isDigits(typeName[3:])):
+		return "int"
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// declarationKeywords may stand between the type and the name of a variable.
+var declarationKeywords = map[string]bool{
+	"memory": true, "storage": true, "calldata": true, "transient": true,
+	"public": true, "private": true, "internal": true, "external": true,
+	"constant": true, "immutable": true, "override": true, "payable": true,
+}
+
+// inferTypes finds the variables of elementary types (and arrays and
+// mappings of them) declared in [start, end]. Scopes are ignored, invalid
+// mutants caused by shadowing are caught by the validation.
+func inferTypes(tokens []solidity.Token, start, end int) map[string]varType {
+	types := make(map[string]varType)
+
+	for i := start; i <= end && i < len(tokens); i++ {
+		var t varType
+		next := i + 1
+
+		if tokens[i].Text == "mapping" {
+			// The value type follows the last '=>' e.g. 'mapping(address => mapping(uint => bool))'.
+			depth, arrow := 0, -1
+			for next = i + 1; next < len(tokens); next++ {
+				switch tokens[next].Text {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				case "=>":
+					arrow = next
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if arrow < 0 || arrow+1 >= len(tokens) {
+				continue
+			}
+			t = varType{kind: elementaryKind(tokens[arrow+1].Text), elem: true}
+			next++
+		} else {
+			t.kind = elementaryKind(tokens[i].Text)
+			for next+1 < len(tokens) && tokens[next].Text == "[" {
+				// Arrays e.g. 'uint256[]' or 'uint256[10]'.
+				closing := next + 1
+				for closing < len(tokens) && tokens[closing].Text != "]" {
+					closing++
+				}
+				t.elem = true
+				next = closing + 1
+			}
+		}
+		if t.kind == "" {
+			continue
+		}
+
+		for next < len(tokens) && declarationKeywords[tokens[next].Text] {
+			next++
+		}
+		if next+1 >= len(tokens) || tokens[next].Kind != solidity.Identifier {
+			continue
+		}
+		switch tokens[next+1].Text {
+		case ";", "=", ",", ")":
+			types[tokens[next].Text] = t
+		}
+	}
+
+	return types
+}
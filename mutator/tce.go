@@ -0,0 +1,186 @@
+package mutator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+)
+
+// Outcomes of trivial compiler equivalence.
+const (
+	Equivalent = "EQUIVALENT" // The mutant compiles to the same code as the original.
+	Duplicate  = "DUPLICATE"  // The mutant compiles to the same code as another mutant.
+)
+
+// Equivalence is a mutant that trivial compiler equivalence found to be
+// redundant. Testing it can't tell anything new about the test suite.
+type Equivalence struct {
+	ID          string
+	Original    string // e.g. "src/Vault.sol"
+	Status      string // Equivalent or Duplicate.
+	DuplicateOf string // ID of the first mutant with the same code, for duplicates.
+}
+
+// TCE applies trivial compiler equivalence to the mutants in outdir: every
+// file is compiled with the optimizer, once as the original and once per
+// mutant, and the runtime bytecode of its contracts is compared without the
+// metadata. Mutants that don't compile, and the mutants of files whose
+// original doesn't compile, are left out.
+func TCE(outdir string, entries []Entry, mutants []Result, opts Options) ([]Equivalence, error) {
+	if opts.Log == nil {
+		opts.Log = os.Stdout
+	}
+	if opts.Compile == nil {
+		opts.Compile = solc.Compile
+	}
+
+	byFile := make(map[string][]Result)
+	var files []string
+	for _, mutant := range mutants {
+		if _, ok := byFile[mutant.Original]; !ok {
+			files = append(files, mutant.Original)
+		}
+		byFile[mutant.Original] = append(byFile[mutant.Original], mutant)
+	}
+	sort.Strings(files)
+
+	var equivalences []Equivalence
+	for _, file := range files {
+		entry := Entry{Filename: file}
+		for _, e := range entries {
+			if filepath.Clean(e.Filename) == filepath.Clean(file) {
+				entry = e
+				break
+			}
+		}
+
+		found, err := fileEquivalences(outdir, entry, file, byFile[file], opts)
+		if err != nil {
+			return nil, err
+		}
+		equivalences = append(equivalences, found...)
+	}
+	return equivalences, nil
+}
+
+// fileEquivalences compares the mutants of a single file, in the order of
+// their IDs so that the first of a group of duplicates is the one kept.
+func fileEquivalences(outdir string, entry Entry, file string, mutants []Result, opts Options) ([]Equivalence, error) {
+	binary := entry.Solc
+	if binary == "" {
+		binary = "solc"
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		fmt.Fprintf(opts.Log, "\033[33m[Warning] Couldn't find solc ('%s') to compare the mutants of %s, they are all kept.\033[0m\n", binary, file)
+		return nil, nil
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read '%s': %w", file, err)
+	}
+	original, reason := runtimeBytecode(binary, entry, file, string(source), opts)
+	if reason != "" {
+		fmt.Fprintf(opts.Log, "\033[33m[Warning] %s doesn't compile with %s (%s), its mutants are all kept.\033[0m\n", file, binary, reason)
+		return nil, nil
+	}
+
+	sort.SliceStable(mutants, func(i, j int) bool { return LessID(mutants[i].ID, mutants[j].ID) })
+
+	var equivalences []Equivalence
+	seen := make(map[string]string) // Bytecode to the ID of the first mutant with it.
+	for _, mutant := range mutants {
+		content, err := ReadMutant(filepath.Join(outdir, mutant.Name), file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read mutant %s: %w", mutant.ID, err)
+		}
+		bytecode, reason := runtimeBytecode(binary, entry, file, string(content), opts)
+		switch {
+		case reason != "":
+			continue
+		case bytecode == original:
+			equivalences = append(equivalences, Equivalence{ID: mutant.ID, Original: file, Status: Equivalent})
+		case seen[bytecode] != "":
+			equivalences = append(equivalences, Equivalence{ID: mutant.ID, Original: file, Status: Duplicate, DuplicateOf: seen[bytecode]})
+		default:
+			seen[bytecode] = mutant.ID
+		}
+	}
+	fmt.Fprintf(opts.Log, "[Info] %s: %d mutants compared, %d equivalent or duplicate.\n", file, len(mutants), len(equivalences))
+	return equivalences, nil
+}
+
+// runtimeBytecode compiles the file with the given content and returns the
+// runtime bytecode of its contracts without the metadata. If the file doesn't
+// compile, the reason is returned instead.
+func runtimeBytecode(binary string, entry Entry, file, content string, opts Options) (string, string) {
+	basePath := entry.SolcBasePath
+	if basePath == "" {
+		basePath = "."
+	}
+	output, err := opts.Compile(binary, solc.Input{
+		Sources: map[string]solc.Source{file: {Content: content}},
+		Settings: solc.Settings{
+			Remappings: entry.SolcRemappings,
+			Optimizer:  solc.Optimizer{Enabled: true, Runs: 200},
+			ViaIR:      entry.SolcViaIR,
+			// Without the source hash, contracts created with 'new' embed the
+			// same creation code whatever the comments of the file are.
+			Metadata:        &solc.Metadata{BytecodeHash: "none"},
+			OutputSelection: map[string]map[string][]string{file: {"*": {"evm.deployedBytecode.object"}}},
+		},
+	}, solc.Options{BasePath: basePath, AllowPaths: entry.SolcAllowPaths})
+	if err != nil {
+		return "", err.Error()
+	}
+	if diagnostic, failed := output.FirstError(); failed {
+		return "", diagnostic.Message
+	}
+
+	contracts := output.Contracts[file]
+	names := make([]string, 0, len(contracts))
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var bytecode strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&bytecode, "%s:%s\n", name, stripMetadata(contracts[name].EVM.DeployedBytecode.Object))
+	}
+	return bytecode.String(), ""
+}
+
+// stripMetadata removes the CBOR encoded metadata that solc appends to the
+// bytecode. Its length is stored in the last two bytes.
+func stripMetadata(bytecode string) string {
+	if len(bytecode) < 4 {
+		return bytecode
+	}
+	length, err := strconv.ParseUint(bytecode[len(bytecode)-4:], 16, 16)
+	if err != nil {
+		return bytecode
+	}
+	start := len(bytecode) - 4 - 2*int(length)
+	// The metadata is a CBOR map, its first byte is 0xa1 to 0xb7.
+	if length == 0 || start < 0 || bytecode[start] != 'a' && bytecode[start] != 'b' {
+		return bytecode
+	}
+	return bytecode[:start]
+}
+
+// LessID orders numeric mutant IDs by value and the others as strings.
+func LessID(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
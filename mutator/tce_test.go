@@ -0,0 +1,93 @@
+package mutator
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ChmielewskiKamil/checkmate/solc"
+)
+
+func TestTCE(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.MkdirAll("src", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("src/Vault.sol", []byte(vaultSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("solc", []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	mutants := []Mutant{
+		// Parentheses don't change the code.
+		Apply("src/Vault.sol", vaultSource, Mutation{Operator: "ParenthesesMutation", Start: strings.Index(vaultSource, "paused;"), End: strings.Index(vaultSource, "paused;") + 6, Original: "paused", Replacement: "(paused)"}),
+		Apply("src/Vault.sol", vaultSource, Mutation{Operator: UnaryOperator, Start: strings.Index(vaultSource, "!paused"), End: strings.Index(vaultSource, "!paused") + 7, Original: "!paused", Replacement: "paused"}),
+		Apply("src/Vault.sol", vaultSource, Mutation{Operator: UnaryOperator, Start: strings.Index(vaultSource, "!paused"), End: strings.Index(vaultSource, "!paused") + 7, Original: "!paused", Replacement: "(paused)"}),
+		Apply("src/Vault.sol", vaultSource, Mutation{Operator: "BrokenMutation", Start: strings.Index(vaultSource, "i++"), End: strings.Index(vaultSource, "i++") + 3, Original: "i++", Replacement: "i+++"}),
+	}
+	results, err := Write(DefaultOutdir, mutants)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake compiler's bytecode ignores comments, whitespace and parentheses,
+	// and ends with metadata that differs on every compilation.
+	compilations := 0
+	compile := func(binary string, input solc.Input, opts solc.Options) (*solc.Output, error) {
+		if !input.Settings.Optimizer.Enabled || input.Settings.Metadata == nil || input.Settings.Metadata.BytecodeHash != "none" {
+			t.Errorf("unexpected settings %+v", input.Settings)
+		}
+		content := input.Sources["src/Vault.sol"].Content
+		if strings.Contains(content, "i+++") {
+			return &solc.Output{Errors: []solc.Diagnostic{{Severity: "error", Message: "ParserError"}}}, nil
+		}
+
+		var code strings.Builder
+		for _, line := range strings.Split(content, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "///") {
+				code.WriteString(strings.NewReplacer(" ", "", "(", "", ")", "").Replace(line))
+			}
+		}
+		compilations++
+		metadata := fmt.Sprintf("a1%04x", compilations)
+		bytecode := hex.EncodeToString([]byte(code.String())) + metadata + fmt.Sprintf("%04x", len(metadata)/2)
+
+		output := &solc.Output{Contracts: map[string]map[string]struct{ EVM solc.EVMOutput }{"src/Vault.sol": {}}}
+		var contract struct{ EVM solc.EVMOutput }
+		contract.EVM.DeployedBytecode.Object = bytecode
+		output.Contracts["src/Vault.sol"]["Vault"] = contract
+		return output, nil
+	}
+
+	var log strings.Builder
+	equivalences, err := TCE(DefaultOutdir, []Entry{{Filename: "src/Vault.sol", Solc: "./solc"}}, results, Options{Log: &log, Compile: compile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Equivalence{
+		{ID: results[0].ID, Original: "src/Vault.sol", Status: Equivalent},
+		{ID: results[2].ID, Original: "src/Vault.sol", Status: Duplicate, DuplicateOf: results[1].ID},
+	}
+	if fmt.Sprint(equivalences) != fmt.Sprint(want) {
+		t.Fatalf("got %+v, want %+v\n%s", equivalences, want, log.String())
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	for _, tt := range []struct{ bytecode, want string }{
+		{"6080604052a2646970667358221220aabb000c", "6080604052"},
+		{"6080604052", "6080604052"},
+		{"60806040520005", "60806040520005"},
+		{"", ""},
+	} {
+		if got := stripMetadata(tt.bytecode); got != tt.want {
+			t.Errorf("stripMetadata(%q) = %q, want %q", tt.bytecode, got, tt.want)
+		}
+	}
+}
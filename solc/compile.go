@@ -0,0 +1,119 @@
+package solc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Input is solc's standard JSON input, limited to the options checkmate uses.
+type Input struct {
+	Language string            `json:"language"`
+	Sources  map[string]Source `json:"sources"`
+	Settings Settings          `json:"settings"`
+}
+
+// Source is a source file of the standard JSON input. Files without
+// content are read from disk by solc.
+type Source struct {
+	Content string   `json:"content,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+}
+
+type Settings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       Optimizer                      `json:"optimizer"`
+	ViaIR           bool                           `json:"viaIR,omitempty"`
+	Metadata        *Metadata                      `json:"metadata,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+type Optimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs,omitempty"`
+}
+
+type Metadata struct {
+	// BytecodeHash selects the hash appended to the bytecode, "none" drops it.
+	BytecodeHash string `json:"bytecodeHash,omitempty"`
+	// AppendCBOR set to false drops the whole metadata section (solc >=0.8.18).
+	AppendCBOR *bool `json:"appendCBOR,omitempty"`
+}
+
+// Output is solc's standard JSON output, limited to the fields checkmate reads.
+type Output struct {
+	Errors    []Diagnostic                                  `json:"errors"`
+	Contracts map[string]map[string]struct{ EVM EVMOutput } `json:"contracts"`
+}
+
+type Diagnostic struct {
+	Severity         string `json:"severity"` // "error", "warning" or "info".
+	Type             string `json:"type"`     // e.g. "TypeError", "ParserError".
+	Message          string `json:"message"`
+	FormattedMessage string `json:"formattedMessage"`
+}
+
+type EVMOutput struct {
+	DeployedBytecode struct {
+		Object string `json:"object"`
+	} `json:"deployedBytecode"`
+}
+
+// Options are the command line options of a compilation.
+type Options struct {
+	BasePath   string   // Passed as --base-path, imports are resolved relative to it.
+	AllowPaths []string // Passed as --allow-paths.
+}
+
+// Compile runs the solc binary with the standard JSON input and returns its
+// output. Compilation errors are reported in Output.Errors, err is only set
+// if solc couldn't be run or its output couldn't be read.
+func Compile(binary string, input Input, opts Options) (*Output, error) {
+	if input.Language == "" {
+		input.Language = "Solidity"
+	}
+	if input.Settings.OutputSelection == nil {
+		// Without outputs solc only parses and analyzes the sources.
+		input.Settings.OutputSelection = map[string]map[string][]string{}
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode the solc input: %w", err)
+	}
+
+	args := []string{"--standard-json"}
+	if opts.BasePath != "" {
+		args = append(args, "--base-path", opts.BasePath)
+	}
+	if len(opts.AllowPaths) > 0 {
+		args = append(args, "--allow-paths", strings.Join(opts.AllowPaths, ","))
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil && len(stdout) == 0 {
+		return nil, fmt.Errorf("Failed to run %s: %v %s", binary, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output Output
+	if err := json.Unmarshal(stdout, &output); err != nil {
+		return nil, fmt.Errorf("Failed to read the output of %s: %w", binary, err)
+	}
+	return &output, nil
+}
+
+// FirstError returns the first diagnostic with the "error" severity.
+func (o *Output) FirstError() (Diagnostic, bool) {
+	for _, diagnostic := range o.Errors {
+		if diagnostic.Severity == "error" {
+			return diagnostic, true
+		}
+	}
+	return Diagnostic{}, false
+}
@@ -0,0 +1,194 @@
+package solc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Installation is a solc binary available on the machine.
+type Installation struct {
+	Version Version
+	Path    string
+	Source  string // Where the binary was found e.g. "solc-select", "svm" or "PATH".
+}
+
+// versionInNameRegex finds the version in the name of a binary or its
+// folder e.g. "solc-v0.8.23", "solc-0.8.23" or "0.8.23".
+var versionInNameRegex = regexp.MustCompile(`(?:^|[-v])(\d+\.\d+\.\d+)(?:[+-]|$)`)
+
+// versionOutputRegex finds the version in the output of 'solc --version'.
+var versionOutputRegex = regexp.MustCompile(`Version: (\d+\.\d+\.\d+)`)
+
+// Installed lists the compilers managed by solc-select and svm, the ones in
+// binDir (if not empty) and the 'solc' on the PATH. For each version only the
+// first binary is kept, in the order: binDir, solc-select, svm, PATH.
+func Installed(binDir string) ([]Installation, error) {
+	var all []Installation
+
+	if binDir != "" {
+		if _, err := os.Stat(binDir); err != nil {
+			return nil, fmt.Errorf("The solc binaries directory '%s' is not accessible: %w", binDir, err)
+		}
+		all = append(all, scanDir(binDir, "solc binaries directory")...)
+	}
+	for _, dir := range solcSelectDirs() {
+		all = append(all, scanDir(dir, "solc-select")...)
+	}
+	for _, dir := range svmDirs() {
+		all = append(all, scanDir(dir, "svm")...)
+	}
+	if onPath, ok := solcOnPath(); ok {
+		all = append(all, onPath)
+	}
+
+	seen := make(map[Version]bool)
+	var installations []Installation
+	for _, installation := range all {
+		if seen[installation.Version] {
+			continue
+		}
+		seen[installation.Version] = true
+		installations = append(installations, installation)
+	}
+
+	return installations, nil
+}
+
+func solcSelectDirs() []string {
+	var dirs []string
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		dirs = append(dirs, filepath.Join(venv, ".solc-select", "artifacts"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".solc-select", "artifacts"))
+	}
+	return dirs
+}
+
+func svmDirs() []string {
+	var dirs []string
+	if svmHome := os.Getenv("SVM_HOME"); svmHome != "" {
+		dirs = append(dirs, svmHome)
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "svm"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".svm"),
+			filepath.Join(home, ".local", "share", "svm"),
+			filepath.Join(home, "Library", "Application Support", "svm"),
+		)
+	}
+	return dirs
+}
+
+// scanDir finds the solc binaries in dir. Both flat layouts ('solc-0.8.23')
+// and one folder per version ('0.8.23/solc-0.8.23', used by solc-select and
+// svm) are supported.
+func scanDir(dir, source string) []Installation {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var installations []Installation
+	for _, entry := range entries {
+		match := versionInNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := ParseVersion(match[1])
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			binary, ok := findBinary(path)
+			if !ok {
+				continue
+			}
+			path = binary
+		} else if !isExecutable(path) {
+			continue
+		}
+
+		installations = append(installations, Installation{Version: version, Path: path, Source: source})
+	}
+
+	sort.Slice(installations, func(i, j int) bool {
+		return installations[i].Version.Compare(installations[j].Version) < 0
+	})
+	return installations
+}
+
+// findBinary returns the solc binary inside a per-version folder.
+func findBinary(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "solc") && isExecutable(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// solcOnPath returns the 'solc' binary on the PATH. solc-select installs a
+// wrapper there, which reports the currently selected version.
+func solcOnPath() (Installation, bool) {
+	path, err := exec.LookPath("solc")
+	if err != nil {
+		return Installation{}, false
+	}
+	output, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return Installation{}, false
+	}
+	match := versionOutputRegex.FindStringSubmatch(string(output))
+	if match == nil {
+		return Installation{}, false
+	}
+	version, err := ParseVersion(match[1])
+	if err != nil {
+		return Installation{}, false
+	}
+	return Installation{Version: version, Path: path, Source: "PATH"}, true
+}
+
+// Select returns the newest installation that satisfies the range. A
+// preferred version (e.g. pinned in foundry.toml) wins if it satisfies the
+// range as well.
+func Select(r Range, installations []Installation, preferred *Version) (Installation, bool) {
+	var best Installation
+	found := false
+
+	for _, installation := range installations {
+		if !r.Matches(installation.Version) {
+			continue
+		}
+		if preferred != nil && installation.Version == *preferred {
+			return installation, true
+		}
+		if !found || installation.Version.Compare(best.Version) > 0 {
+			best = installation
+			found = true
+		}
+	}
+
+	return best, found
+}
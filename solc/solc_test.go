@@ -0,0 +1,153 @@
+package solc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustVersion(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := ParseVersion(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestRangeMatches(t *testing.T) {
+	tests := []struct {
+		pragma   string
+		matching []string
+		other    []string
+	}{
+		{"^0.8.0", []string{"0.8.0", "0.8.23"}, []string{"0.7.6", "0.9.0"}},
+		{"^0.8", []string{"0.8.0", "0.8.30"}, []string{"0.9.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~0.8.4", []string{"0.8.4", "0.8.29"}, []string{"0.8.3", "0.9.0"}},
+		{"0.8.19", []string{"0.8.19"}, []string{"0.8.18", "0.8.20"}},
+		{"=0.8.19", []string{"0.8.19"}, []string{"0.8.20"}},
+		{">=0.6.2 <0.8.0", []string{"0.6.2", "0.7.6"}, []string{"0.6.1", "0.8.0"}},
+		{">= 0.8.0 < 0.8.20", []string{"0.8.19"}, []string{"0.8.20"}},
+		{">0.8.19 <=0.8.21", []string{"0.8.20", "0.8.21"}, []string{"0.8.19", "0.8.22"}},
+		{"<=0.8", []string{"0.8.30"}, []string{"0.9.0"}},
+		{"0.8.0 - 0.8.20", []string{"0.8.0", "0.8.20"}, []string{"0.7.6", "0.8.21"}},
+		{"0.6.12 || ^0.8.0", []string{"0.6.12", "0.8.1"}, []string{"0.7.0"}},
+		{"0.8.x", []string{"0.8.5"}, []string{"0.9.0"}},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.pragma)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.pragma, err)
+			continue
+		}
+		for _, v := range tt.matching {
+			if !r.Matches(mustVersion(t, v)) {
+				t.Errorf("%q should match %s", tt.pragma, v)
+			}
+		}
+		for _, v := range tt.other {
+			if r.Matches(mustVersion(t, v)) {
+				t.Errorf("%q should not match %s", tt.pragma, v)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"", "^0.8.a", "0.8.0.1", "> "} {
+		if _, err := ParseRange(invalid); err == nil {
+			t.Errorf("ParseRange(%q): expected an error", invalid)
+		}
+	}
+}
+
+func TestPragmaRange(t *testing.T) {
+	source := `// SPDX-License-Identifier: MIT
+// pragma solidity 0.4.0;
+/* pragma solidity 0.5.0; */
+pragma solidity >=0.8.0;
+pragma solidity <0.8.20;
+pragma abicoder v2;
+
+contract A {}
+`
+	r, ok, err := PragmaRange(source)
+	if err != nil || !ok {
+		t.Fatalf("expected a range, got ok=%v err=%v", ok, err)
+	}
+	if !r.Matches(mustVersion(t, "0.8.19")) || r.Matches(mustVersion(t, "0.8.20")) || r.Matches(mustVersion(t, "0.5.0")) {
+		t.Fatalf("unexpected range %q", r)
+	}
+
+	if _, ok, _ := PragmaRange("contract A {}"); ok {
+		t.Fatal("expected no range for a file without a pragma")
+	}
+}
+
+func TestInstalledAndSelect(t *testing.T) {
+	home := t.TempDir()
+	binDir := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SVM_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("PATH", "")
+
+	executable := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	executable(filepath.Join(home, ".solc-select", "artifacts", "solc-0.8.19", "solc-0.8.19"))
+	executable(filepath.Join(home, ".svm", "0.8.23", "solc-0.8.23"))
+	executable(filepath.Join(home, ".svm", "0.7.6", "solc-0.7.6"))
+	executable(filepath.Join(binDir, "solc-v0.6.12+commit.27d51765"))
+	executable(filepath.Join(binDir, "solc-0.8.19"))
+	// Not executable, skipped.
+	if err := os.WriteFile(filepath.Join(binDir, "solc-0.8.25"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	installations, err := Installed(binDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]string)
+	for _, installation := range installations {
+		sources[installation.Version.String()] = installation.Source
+	}
+	want := map[string]string{
+		"0.6.12": "solc binaries directory",
+		"0.8.19": "solc binaries directory",
+		"0.7.6":  "svm",
+		"0.8.23": "svm",
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("got %v, want %v", sources, want)
+	}
+	for version, source := range want {
+		if sources[version] != source {
+			t.Errorf("version %s: got source %q, want %q", version, sources[version], source)
+		}
+	}
+
+	caret, _ := ParseRange("^0.8.0")
+	if selected, ok := Select(caret, installations, nil); !ok || selected.Version.String() != "0.8.23" {
+		t.Errorf("expected the newest matching version 0.8.23, got %v", selected)
+	}
+	pinned := mustVersion(t, "0.8.19")
+	if selected, ok := Select(caret, installations, &pinned); !ok || selected.Version != pinned {
+		t.Errorf("expected the pinned version 0.8.19, got %v", selected)
+	}
+	old, _ := ParseRange("^0.5.0")
+	if _, ok := Select(old, installations, nil); ok {
+		t.Error("expected no compiler for ^0.5.0")
+	}
+
+	if _, err := Installed(filepath.Join(binDir, "missing")); err == nil {
+		t.Error("expected an error for a missing binaries directory")
+	}
+}
@@ -0,0 +1,283 @@
+// Package solc selects the Solidity compiler for each source file from its
+// 'pragma solidity' version range and the compilers installed on the machine.
+package solc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a solc release version e.g. 0.8.23.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if v is lower, equal or greater than other.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		} else if diff > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseVersion parses a full version like "0.8.23" or "v0.8.23+commit.f704f362".
+func ParseVersion(s string) (Version, error) {
+	parts, wildcard, err := parseParts(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if len(parts) != 3 || wildcard {
+		return Version{}, fmt.Errorf("'%s' is not a full version", s)
+	}
+	return Version{parts[0], parts[1], parts[2]}, nil
+}
+
+// parseParts parses a possibly partial version like "0.8" or "0.8.x". The
+// wildcard result reports whether the version ended with 'x' or '*'.
+func parseParts(s string) ([]int, bool, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if build := strings.IndexAny(s, "+-"); build >= 0 {
+		s = s[:build]
+	}
+
+	var parts []int
+	wildcard := false
+	for _, field := range strings.Split(s, ".") {
+		if wildcard {
+			return nil, false, fmt.Errorf("invalid version '%s'", s)
+		}
+		if field == "x" || field == "X" || field == "*" {
+			wildcard = true
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false, fmt.Errorf("invalid version '%s'", s)
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) > 3 {
+		return nil, false, fmt.Errorf("invalid version '%s'", s)
+	}
+
+	return parts, wildcard, nil
+}
+
+// comparator is a single condition of a range e.g. ">=0.8.0".
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Range is a version range in the syntax of 'pragma solidity' e.g.
+// "^0.8.0", ">=0.7.0 <0.9.0" or "0.6.12 || ^0.8.0".
+type Range struct {
+	raw string
+	// Alternatives separated with '||', each a list of conditions that must
+	// all hold.
+	sets [][]comparator
+}
+
+func (r Range) String() string {
+	return r.raw
+}
+
+// Matches reports whether v satisfies the range.
+func (r Range) Matches(v Version) bool {
+	for _, set := range r.sets {
+		matches := true
+		for _, c := range set {
+			if !c.matches(v) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionRegex splits a condition into its operator and version. solc
+// allows whitespace between them e.g. ">= 0.8.0".
+var conditionRegex = regexp.MustCompile(`^(\^|~|>=|<=|>|<|=)?\s*(\S+)$`)
+
+// ParseRange parses the version range of a 'pragma solidity' directive.
+func ParseRange(s string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(s)}
+
+	for _, alternative := range strings.Split(s, "||") {
+		// Glue operators to their versions, then split the conditions.
+		fields := strings.Fields(alternative)
+		var conditions []string
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+			if strings.Trim(field, "^~<>=") == "" && i+1 < len(fields) {
+				field += fields[i+1]
+				i++
+			}
+			conditions = append(conditions, field)
+		}
+		if len(conditions) == 0 {
+			return r, fmt.Errorf("empty version range in '%s'", s)
+		}
+
+		var set []comparator
+		if len(conditions) == 3 && conditions[1] == "-" {
+			// Hyphen range e.g. "0.8.0 - 0.8.20".
+			low, err := expand(">=", conditions[0])
+			if err != nil {
+				return r, err
+			}
+			high, err := expand("<=", conditions[2])
+			if err != nil {
+				return r, err
+			}
+			set = append(low, high...)
+		} else {
+			for _, condition := range conditions {
+				match := conditionRegex.FindStringSubmatch(condition)
+				if match == nil {
+					return r, fmt.Errorf("invalid version condition '%s'", condition)
+				}
+				comparators, err := expand(match[1], match[2])
+				if err != nil {
+					return r, err
+				}
+				set = append(set, comparators...)
+			}
+		}
+		r.sets = append(r.sets, set)
+	}
+
+	return r, nil
+}
+
+// expand turns a single condition into comparators, resolving partial
+// versions and the caret and tilde operators the way solc does.
+func expand(op, version string) ([]comparator, error) {
+	parts, _, err := parseParts(version)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		// "*" matches everything.
+		return []comparator{{">=", Version{}}}, nil
+	}
+
+	low := Version{}
+	fields := []*int{&low.Major, &low.Minor, &low.Patch}
+	for i, n := range parts {
+		*fields[i] = n
+	}
+
+	// The first version that no longer matches a partial version e.g. 0.9.0 for 0.8.
+	next := func(level int) Version {
+		switch level {
+		case 0:
+			return Version{low.Major + 1, 0, 0}
+		case 1:
+			return Version{low.Major, low.Minor + 1, 0}
+		default:
+			return Version{low.Major, low.Minor, low.Patch + 1}
+		}
+	}
+
+	switch op {
+	case "^":
+		// Changes to the left-most non-zero part are incompatible.
+		level := 0
+		for level < len(parts)-1 && parts[level] == 0 {
+			level++
+		}
+		return []comparator{{">=", low}, {"<", next(level)}}, nil
+	case "~":
+		level := len(parts) - 1
+		if level > 1 {
+			level = 1
+		}
+		return []comparator{{">=", low}, {"<", next(level)}}, nil
+	case ">":
+		return []comparator{{">=", next(len(parts) - 1)}}, nil
+	case "<=":
+		return []comparator{{"<", next(len(parts) - 1)}}, nil
+	case ">=", "<":
+		return []comparator{{op, low}}, nil
+	default:
+		if len(parts) == 3 {
+			return []comparator{{"=", low}}, nil
+		}
+		return []comparator{{">=", low}, {"<", next(len(parts) - 1)}}, nil
+	}
+}
+
+var (
+	pragmaRegex       = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+	lineCommentRegex  = regexp.MustCompile(`//[^\n]*`)
+	blockCommentRegex = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+// PragmaRange returns the combined version range of all 'pragma solidity'
+// directives in the source. ok is false if the source has none.
+func PragmaRange(source string) (r Range, ok bool, err error) {
+	source = blockCommentRegex.ReplaceAllString(source, "")
+	source = lineCommentRegex.ReplaceAllString(source, "")
+
+	matches := pragmaRegex.FindAllStringSubmatch(source, -1)
+	if len(matches) == 0 {
+		return r, false, nil
+	}
+
+	for i, match := range matches {
+		parsed, err := ParseRange(match[1])
+		if err != nil {
+			return r, true, fmt.Errorf("invalid 'pragma solidity %s': %w", strings.TrimSpace(match[1]), err)
+		}
+		if i == 0 {
+			r = parsed
+			continue
+		}
+		// Several pragmas must all hold.
+		r = intersect(r, parsed)
+	}
+
+	return r, true, nil
+}
+
+func intersect(a, b Range) Range {
+	result := Range{raw: a.raw + " " + b.raw}
+	for _, setA := range a.sets {
+		for _, setB := range b.sets {
+			set := append(append([]comparator{}, setA...), setB...)
+			result.sets = append(result.sets, set)
+		}
+	}
+	return result
+}
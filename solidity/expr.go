@@ -0,0 +1,385 @@
+package solidity
+
+import "fmt"
+
+// NodeKind is the kind of an expression node.
+type NodeKind int
+
+const (
+	Primary     NodeKind = iota // Identifiers, literals, parenthesized expressions and other atoms.
+	Binary                      // 'a + b', 'a < b', 'a && b', ...
+	Unary                       // Prefix operators '!a', '-a', '++a', 'delete a', ...
+	Postfix                     // 'a++', 'a--'.
+	Assignment                  // 'a = b', 'a += b', ...
+	Conditional                 // 'c ? a : b'.
+	Call                        // 'f(a, b)', including type conversions e.g. 'uint256(x)'.
+	Index                       // 'a[i]', 'a[i:j]'.
+	Member                      // 'a.b'.
+	Tuple                       // '(a, b)' and inline arrays '[a, b]'.
+)
+
+// Node is an expression of a source file. Positions are token indices.
+type Node struct {
+	Kind  NodeKind
+	Start int // Index of the first token of the expression.
+	End   int // Index after the last token of the expression.
+	Op    int // Index of the operator token, -1 if there is none.
+
+	// Children, depending on the kind: the operands of operators, the callee
+	// and arguments of calls, the base and index of index access, the base of
+	// member access and the components of tuples.
+	Children []*Node
+}
+
+// binaryPrecedence of Solidity's binary operators, higher binds tighter.
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, ">": 4, "<=": 4, ">=": 4,
+	"|":  5,
+	"^":  6,
+	"&":  7,
+	"<<": 8, ">>": 8, ">>>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+	"**": 11,
+}
+
+var assignmentOps = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"|=": true, "&=": true, "^=": true, "<<=": true, ">>=": true, ">>>=": true,
+}
+
+// numberUnits may follow number literals e.g. '1 ether', '2 days'.
+var numberUnits = map[string]bool{
+	"wei": true, "gwei": true, "ether": true,
+	"seconds": true, "minutes": true, "hours": true, "days": true, "weeks": true,
+}
+
+type exprParser struct {
+	tokens []Token
+	pos    int
+	end    int
+}
+
+// ParseExpression parses the tokens in [start, end) as a single expression.
+func ParseExpression(tokens []Token, start, end int) (*Node, error) {
+	p := &exprParser{tokens: tokens, pos: start, end: end}
+	node, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != end {
+		return nil, p.errorf("unexpected '%s'", p.tokens[p.pos].Text)
+	}
+	return node, nil
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].Line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].Line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// peek returns the text of the current token if it is punctuation or an
+// identifier, so that string literals never look like operators.
+func (p *exprParser) peek() string {
+	if p.pos >= p.end || p.tokens[p.pos].Kind == String {
+		return ""
+	}
+	return p.tokens[p.pos].Text
+}
+
+func (p *exprParser) expect(text string) error {
+	if p.peek() != text {
+		if p.pos >= p.end {
+			return p.errorf("expected '%s' but the expression ended", text)
+		}
+		return p.errorf("expected '%s', got '%s'", text, p.tokens[p.pos].Text)
+	}
+	p.pos++
+	return nil
+}
+
+// expression parses assignments and conditionals, both right associative.
+func (p *exprParser) expression() (*Node, error) {
+	start := p.pos
+	cond, err := p.binary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() == "?" {
+		op := p.pos
+		p.pos++
+		whenTrue, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		whenFalse, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: Conditional, Start: start, End: p.pos, Op: op, Children: []*Node{cond, whenTrue, whenFalse}}, nil
+	}
+
+	if assignmentOps[p.peek()] {
+		op := p.pos
+		p.pos++
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: Assignment, Start: start, End: p.pos, Op: op, Children: []*Node{cond, value}}, nil
+	}
+
+	return cond, nil
+}
+
+// binary parses binary operators with at least minPrecedence.
+func (p *exprParser) binary(minPrecedence int) (*Node, error) {
+	start := p.pos
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		precedence, ok := binaryPrecedence[p.peek()]
+		if !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		op := p.pos
+		p.pos++
+
+		next := precedence + 1
+		if p.tokens[op].Text == "**" {
+			next = precedence // Right associative.
+		}
+		right, err := p.binary(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &Node{Kind: Binary, Start: start, End: p.pos, Op: op, Children: []*Node{left, right}}
+	}
+}
+
+func (p *exprParser) unary() (*Node, error) {
+	switch p.peek() {
+	case "!", "~", "-", "++", "--", "delete":
+		start := p.pos
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: Unary, Start: start, End: p.pos, Op: start, Children: []*Node{operand}}, nil
+	}
+	return p.postfix()
+}
+
+func (p *exprParser) postfix() (*Node, error) {
+	start := p.pos
+	node, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek() {
+		case "++", "--":
+			op := p.pos
+			p.pos++
+			node = &Node{Kind: Postfix, Start: start, End: p.pos, Op: op, Children: []*Node{node}}
+		case ".":
+			p.pos++
+			if p.pos >= p.end || p.tokens[p.pos].Kind != Identifier {
+				return nil, p.errorf("expected a member name after '.'")
+			}
+			p.pos++
+			node = &Node{Kind: Member, Start: start, End: p.pos, Op: -1, Children: []*Node{node}}
+		case "(":
+			args, err := p.list("(", ")")
+			if err != nil {
+				return nil, err
+			}
+			node = &Node{Kind: Call, Start: start, End: p.pos, Op: -1, Children: append([]*Node{node}, args...)}
+		case "[":
+			p.pos++
+			children := []*Node{node}
+			// Array types e.g. 'uint256[]' and slices e.g. 'a[1:]' have empty parts.
+			for p.peek() != "]" {
+				if p.peek() == ":" {
+					p.pos++
+					continue
+				}
+				index, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, index)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &Node{Kind: Index, Start: start, End: p.pos, Op: -1, Children: children}
+		case "{":
+			// Call options e.g. 'addr.call{value: amount}("")'.
+			if err := p.skipBalanced("{", "}"); err != nil {
+				return nil, err
+			}
+			node = &Node{Kind: Primary, Start: start, End: p.pos, Op: -1}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *exprParser) primary() (*Node, error) {
+	if p.pos >= p.end {
+		return nil, p.errorf("expected an expression")
+	}
+	start := p.pos
+	tok := p.tokens[p.pos]
+
+	switch {
+	case tok.Kind == Number:
+		p.pos++
+		if numberUnits[p.peek()] {
+			p.pos++
+		}
+	case tok.Kind == String:
+		// Adjacent string literals are concatenated.
+		for p.pos < p.end && p.tokens[p.pos].Kind == String {
+			p.pos++
+		}
+	case tok.Kind == Identifier && (tok.Text == "hex" || tok.Text == "unicode") && p.pos+1 < p.end && p.tokens[p.pos+1].Kind == String:
+		p.pos += 2
+	case tok.Kind == Identifier && tok.Text == "new":
+		p.pos++
+		if p.pos >= p.end || p.tokens[p.pos].Kind != Identifier {
+			return nil, p.errorf("expected a type after 'new'")
+		}
+		p.pos++
+		for p.peek() == "." && p.pos+1 < p.end {
+			p.pos += 2
+		}
+		for p.peek() == "[" {
+			if err := p.skipBalanced("[", "]"); err != nil {
+				return nil, err
+			}
+		}
+	case tok.Kind == Identifier && tok.Text == "mapping":
+		// Only valid in type expressions, skip it as a whole.
+		p.pos++
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return nil, err
+		}
+	case tok.Kind == Identifier:
+		p.pos++
+		// Function types and storage locations in type expressions e.g. 'address payable'.
+		if tok.Text == "address" && p.peek() == "payable" {
+			p.pos++
+		}
+	case tok.Text == "(":
+		components, err := p.list("(", ")")
+		if err != nil {
+			return nil, err
+		}
+		// Parenthesized expressions are tuples with a single component.
+		return &Node{Kind: Tuple, Start: start, End: p.pos, Op: -1, Children: components}, nil
+	case tok.Text == "[":
+		components, err := p.list("[", "]")
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: Tuple, Start: start, End: p.pos, Op: -1, Children: components}, nil
+	default:
+		return nil, p.errorf("unexpected '%s'", tok.Text)
+	}
+
+	return &Node{Kind: Primary, Start: start, End: p.pos, Op: -1}, nil
+}
+
+// list parses a comma separated list between open and close. Empty
+// components e.g. in '(, b)' are nil. Named arguments e.g. 'f({a: 1})' are
+// skipped as a single component.
+func (p *exprParser) list(open, close string) ([]*Node, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+
+	var items []*Node
+	for p.peek() != close {
+		switch p.peek() {
+		case ",":
+			items = append(items, nil)
+			p.pos++
+			continue
+		case "{":
+			start := p.pos
+			if err := p.skipBalanced("{", "}"); err != nil {
+				return nil, err
+			}
+			items = append(items, &Node{Kind: Primary, Start: start, End: p.pos, Op: -1})
+		default:
+			item, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if p.peek() == "," {
+			p.pos++
+			if p.peek() == close {
+				items = append(items, nil)
+			}
+		} else if p.peek() != close {
+			if p.pos >= p.end {
+				return nil, p.errorf("expected '%s' but the expression ended", close)
+			}
+			return nil, p.errorf("expected ',' or '%s', got '%s'", close, p.tokens[p.pos].Text)
+		}
+	}
+	p.pos++
+
+	return items, nil
+}
+
+// skipBalanced skips tokens from open up to the matching close.
+func (p *exprParser) skipBalanced(open, close string) error {
+	if err := p.expect(open); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; p.pos++ {
+		if p.pos >= p.end {
+			return p.errorf("unclosed '%s'", open)
+		}
+		switch p.peek() {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for the node and all of its descendants, parents first.
+func (n *Node) Walk(fn func(*Node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
@@ -0,0 +1,204 @@
+// Package solidity provides a lightweight tokenizer and an outline of the
+// contracts and functions of Solidity source files. It is not a full parser,
+// it understands just enough of the language to classify files and locate
+// the code to mutate.
+package solidity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind is the category of a token.
+type TokenKind int
+
+const (
+	Identifier TokenKind = iota // Names and keywords e.g. 'balance', 'require', 'function'.
+	Number                      // Number literals e.g. '1', '0xff', '1e18'.
+	String                      // String literals including the quotes e.g. '"hello"'.
+	Punct                       // Operators and punctuation e.g. '+=', '{', ';'.
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case Identifier:
+		return "identifier"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	default:
+		return "punctuation"
+	}
+}
+
+// Token is a single token of a source file. Comments and whitespace are not
+// tokens.
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Offset int // Byte offset of the token in the source.
+	Line   int // 1-based line of the token.
+	Col    int // 1-based column (in bytes) of the token.
+}
+
+// End returns the byte offset right after the token.
+func (t Token) End() int {
+	return t.Offset + len(t.Text)
+}
+
+// punctuators lists the multi-character operators, longest first so that the
+// longest match wins.
+var punctuators = []string{
+	">>>=",
+	">>>", "<<=", ">>=",
+	"**", "==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "*=", "/=", "%=",
+	"&=", "|=", "^=", "<<", ">>", "=>", "->", ":=",
+}
+
+// Tokenize splits Solidity source code into tokens. It fails on unterminated
+// strings and comments.
+func Tokenize(source string) ([]Token, error) {
+	var tokens []Token
+	line, lineStart := 1, 0
+
+	for i := 0; i < len(source); {
+		c := source[i]
+
+		switch {
+		case c == '\n':
+			line++
+			lineStart = i + 1
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			comment := source[i : i+2+end+2]
+			if newlines := strings.Count(comment, "\n"); newlines > 0 {
+				line += newlines
+				lineStart = i + strings.LastIndexByte(comment, '\n') + 1
+			}
+			i += len(comment)
+			continue
+		}
+
+		start := i
+		var kind TokenKind
+		switch {
+		case isIdentStart(c):
+			kind = Identifier
+			for i < len(source) && isIdentPart(source[i]) {
+				i++
+			}
+		case isDigit(c) || (c == '.' && i+1 < len(source) && isDigit(source[i+1])):
+			kind = Number
+			i = scanNumber(source, i)
+		case c == '"' || c == '\'':
+			kind = String
+			end, ok := scanString(source, i)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i = end
+		default:
+			kind = Punct
+			i++
+			for _, p := range punctuators {
+				if strings.HasPrefix(source[start:], p) {
+					i = start + len(p)
+					break
+				}
+			}
+		}
+
+		tokens = append(tokens, Token{
+			Kind:   kind,
+			Text:   source[start:i],
+			Offset: start,
+			Line:   line,
+			Col:    start - lineStart + 1,
+		})
+	}
+
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanNumber returns the end of the number literal starting at i e.g.
+// '0xff', '1_000', '2.5e18'.
+func scanNumber(source string, i int) int {
+	if strings.HasPrefix(source[i:], "0x") || strings.HasPrefix(source[i:], "0X") {
+		i += 2
+		for i < len(source) && (isHexDigit(source[i]) || source[i] == '_') {
+			i++
+		}
+		return i
+	}
+
+	for i < len(source) && (isDigit(source[i]) || source[i] == '_') {
+		i++
+	}
+	if i+1 < len(source) && source[i] == '.' && isDigit(source[i+1]) {
+		i++
+		for i < len(source) && (isDigit(source[i]) || source[i] == '_') {
+			i++
+		}
+	}
+	if i < len(source) && (source[i] == 'e' || source[i] == 'E') {
+		j := i + 1
+		if j < len(source) && source[j] == '-' {
+			j++
+		}
+		if j < len(source) && isDigit(source[j]) {
+			i = j
+			for i < len(source) && isDigit(source[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// scanString returns the end of the string literal starting at i.
+func scanString(source string, i int) (int, bool) {
+	quote := source[i]
+	for i++; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '\n':
+			return i, false
+		case quote:
+			return i + 1, true
+		}
+	}
+	return i, false
+}
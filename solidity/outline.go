@@ -0,0 +1,241 @@
+package solidity
+
+import "fmt"
+
+// ContractKind is the kind of a top level declaration.
+type ContractKind string
+
+const (
+	KindContract  ContractKind = "contract"
+	KindInterface ContractKind = "interface"
+	KindLibrary   ContractKind = "library"
+)
+
+// Function is a function-like declaration: a function, modifier,
+// constructor, fallback or receive function.
+type Function struct {
+	Kind    string // "function", "modifier", "constructor", "fallback" or "receive".
+	Name    string // Name of the function, equal to Kind for constructor, fallback and receive.
+	HasBody bool   // Whether the function is implemented, false for declarations ending with ';'.
+
+	Start     int // Index of the first token of the declaration.
+	BodyStart int // Index of the body's opening brace, -1 without a body.
+	End       int // Index of the last token of the declaration, its closing brace or ';'.
+}
+
+// Contract is a contract, interface or library.
+type Contract struct {
+	Kind      ContractKind
+	Name      string
+	Abstract  bool
+	Functions []Function
+
+	Start int // Index of the first token of the declaration.
+	End   int // Index of the contract's closing brace.
+}
+
+// SourceUnit is the outline of a source file.
+type SourceUnit struct {
+	Tokens        []Token
+	Contracts     []Contract
+	FreeFunctions []Function // Functions declared outside of contracts.
+}
+
+// Parse tokenizes the source and outlines its contracts and functions.
+func Parse(source string) (*SourceUnit, error) {
+	tokens, err := Tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := &SourceUnit{Tokens: tokens}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Kind != Identifier {
+			continue
+		}
+
+		switch tok.Text {
+		case "abstract", "contract", "interface", "library":
+			contract, end, err := parseContract(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			unit.Contracts = append(unit.Contracts, contract)
+			i = end
+		case "function":
+			function, end, err := parseFunction(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			unit.FreeFunctions = append(unit.FreeFunctions, function)
+			i = end
+		case "import", "pragma", "using", "error", "event", "type":
+			i = skipTo(tokens, i, ";")
+		case "struct", "enum":
+			if open := skipTo(tokens, i, "{"); open < len(tokens) {
+				i = matchingBrace(tokens, open)
+			}
+		}
+	}
+
+	return unit, nil
+}
+
+func parseContract(tokens []Token, start int) (Contract, int, error) {
+	contract := Contract{Start: start}
+
+	i := start
+	if tokens[i].Text == "abstract" {
+		contract.Abstract = true
+		i++
+	}
+	if i >= len(tokens) {
+		return contract, 0, fmt.Errorf("line %d: unexpected end of file", tokens[start].Line)
+	}
+	contract.Kind = ContractKind(tokens[i].Text)
+	if i+1 < len(tokens) && tokens[i+1].Kind == Identifier {
+		contract.Name = tokens[i+1].Text
+	}
+
+	open := skipTo(tokens, i, "{")
+	if open >= len(tokens) {
+		return contract, 0, fmt.Errorf("line %d: %s %s has no body", tokens[start].Line, contract.Kind, contract.Name)
+	}
+	contract.End = matchingBrace(tokens, open)
+	if contract.End >= len(tokens) {
+		return contract, 0, fmt.Errorf("line %d: unclosed %s %s", tokens[start].Line, contract.Kind, contract.Name)
+	}
+
+	// Members are at depth 1, function bodies are skipped as a whole.
+	for j := open + 1; j < contract.End; j++ {
+		switch tokens[j].Text {
+		case "function", "modifier", "constructor", "fallback", "receive":
+			if tokens[j].Kind != Identifier {
+				continue
+			}
+			function, end, err := parseFunction(tokens, j)
+			if err != nil {
+				return contract, 0, err
+			}
+			// Function types of state variables e.g. 'function(uint) external f;'.
+			if function.Kind == "function" && function.Name == "" && !function.HasBody {
+				j = end
+				continue
+			}
+			contract.Functions = append(contract.Functions, function)
+			j = end
+		case "{":
+			// Struct and enum bodies.
+			j = matchingBrace(tokens, j)
+		}
+	}
+
+	return contract, contract.End, nil
+}
+
+func parseFunction(tokens []Token, start int) (Function, int, error) {
+	function := Function{Kind: tokens[start].Text, Start: start, BodyStart: -1}
+	switch function.Kind {
+	case "function", "modifier":
+		if start+1 < len(tokens) && tokens[start+1].Kind == Identifier {
+			function.Name = tokens[start+1].Text
+		}
+	default:
+		function.Name = function.Kind
+	}
+
+	// The declaration ends with its body or a ';' outside of the parameter lists.
+	depth := 0
+	for i := start + 1; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ";":
+			if depth == 0 {
+				function.End = i
+				return function, i, nil
+			}
+		case "{":
+			if depth == 0 {
+				function.HasBody = true
+				function.BodyStart = i
+				function.End = matchingBrace(tokens, i)
+				if function.End >= len(tokens) {
+					return function, 0, fmt.Errorf("line %d: unclosed body of %s %s", tokens[start].Line, function.Kind, function.Name)
+				}
+				return function, function.End, nil
+			}
+		}
+	}
+
+	return function, 0, fmt.Errorf("line %d: unterminated %s %s", tokens[start].Line, function.Kind, function.Name)
+}
+
+// skipTo returns the index of the next token with the given text, or
+// len(tokens) if there is none.
+func skipTo(tokens []Token, i int, text string) int {
+	for ; i < len(tokens); i++ {
+		if tokens[i].Kind == Punct && tokens[i].Text == text {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at open, or
+// len(tokens) if it is not closed.
+func matchingBrace(tokens []Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].Kind != Punct {
+			continue
+		}
+		switch tokens[i].Text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// HasImplementation reports whether the contract has any function, modifier
+// or constructor with a body.
+func (c Contract) HasImplementation() bool {
+	for _, function := range c.Functions {
+		if function.HasBody {
+			return true
+		}
+	}
+	return false
+}
+
+// FunctionAt returns the function whose declaration contains the token at
+// index i, including free functions.
+func (u *SourceUnit) FunctionAt(i int) (*Contract, *Function, bool) {
+	for c := range u.Contracts {
+		contract := &u.Contracts[c]
+		if i < contract.Start || i > contract.End {
+			continue
+		}
+		for f := range contract.Functions {
+			if function := &contract.Functions[f]; i >= function.Start && i <= function.End {
+				return contract, function, true
+			}
+		}
+		return contract, nil, false
+	}
+	for f := range u.FreeFunctions {
+		if function := &u.FreeFunctions[f]; i >= function.Start && i <= function.End {
+			return nil, function, true
+		}
+	}
+	return nil, nil, false
+}
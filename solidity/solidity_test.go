@@ -0,0 +1,171 @@
+package solidity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	source := `x >>= 0x1F_ff; // comment
+/* multi
+   line */ s = "a\"b" + 'c';
+y=1.5e18**2;`
+
+	tokens, err := Tokenize(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	for _, tok := range tokens {
+		texts = append(texts, tok.Text)
+	}
+	want := []string{"x", ">>=", "0x1F_ff", ";", "s", "=", `"a\"b"`, "+", "'c'", ";", "y", "=", "1.5e18", "**", "2", ";"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Fatalf("got  %q\nwant %q", texts, want)
+	}
+
+	// Positions account for the comments.
+	if s := tokens[4]; s.Line != 3 || s.Col != 12 || source[s.Offset:s.End()] != "s" {
+		t.Fatalf("unexpected position of 's': %+v", s)
+	}
+	if y := tokens[10]; y.Line != 4 || y.Col != 1 {
+		t.Fatalf("unexpected position of 'y': %+v", y)
+	}
+
+	for _, invalid := range []string{`s = "unterminated;`, "/* unterminated"} {
+		if _, err := Tokenize(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+const outlineSource = `pragma solidity ^0.8.0;
+import {IERC20} from "./IERC20.sol";
+
+error Unauthorized();
+
+function freeHelper(uint a) pure returns (uint) { return a + 1; }
+
+interface IVault {
+    function deposit(uint amount) external;
+}
+
+abstract contract Base {
+    struct Position { uint size; }
+    function(uint) external callback;
+    function hook() internal virtual;
+}
+
+contract Vault is Base, IVault {
+    modifier onlyOwner() { _; }
+    constructor() { }
+    function deposit(uint amount) external onlyOwner {
+        if (amount > 0) { total += amount; }
+    }
+    function hook() internal override {}
+    receive() external payable {}
+}
+`
+
+func TestParse(t *testing.T) {
+	unit, err := Parse(outlineSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unit.FreeFunctions) != 1 || unit.FreeFunctions[0].Name != "freeHelper" || !unit.FreeFunctions[0].HasBody {
+		t.Fatalf("unexpected free functions: %+v", unit.FreeFunctions)
+	}
+
+	type summary struct {
+		Kind        ContractKind
+		Name        string
+		Abstract    bool
+		Functions   []string
+		Implemented bool
+	}
+	var got []summary
+	for _, contract := range unit.Contracts {
+		s := summary{Kind: contract.Kind, Name: contract.Name, Abstract: contract.Abstract, Implemented: contract.HasImplementation()}
+		for _, function := range contract.Functions {
+			s.Functions = append(s.Functions, function.Name)
+		}
+		got = append(got, s)
+	}
+	want := []summary{
+		{Kind: KindInterface, Name: "IVault", Functions: []string{"deposit"}},
+		{Kind: KindContract, Name: "Base", Abstract: true, Functions: []string{"hook"}},
+		{Kind: KindContract, Name: "Vault", Functions: []string{"onlyOwner", "constructor", "deposit", "hook", "receive"}, Implemented: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got  %+v\nwant %+v", got, want)
+	}
+
+	// The token '+=' in Vault.deposit belongs to that function.
+	for i, tok := range unit.Tokens {
+		if tok.Text != "+=" {
+			continue
+		}
+		contract, function, ok := unit.FunctionAt(i)
+		if !ok || contract.Name != "Vault" || function.Name != "deposit" {
+			t.Fatalf("expected '+=' to be in Vault.deposit, got %v %v", contract, function)
+		}
+	}
+
+	if _, err := Parse("contract A { function f() public {"); err == nil {
+		t.Fatal("expected an error for an unclosed contract")
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := map[string]string{
+		"a + b * c":                               "(a + (b * c))",
+		"a ** b ** c":                             "(a ** (b ** c))",
+		"x = c ? a : b":                           "(x = (c ? a : b))",
+		"balances[msg.sender] -= amount - fee":    "(balances[msg.sender] -= (amount - fee))",
+		"!paused && i++ < 10":                     "((!paused) && ((i++) < 10))",
+		"addr.call{value: 1 ether}(\"\")":         "addr.call{value: 1 ether}(\"\")",
+		"(a, , b) == abi.decode(d, (uint, bool))": "((a, , b) == abi.decode(d, (uint, bool)))",
+		"new uint256[](n) == x[1:]":               "(new uint256[](n) == x[1:])",
+	}
+
+	for source, want := range tests {
+		tokens, err := Tokenize(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := ParseExpression(tokens, 0, len(tokens))
+		if err != nil {
+			t.Errorf("ParseExpression(%q): %v", source, err)
+			continue
+		}
+		if got := render(source, tokens, node); got != want {
+			t.Errorf("ParseExpression(%q) = %s, want %s", source, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"a +", "(a", "a b", "f(,"} {
+		tokens, _ := Tokenize(invalid)
+		if _, err := ParseExpression(tokens, 0, len(tokens)); err == nil {
+			t.Errorf("ParseExpression(%q): expected an error", invalid)
+		}
+	}
+}
+
+// render parenthesizes operators to show the structure of the expression.
+func render(source string, tokens []Token, n *Node) string {
+	text := func(n *Node) string { return source[tokens[n.Start].Offset:tokens[n.End-1].End()] }
+	switch n.Kind {
+	case Binary, Assignment:
+		return "(" + render(source, tokens, n.Children[0]) + " " + tokens[n.Op].Text + " " + render(source, tokens, n.Children[1]) + ")"
+	case Conditional:
+		return "(" + render(source, tokens, n.Children[0]) + " ? " + render(source, tokens, n.Children[1]) + " : " + render(source, tokens, n.Children[2]) + ")"
+	case Unary:
+		return "(" + tokens[n.Op].Text + render(source, tokens, n.Children[0]) + ")"
+	case Postfix:
+		return "(" + render(source, tokens, n.Children[0]) + tokens[n.Op].Text + ")"
+	default:
+		return text(n)
+	}
+}
@@ -0,0 +1,125 @@
+// Package subsumption finds the redundant mutants from the tests that kill
+// them. A killed mutant A subsumes a mutant B if every test that kills A also
+// kills B: a test suite that kills A is guaranteed to kill B, so B says
+// nothing new about the tests.
+package subsumption
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ChmielewskiKamil/checkmate/mutator"
+)
+
+// Mutant is a tested mutant with the tests that killed it. Survivors have no
+// tests.
+type Mutant struct {
+	ID    string
+	Tests []string
+}
+
+// Group is a set of killed mutants that are killed by exactly the same tests.
+// They subsume each other, so any one of them represents the group.
+type Group struct {
+	Mutants []string // IDs ordered numerically, the first one represents the group.
+	Tests   []string // The tests killing every mutant of the group, sorted.
+}
+
+// Analysis is the outcome of the subsumption analysis.
+type Analysis struct {
+	// Dominators are the groups no other group subsumes, ordered by their first
+	// mutant. Their representatives form the dominator set.
+	Dominators []Group
+	// Subsumed holds the IDs of the killed mutants outside of the dominator
+	// set: the mutants of subsumed groups and all but the first mutant of
+	// every dominator group.
+	Subsumed []string
+	// Survivors holds the IDs of the mutants no test killed. Without tests
+	// they can't be compared, each one counts on its own.
+	Survivors []string
+	// Score is the subsumption-adjusted mutation score in percent: the share
+	// of killed dominators among the dominators and survivors.
+	Score float32
+}
+
+// Analyze computes the dominator set of the mutants.
+func Analyze(mutants []Mutant) Analysis {
+	var analysis Analysis
+
+	groups := make(map[string]*Group)
+	for _, m := range mutants {
+		if len(m.Tests) == 0 {
+			analysis.Survivors = append(analysis.Survivors, m.ID)
+			continue
+		}
+		tests := uniqueSorted(m.Tests)
+		key := strings.Join(tests, "\x00")
+		group, ok := groups[key]
+		if !ok {
+			group = &Group{Tests: tests}
+			groups[key] = group
+		}
+		group.Mutants = append(group.Mutants, m.ID)
+	}
+
+	all := make([]*Group, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Mutants, func(i, j int) bool { return mutator.LessID(group.Mutants[i], group.Mutants[j]) })
+		all = append(all, group)
+	}
+	sort.Slice(all, func(i, j int) bool { return mutator.LessID(all[i].Mutants[0], all[j].Mutants[0]) })
+
+	for _, group := range all {
+		dominated := false
+		for _, other := range all {
+			if other != group && isStrictSubset(other.Tests, group.Tests) {
+				dominated = true
+				break
+			}
+		}
+		if dominated {
+			analysis.Subsumed = append(analysis.Subsumed, group.Mutants...)
+			continue
+		}
+		analysis.Dominators = append(analysis.Dominators, *group)
+		analysis.Subsumed = append(analysis.Subsumed, group.Mutants[1:]...)
+	}
+	sort.Slice(analysis.Subsumed, func(i, j int) bool { return mutator.LessID(analysis.Subsumed[i], analysis.Subsumed[j]) })
+	sort.Slice(analysis.Survivors, func(i, j int) bool { return mutator.LessID(analysis.Survivors[i], analysis.Survivors[j]) })
+
+	if total := len(analysis.Dominators) + len(analysis.Survivors); total > 0 {
+		analysis.Score = float32(len(analysis.Dominators)) / float32(total) * 100
+	}
+	return analysis
+}
+
+// isStrictSubset reports whether the sorted set a is a proper subset of the
+// sorted set b.
+func isStrictSubset(a, b []string) bool {
+	if len(a) >= len(b) {
+		return false
+	}
+	j := 0
+	for _, test := range a {
+		for j < len(b) && b[j] < test {
+			j++
+		}
+		if j == len(b) || b[j] != test {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+func uniqueSorted(list []string) []string {
+	sorted := append([]string{}, list...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
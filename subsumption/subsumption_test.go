@@ -0,0 +1,40 @@
+package subsumption
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	analysis := Analyze([]Mutant{
+		{ID: "1", Tests: []string{"testWithdraw"}},
+		// Killed by testWithdraw too, so mutant 1 subsumes it.
+		{ID: "2", Tests: []string{"testWithdraw", "testDeposit"}},
+		// Same tests as mutant 1, redundant.
+		{ID: "10", Tests: []string{"testWithdraw", "testWithdraw"}},
+		{ID: "3", Tests: []string{"testDeposit", "testPause"}},
+		{ID: "4", Tests: []string{"testPause"}},
+		{ID: "5", Tests: []string{"testOwner"}},
+		{ID: "6"},
+	})
+
+	if got, want := fmt.Sprint(analysis.Dominators), "[{[1 10] [testWithdraw]} {[4] [testPause]} {[5] [testOwner]}]"; got != want {
+		t.Errorf("got dominators %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(analysis.Subsumed), "[2 3 10]"; got != want {
+		t.Errorf("got subsumed %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(analysis.Survivors), "[6]"; got != want {
+		t.Errorf("got survivors %s, want %s", got, want)
+	}
+	if analysis.Score != 75 {
+		t.Errorf("got score %.2f, want 75", analysis.Score)
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	analysis := Analyze(nil)
+	if len(analysis.Dominators) != 0 || analysis.Score != 0 {
+		t.Errorf("unexpected analysis %+v", analysis)
+	}
+}